@@ -32,7 +32,7 @@ import (
 var runtimeAttachCommand = &cli.Command{
 	Name:                   "attach",
 	Usage:                  "Attach to a running container",
-	ArgsUsage:              "CONTAINER-ID",
+	ArgsUsage:              "CONTAINER-ID|NAMESPACE/POD[/CONTAINER]",
 	UseShortOptionHandling: true,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -85,6 +85,11 @@ var runtimeAttachCommand = &cli.Command{
 			return err
 		}
 
+		id, err = resolveContainerID(c.Context, runtimeClient, id)
+		if err != nil {
+			return err
+		}
+
 		ctx, cancel := context.WithCancel(c.Context)
 		defer cancel()
 
@@ -0,0 +1,400 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/common"
+)
+
+const (
+	auditResultSuccess = "success"
+	auditResultFailure = "failure"
+
+	// auditRedacted replaces the values of credential flags in the audit log.
+	auditRedacted = "REDACTED"
+)
+
+// auditCredentialFlags are the flags, whose values are not written to the
+// audit log, like the registry credentials of pull.
+var auditCredentialFlags = []string{"creds", "auth", "username", "u", "password", "identity-token", "registry-token"}
+
+// auditRecord is a single line of the audit log, which describes an
+// invocation of crictl sending RPCs that modify the runtime.
+type auditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	UID       string    `json:"uid"`
+	// SudoUser is the user who invoked crictl through sudo, if any.
+	SudoUser string `json:"sudoUser,omitempty"`
+	Endpoint string `json:"endpoint"`
+	// ImageEndpoint is only set if it differs from the runtime endpoint.
+	ImageEndpoint string     `json:"imageEndpoint,omitempty"`
+	Command       []string   `json:"command"`
+	Targets       []string   `json:"targets"`
+	RPCs          []auditRPC `json:"rpcs"`
+	Result        string     `json:"result"`
+	Error         string     `json:"error,omitempty"`
+}
+
+type auditRPC struct {
+	Name   string `json:"name"`
+	Target string `json:"target,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// auditLog records the RPCs of an invocation which modify the runtime. The
+// file is opened before the first of them is sent, so that they fail if the
+// invocation cannot be audited.
+type auditLog struct {
+	path    string
+	started time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	rpcs []auditRPC
+}
+
+func newAuditLog(path string) *auditLog {
+	return &auditLog{path: path, started: time.Now()}
+}
+
+// record sends an RPC by calling send, which returns the ID of the affected
+// object, and records its result.
+func (a *auditLog) record(rpc string, send func() (string, error)) error {
+	if err := a.open(); err != nil {
+		return err
+	}
+
+	target, err := send()
+
+	entry := auditRPC{Name: rpc, Target: target}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	a.rpcs = append(a.rpcs, entry)
+	a.mu.Unlock()
+
+	return err
+}
+
+func (a *auditLog) open() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file != nil {
+		return nil
+	}
+
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	a.file = file
+
+	return nil
+}
+
+// write appends the record of the invocation to the audit log, unless no RPC
+// has been recorded.
+func (a *auditLog) write(record *auditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+
+	record.Timestamp = a.started
+	record.RPCs = a.rpcs
+	record.Targets = []string{}
+
+	for _, rpc := range a.rpcs {
+		if rpc.Target != "" && !slices.Contains(record.Targets, rpc.Target) {
+			record.Targets = append(record.Targets, rpc.Target)
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+
+	// A single write keeps the concurrently appended records intact.
+	_, err = a.file.Write(append(data, '\n'))
+	if err != nil {
+		err = fmt.Errorf("write audit log: %w", err)
+	}
+
+	return errors.Join(err, a.file.Close())
+}
+
+// writeAuditLog appends the record of the invocation to the audit log, if
+// enabled.
+func (cfg *CrictlConfig) writeAuditLog(args []string, invocationErr error) error {
+	if cfg.audit == nil {
+		return nil
+	}
+
+	record := &auditRecord{
+		UID:      strconv.Itoa(os.Getuid()),
+		SudoUser: os.Getenv("SUDO_USER"),
+		Command:  redactCredentials(args),
+		Result:   auditResultSuccess,
+	}
+
+	if current, err := user.Current(); err == nil {
+		record.User = current.Username
+	}
+
+	record.Endpoint, record.ImageEndpoint = cfg.connectedEndpoints()
+	if record.ImageEndpoint == record.Endpoint {
+		record.ImageEndpoint = ""
+	}
+
+	if invocationErr != nil {
+		record.Result = auditResultFailure
+		record.Error = invocationErr.Error()
+	}
+
+	return cfg.audit.write(record)
+}
+
+// redactCredentials returns the command line with the values of the
+// credential flags replaced, both in the --flag=value and --flag value form.
+func redactCredentials(args []string) []string {
+	redacted := slices.Clone(args)
+
+	for i := 0; i < len(redacted); i++ {
+		arg := redacted[i]
+		if !strings.HasPrefix(arg, "-") || arg == "--" {
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !slices.Contains(auditCredentialFlags, name) {
+			continue
+		}
+
+		if hasValue {
+			redacted[i] = arg[:strings.Index(arg, "=")+1] + auditRedacted
+		} else if i+1 < len(redacted) {
+			i++
+			redacted[i] = auditRedacted
+		}
+	}
+
+	return redacted
+}
+
+// connectedEndpoints returns the runtime and image endpoints the clients
+// are connected to.
+func (cfg *CrictlConfig) connectedEndpoints() (runtimeEndpoint, imageEndpoint string) {
+	runtimeEndpoint, imageEndpoint = cfg.RuntimeEndpoint, cfg.ImageEndpoint
+
+	if cfg.discovered != nil {
+		if !cfg.RuntimeEndpointIsSet || runtimeEndpoint == common.EndpointAuto {
+			runtimeEndpoint = cfg.discovered.runtimeEndpoint
+		}
+
+		if !cfg.ImageEndpointIsSet || imageEndpoint == common.EndpointAuto {
+			imageEndpoint = cfg.discovered.imageEndpoint
+			if imageEndpoint == "" {
+				imageEndpoint = cfg.discovered.runtimeEndpoint
+			}
+		}
+	}
+
+	if imageEndpoint == "" {
+		imageEndpoint = runtimeEndpoint
+	}
+
+	return runtimeEndpoint, imageEndpoint
+}
+
+// auditRuntimeService records all RPCs of the runtime service which modify
+// the runtime, as well as the streaming RPCs.
+type auditRuntimeService struct {
+	internalapi.RuntimeService
+
+	log *auditLog
+}
+
+func (a *auditRuntimeService) RunPodSandbox(ctx context.Context, config *runtimeapi.PodSandboxConfig, runtimeHandler string) (podSandboxID string, err error) {
+	err = a.log.record("RunPodSandbox", func() (string, error) {
+		podSandboxID, err = a.RuntimeService.RunPodSandbox(ctx, config, runtimeHandler)
+
+		return podSandboxID, err
+	})
+
+	return podSandboxID, err
+}
+
+func (a *auditRuntimeService) StopPodSandbox(ctx context.Context, podSandboxID string) error {
+	return a.log.record("StopPodSandbox", func() (string, error) {
+		return podSandboxID, a.RuntimeService.StopPodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (a *auditRuntimeService) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	return a.log.record("RemovePodSandbox", func() (string, error) {
+		return podSandboxID, a.RuntimeService.RemovePodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (a *auditRuntimeService) UpdatePodSandboxResources(ctx context.Context, req *runtimeapi.UpdatePodSandboxResourcesRequest) (resp *runtimeapi.UpdatePodSandboxResourcesResponse, err error) {
+	err = a.log.record("UpdatePodSandboxResources", func() (string, error) {
+		resp, err = a.RuntimeService.UpdatePodSandboxResources(ctx, req)
+
+		return req.GetPodSandboxId(), err
+	})
+
+	return resp, err
+}
+
+func (a *auditRuntimeService) CreateContainer(ctx context.Context, podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (containerID string, err error) {
+	err = a.log.record("CreateContainer", func() (string, error) {
+		containerID, err = a.RuntimeService.CreateContainer(ctx, podSandboxID, config, sandboxConfig)
+
+		return containerID, err
+	})
+
+	return containerID, err
+}
+
+func (a *auditRuntimeService) StartContainer(ctx context.Context, containerID string) error {
+	return a.log.record("StartContainer", func() (string, error) {
+		return containerID, a.RuntimeService.StartContainer(ctx, containerID)
+	})
+}
+
+func (a *auditRuntimeService) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	return a.log.record("StopContainer", func() (string, error) {
+		return containerID, a.RuntimeService.StopContainer(ctx, containerID, timeout)
+	})
+}
+
+func (a *auditRuntimeService) RemoveContainer(ctx context.Context, containerID string) error {
+	return a.log.record("RemoveContainer", func() (string, error) {
+		return containerID, a.RuntimeService.RemoveContainer(ctx, containerID)
+	})
+}
+
+func (a *auditRuntimeService) UpdateContainerResources(ctx context.Context, containerID string, resources *runtimeapi.ContainerResources) error {
+	return a.log.record("UpdateContainerResources", func() (string, error) {
+		return containerID, a.RuntimeService.UpdateContainerResources(ctx, containerID, resources)
+	})
+}
+
+func (a *auditRuntimeService) ReopenContainerLog(ctx context.Context, containerID string) error {
+	return a.log.record("ReopenContainerLog", func() (string, error) {
+		return containerID, a.RuntimeService.ReopenContainerLog(ctx, containerID)
+	})
+}
+
+func (a *auditRuntimeService) CheckpointContainer(ctx context.Context, req *runtimeapi.CheckpointContainerRequest) error {
+	return a.log.record("CheckpointContainer", func() (string, error) {
+		return req.GetContainerId(), a.RuntimeService.CheckpointContainer(ctx, req)
+	})
+}
+
+func (a *auditRuntimeService) UpdateRuntimeConfig(ctx context.Context, runtimeConfig *runtimeapi.RuntimeConfig) error {
+	return a.log.record("UpdateRuntimeConfig", func() (string, error) {
+		return "", a.RuntimeService.UpdateRuntimeConfig(ctx, runtimeConfig)
+	})
+}
+
+func (a *auditRuntimeService) ExecSync(ctx context.Context, containerID string, cmd []string, timeout time.Duration) (stdout, stderr []byte, err error) {
+	err = a.log.record("ExecSync", func() (string, error) {
+		stdout, stderr, err = a.RuntimeService.ExecSync(ctx, containerID, cmd, timeout)
+
+		return containerID, err
+	})
+
+	return stdout, stderr, err
+}
+
+func (a *auditRuntimeService) Exec(ctx context.Context, req *runtimeapi.ExecRequest) (resp *runtimeapi.ExecResponse, err error) {
+	err = a.log.record("Exec", func() (string, error) {
+		resp, err = a.RuntimeService.Exec(ctx, req)
+
+		return req.GetContainerId(), err
+	})
+
+	return resp, err
+}
+
+func (a *auditRuntimeService) Attach(ctx context.Context, req *runtimeapi.AttachRequest) (resp *runtimeapi.AttachResponse, err error) {
+	err = a.log.record("Attach", func() (string, error) {
+		resp, err = a.RuntimeService.Attach(ctx, req)
+
+		return req.GetContainerId(), err
+	})
+
+	return resp, err
+}
+
+func (a *auditRuntimeService) PortForward(ctx context.Context, req *runtimeapi.PortForwardRequest) (resp *runtimeapi.PortForwardResponse, err error) {
+	err = a.log.record("PortForward", func() (string, error) {
+		resp, err = a.RuntimeService.PortForward(ctx, req)
+
+		return req.GetPodSandboxId(), err
+	})
+
+	return resp, err
+}
+
+// auditImageService records all RPCs of the image service which modify the
+// images.
+type auditImageService struct {
+	internalapi.ImageManagerService
+
+	log *auditLog
+}
+
+func (a *auditImageService) PullImage(ctx context.Context, image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig, podSandboxConfig *runtimeapi.PodSandboxConfig) (imageRef string, err error) {
+	err = a.log.record("PullImage", func() (string, error) {
+		imageRef, err = a.ImageManagerService.PullImage(ctx, image, auth, podSandboxConfig)
+
+		return image.GetImage(), err
+	})
+
+	return imageRef, err
+}
+
+func (a *auditImageService) RemoveImage(ctx context.Context, image *runtimeapi.ImageSpec) error {
+	return a.log.record("RemoveImage", func() (string, error) {
+		return image.GetImage(), a.ImageManagerService.RemoveImage(ctx, image)
+	})
+}
@@ -0,0 +1,214 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+var errFakeNotFound = errors.New("container not found")
+
+// fakeAuditRuntimeSvc implements the RPCs used to verify the audit log.
+type fakeAuditRuntimeSvc struct {
+	internalapi.RuntimeService
+}
+
+func (fakeAuditRuntimeSvc) RunPodSandbox(context.Context, *runtimeapi.PodSandboxConfig, string) (string, error) {
+	return "pod", nil
+}
+
+func (fakeAuditRuntimeSvc) RemoveContainer(context.Context, string) error {
+	return errFakeNotFound
+}
+
+func (fakeAuditRuntimeSvc) ListPodSandbox(context.Context, *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	return nil, nil
+}
+
+func readAuditRecords(g *WithT, path string) []auditRecord {
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	records := []auditRecord{}
+
+	for line := range bytes.Lines(data) {
+		record := auditRecord{}
+		g.Expect(json.Unmarshal(line, &record)).To(Succeed())
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	invoke := func(args []string, call func(internalapi.RuntimeService, internalapi.ImageManagerService) error) {
+		cfg := &CrictlConfig{
+			RuntimeEndpoint:        "unix:///run/containerd/containerd.sock",
+			RuntimeEndpointIsSet:   true,
+			audit:                  newAuditLog(path),
+			runtimeServiceOverride: fakeAuditRuntimeSvc{},
+			imageServiceOverride:   fakeImageSvc{},
+		}
+
+		rs, err := cfg.GetRuntimeService(ctx, 0)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		is, err := cfg.GetImageService(ctx)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(cfg.writeAuditLog(args, call(rs, is))).To(Succeed())
+	}
+
+	invoke([]string{"crictl", "runp", "pod.json"}, func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+		_, err := rs.RunPodSandbox(ctx, &runtimeapi.PodSandboxConfig{}, "")
+
+		return err
+	})
+
+	// Invocations without mutating RPCs are not recorded.
+	invoke([]string{"crictl", "pods"}, func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+		_, err := rs.ListPodSandbox(ctx, nil)
+
+		return err
+	})
+
+	invoke([]string{"crictl", "rm", "ctr"}, func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+		return rs.RemoveContainer(ctx, "ctr")
+	})
+
+	records := readAuditRecords(g, path)
+	g.Expect(records).To(HaveLen(2))
+
+	g.Expect(records[0].Command).To(Equal([]string{"crictl", "runp", "pod.json"}))
+	g.Expect(records[0].Endpoint).To(Equal("unix:///run/containerd/containerd.sock"))
+	g.Expect(records[0].ImageEndpoint).To(BeEmpty())
+	g.Expect(records[0].Targets).To(Equal([]string{"pod"}))
+	g.Expect(records[0].RPCs).To(Equal([]auditRPC{{Name: "RunPodSandbox", Target: "pod"}}))
+	g.Expect(records[0].Result).To(Equal(auditResultSuccess))
+	g.Expect(records[0].Error).To(BeEmpty())
+	g.Expect(records[0].UID).NotTo(BeEmpty())
+	g.Expect(records[0].Timestamp).NotTo(BeZero())
+
+	g.Expect(records[1].Targets).To(Equal([]string{"ctr"}))
+	g.Expect(records[1].RPCs).To(Equal([]auditRPC{{Name: "RemoveContainer", Target: "ctr", Error: errFakeNotFound.Error()}}))
+	g.Expect(records[1].Result).To(Equal(auditResultFailure))
+	g.Expect(records[1].Error).To(Equal(errFakeNotFound.Error()))
+}
+
+// fakeAuditImageSvc implements the RPCs used to verify the audit log of
+// pulls.
+type fakeAuditImageSvc struct {
+	internalapi.ImageManagerService
+}
+
+func (fakeAuditImageSvc) PullImage(_ context.Context, image *runtimeapi.ImageSpec, _ *runtimeapi.AuthConfig, _ *runtimeapi.PodSandboxConfig) (string, error) {
+	return image.GetImage(), nil
+}
+
+func TestAuditLogRedactsCredentials(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := &CrictlConfig{
+		audit:                newAuditLog(path),
+		imageServiceOverride: fakeAuditImageSvc{},
+	}
+
+	is, err := cfg.GetImageService(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = is.PullImage(ctx, &runtimeapi.ImageSpec{Image: "busybox"}, &runtimeapi.AuthConfig{Username: "user", Password: "s3cr3t"}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	args := []string{"crictl", "pull", "--creds", "user:s3cr3t", "--auth=dXNlcjpzM2NyM3Q=", "busybox"}
+	g.Expect(cfg.writeAuditLog(args, nil)).To(Succeed())
+
+	data, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).NotTo(ContainSubstring("s3cr3t"))
+	g.Expect(string(data)).NotTo(ContainSubstring("dXNlcjpzM2NyM3Q="))
+
+	records := readAuditRecords(g, path)
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Command).To(Equal([]string{"crictl", "pull", "--creds", auditRedacted, "--auth=" + auditRedacted, "busybox"}))
+	g.Expect(records[0].RPCs).To(Equal([]auditRPC{{Name: "PullImage", Target: "busybox"}}))
+}
+
+func TestAuditLogRecordsRejectedRPCs(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := &CrictlConfig{
+		ReadOnly:               true,
+		audit:                  newAuditLog(path),
+		runtimeServiceOverride: fakeAuditRuntimeSvc{},
+	}
+
+	rs, err := cfg.GetRuntimeService(ctx, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = rs.RemoveContainer(ctx, "ctr")
+	g.Expect(err).To(MatchError(errReadOnly))
+	g.Expect(cfg.writeAuditLog([]string{"crictl", "rm", "ctr"}, err)).To(Succeed())
+
+	records := readAuditRecords(g, path)
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Result).To(Equal(auditResultFailure))
+	g.Expect(records[0].Error).To(Equal(err.Error()))
+	g.Expect(records[0].RPCs).To(Equal([]auditRPC{{Name: "RemoveContainer", Target: "ctr", Error: err.Error()}}))
+}
+
+func TestAuditLogFailsUnauditedRPCs(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	cfg := &CrictlConfig{
+		audit:                  newAuditLog(filepath.Join(t.TempDir(), "missing", "audit.log")),
+		runtimeServiceOverride: fakeAuditRuntimeSvc{},
+	}
+
+	rs, err := cfg.GetRuntimeService(ctx, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = rs.RunPodSandbox(ctx, &runtimeapi.PodSandboxConfig{}, "")
+	g.Expect(err).To(MatchError(os.ErrNotExist))
+}
@@ -17,17 +17,33 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
 var bashCompletionTemplate = `_crictl() {
     local cur opts base
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ ${COMP_CWORD} -gt 1 && ${cur} != -* ]]; then
+        opts="$(crictl ` + completeCommandName + ` "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)"
+        if [[ -n "${opts}" ]]; then
+            COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
+            return 0
+        fi
+    fi
     opts="%s"
     COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
     return 0
@@ -59,6 +75,15 @@ func bashCompletion(c *cli.Context) error {
 var zshCompletionTemplate = `#compdef crictl
 
 _crictl() {
+  if (( CURRENT > 2 )) && [[ ${words[CURRENT]} != -* ]]; then
+    local -a dynamic
+    dynamic=(${(f)"$(crictl ` + completeCommandName + ` ${words[2,CURRENT-1]} 2>/dev/null)"})
+    if (( ${#dynamic} )); then
+      compadd -a dynamic
+      return
+    fi
+  fi
+
   local -a cmds
   cmds=('%s')
   _describe 'commands' cmds
@@ -104,6 +129,17 @@ func fishCompletion(c *cli.Context) error {
 
 	fmt.Fprintln(c.App.Writer, completion)
 
+	for _, command := range c.App.Commands {
+		if _, ok := completionTargets[command.Name]; !ok {
+			continue
+		}
+
+		fmt.Fprintf(c.App.Writer,
+			"complete -c crictl -f -n '__fish_seen_subcommand_from %s' -a '(crictl %s (commandline -opc)[2..-1] 2>/dev/null)'\n",
+			strings.Join(command.Names(), " "), completeCommandName,
+		)
+	}
+
 	return nil
 }
 
@@ -123,6 +159,11 @@ Examples:
 
     # Installing fish completion on Linux
     crictl completion fish | source
+
+Container, pod and image IDs and names are completed from the runtime
+endpoint of the completed command line, like the one of its --runtime-endpoint,
+--config or --context global flags. The results are cached for a few seconds in the user's
+cache directory to keep the completion fast.
 	`,
 	Action: func(c *cli.Context) error {
 		// select bash by default for backwards compatibility
@@ -146,3 +187,361 @@ Examples:
 		}
 	},
 }
+
+const (
+	// completeCommandName is the hidden command used by the completion
+	// scripts to retrieve candidates from the runtime.
+	completeCommandName = "__complete"
+
+	// completionTimeout is the maximum time spent on connecting to the
+	// runtime and listing the candidates.
+	completionTimeout = time.Second
+
+	// completionCacheTTL is the time the candidates are cached on disk.
+	completionCacheTTL = 5 * time.Second
+
+	completeContainers        = "containers"
+	completeRunningContainers = "running-containers"
+	completePods              = "pods"
+	completeImages            = "images"
+)
+
+// completionTarget describes which candidates a command argument completes to.
+type completionTarget struct {
+	// kind is the type of candidates.
+	kind string
+	// firstArgOnly restricts the completion to the first argument.
+	firstArgOnly bool
+}
+
+var completionTargets = map[string]completionTarget{
+	"attach":       {kind: completeRunningContainers, firstArgOnly: true},
+	"checkpoint":   {kind: completeRunningContainers},
+	"exec":         {kind: completeRunningContainers, firstArgOnly: true},
+	"inspect":      {kind: completeContainers},
+	"inspecti":     {kind: completeImages},
+	"inspectp":     {kind: completePods},
+	"logs":         {kind: completeContainers, firstArgOnly: true},
+	"port-forward": {kind: completePods, firstArgOnly: true},
+	"rm":           {kind: completeContainers},
+	"rmi":          {kind: completeImages},
+	"rmp":          {kind: completePods},
+	"start":        {kind: completeContainers},
+	"stats":        {kind: completeContainers, firstArgOnly: true},
+	"stop":         {kind: completeRunningContainers},
+	"stopp":        {kind: completePods},
+	"update":       {kind: completeRunningContainers},
+}
+
+// completionCandidate is a single completion value as stored in the cache.
+type completionCandidate struct {
+	Value   string `json:"value"`
+	Running bool   `json:"running,omitempty"`
+}
+
+var completeCommand = &cli.Command{
+	Name:      completeCommandName,
+	Usage:     "Print dynamic completion candidates for the provided command line",
+	ArgsUsage: "[WORD...]",
+	Hidden:    true,
+	// The words may contain flags of the completed command.
+	SkipFlagParsing: true,
+	Action: func(c *cli.Context) error {
+		target, ok := completionTargetForWords(c.App, c.Args().Slice())
+		if !ok {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(c.Context, completionTimeout)
+		defer cancel()
+
+		cfg := configFromContext(c)
+		cfg.MaxRetries = 0
+		cfg.Timeout = completionTimeout
+
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			cacheDir = os.TempDir()
+		}
+
+		candidates, err := listCompletionCandidates(ctx, cfg, target.kind, filepath.Join(cacheDir, "crictl"))
+		if err != nil {
+			// Never break the shell completion, just log for debugging.
+			logrus.Debugf("Unable to retrieve completion candidates: %v", err)
+
+			return nil
+		}
+
+		for _, candidate := range candidates {
+			fmt.Fprintln(c.App.Writer, candidate.Value)
+		}
+
+		return nil
+	},
+}
+
+// completionArgs moves the global flags of the completed command line ahead
+// of the __complete command, so that the candidates are retrieved from the
+// runtime selected by them, like `crictl __complete -r unix:///run/crio.sock
+// rmp` becoming `crictl -r unix:///run/crio.sock __complete rmp`. Other
+// arguments are returned as they are.
+func completionArgs(app *cli.App, args []string) []string {
+	if len(args) < 2 || args[1] != completeCommandName {
+		return args
+	}
+
+	words := args[2:]
+	globals := []string{}
+
+	i := 0
+	for ; i < len(words); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(words[i], "-"), "=")
+		if !strings.HasPrefix(words[i], "-") || name == "" {
+			break
+		}
+
+		flag := globalFlag(app, name)
+		if flag == nil {
+			break
+		}
+
+		globals = append(globals, words[i])
+
+		if f, ok := flag.(cli.DocGenerationFlag); ok && f.TakesValue() && !hasValue && i+1 < len(words) {
+			i++
+			globals = append(globals, words[i])
+		}
+	}
+
+	result := append([]string{args[0]}, globals...)
+	result = append(result, completeCommandName)
+
+	return append(result, words[i:]...)
+}
+
+// globalFlag returns the global flag of the app with the name, or nil.
+func globalFlag(app *cli.App, name string) cli.Flag {
+	for _, flag := range app.Flags {
+		if slices.Contains(flag.Names(), name) {
+			return flag
+		}
+	}
+
+	return nil
+}
+
+// completionTargetForWords returns the completion target for the words
+// preceding the completed one, which start after the "crictl" binary name.
+func completionTargetForWords(app *cli.App, words []string) (completionTarget, bool) {
+	for i, word := range words {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+
+		command := app.Command(word)
+		if command == nil {
+			// Most likely the value of a global flag.
+			continue
+		}
+
+		target, ok := completionTargets[command.Name]
+		if !ok {
+			return completionTarget{}, false
+		}
+
+		if target.firstArgOnly {
+			for _, arg := range words[i+1:] {
+				if !strings.HasPrefix(arg, "-") {
+					return completionTarget{}, false
+				}
+			}
+		}
+
+		return target, true
+	}
+
+	return completionTarget{}, false
+}
+
+// listCompletionCandidates returns the candidates of the provided kind,
+// either from the cache in cacheDir or from the runtime.
+func listCompletionCandidates(ctx context.Context, cfg *CrictlConfig, kind, cacheDir string) ([]completionCandidate, error) {
+	cacheKind := kind
+	if kind == completeRunningContainers {
+		cacheKind = completeContainers
+	}
+
+	endpoint := cfg.RuntimeEndpoint
+	if cacheKind == completeImages && cfg.ImageEndpoint != "" {
+		endpoint = cfg.ImageEndpoint
+	}
+
+	cachePath := completionCachePath(cacheDir, cacheKind, endpoint)
+
+	candidates, err := readCompletionCache(cachePath, completionCacheTTL)
+	if err != nil {
+		candidates, err = fetchCompletionCandidates(ctx, cfg, cacheKind)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeCompletionCache(cachePath, candidates); err != nil {
+			logrus.Debugf("Unable to write completion cache: %v", err)
+		}
+	}
+
+	if kind != completeRunningContainers {
+		return candidates, nil
+	}
+
+	running := []completionCandidate{}
+
+	for _, candidate := range candidates {
+		if candidate.Running {
+			running = append(running, candidate)
+		}
+	}
+
+	return running, nil
+}
+
+func fetchCompletionCandidates(ctx context.Context, cfg *CrictlConfig, kind string) ([]completionCandidate, error) {
+	candidates := []completionCandidate{}
+
+	switch kind {
+	case completeContainers:
+		runtimeClient, err := cfg.GetRuntimeService(ctx, completionTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		containers, err := runtimeClient.ListContainers(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list containers: %w", err)
+		}
+
+		sandboxes, err := runtimeClient.ListPodSandbox(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list pod sandboxes: %w", err)
+		}
+
+		sandboxByID := make(map[string]*pb.PodSandbox, len(sandboxes))
+		for _, s := range sandboxes {
+			sandboxByID[s.GetId()] = s
+		}
+
+		for _, c := range containers {
+			running := c.GetState() == pb.ContainerState_CONTAINER_RUNNING
+			namespace, pod, name := k8sContainerRef(c, sandboxByID[c.GetPodSandboxId()])
+			candidates = append(candidates,
+				completionCandidate{Value: getTruncatedID(c.GetId(), ""), Running: running},
+				completionCandidate{Value: namespace + "/" + pod + "/" + name, Running: running},
+			)
+		}
+
+	case completePods:
+		runtimeClient, err := cfg.GetRuntimeService(ctx, completionTimeout)
+		if err != nil {
+			return nil, err
+		}
+
+		sandboxes, err := runtimeClient.ListPodSandbox(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list pod sandboxes: %w", err)
+		}
+
+		for _, s := range sandboxes {
+			candidates = append(candidates,
+				completionCandidate{Value: getTruncatedID(s.GetId(), "")},
+				completionCandidate{Value: s.GetMetadata().GetNamespace() + "/" + s.GetMetadata().GetName()},
+			)
+		}
+
+	case completeImages:
+		imageClient, err := cfg.GetImageService(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		images, err := imageClient.ListImages(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list images: %w", err)
+		}
+
+		for _, image := range images {
+			candidates = append(candidates, completionCandidate{Value: getTruncatedID(image.GetId(), "sha256:")})
+			for _, tag := range image.GetRepoTags() {
+				candidates = append(candidates, completionCandidate{Value: tag})
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown completion kind %q", kind)
+	}
+
+	return candidates, nil
+}
+
+// completionCachePath returns the cache file for the kind of candidates and
+// the endpoint they have been retrieved from.
+func completionCachePath(dir, kind, endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+
+	return filepath.Join(dir, fmt.Sprintf("completion-%s-%x.json", kind, sum[:8]))
+}
+
+func readCompletionCache(path string, ttl time.Duration) ([]completionCandidate, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(info.ModTime()) > ttl {
+		return nil, errors.New("completion cache expired")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []completionCandidate{}
+	if err := json.Unmarshal(data, &candidates); err != nil {
+		return nil, fmt.Errorf("unmarshal completion cache: %w", err)
+	}
+
+	return candidates, nil
+}
+
+func writeCompletionCache(path string, candidates []completionCandidate) error {
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return fmt.Errorf("marshal completion cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that concurrent completions never
+	// read a partially written cache.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompletionTargetForWords(t *testing.T) {
+	t.Parallel()
+
+	app := &cli.App{Commands: []*cli.Command{
+		runtimeExecCommand, removeImageCommand, removePodCommand, listContainersCommand,
+	}}
+
+	testCases := []struct {
+		desc     string
+		words    []string
+		expected string
+	}{
+		{desc: "first exec argument", words: []string{"exec"}, expected: completeRunningContainers},
+		{desc: "exec flags are skipped", words: []string{"exec", "-it"}, expected: completeRunningContainers},
+		{desc: "exec command is not completed", words: []string{"exec", "abc"}},
+		{desc: "global flags are skipped", words: []string{"--debug", "rmi", "busybox"}, expected: completeImages},
+		{desc: "global flag values are skipped", words: []string{"-r", "unix:///run/crio.sock", "rmp"}, expected: completePods},
+		{desc: "command without completion", words: []string{"ps"}},
+		{desc: "no command", words: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			target, ok := completionTargetForWords(app, tc.words)
+			if tc.expected == "" {
+				if ok {
+					t.Fatalf("expected no completion, got %q", target.kind)
+				}
+
+				return
+			}
+
+			if !ok || target.kind != tc.expected {
+				t.Fatalf("expected completion kind %q, got %q (ok: %v)", tc.expected, target.kind, ok)
+			}
+		})
+	}
+}
+
+func TestCompletionArgs(t *testing.T) {
+	t.Parallel()
+
+	app := &cli.App{Flags: []cli.Flag{
+		&cli.StringFlag{Name: "runtime-endpoint", Aliases: []string{"r"}},
+		&cli.StringFlag{Name: "config", Aliases: []string{"c"}},
+		&cli.BoolFlag{Name: "debug", Aliases: []string{"D"}},
+	}}
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected []string
+	}{
+		{
+			desc:     "runtime endpoint is forwarded",
+			args:     []string{"crictl", "__complete", "-r", "unix:///run/crio.sock", "rmp"},
+			expected: []string{"crictl", "-r", "unix:///run/crio.sock", "__complete", "rmp"},
+		},
+		{
+			desc:     "flag values with equal sign and bool flags are forwarded",
+			args:     []string{"crictl", "__complete", "--config=/etc/other.yaml", "-D", "exec", "-it"},
+			expected: []string{"crictl", "--config=/etc/other.yaml", "-D", "__complete", "exec", "-it"},
+		},
+		{
+			desc:     "flags after the command are kept",
+			args:     []string{"crictl", "__complete", "rmi", "-r", "unix:///run/crio.sock"},
+			expected: []string{"crictl", "__complete", "rmi", "-r", "unix:///run/crio.sock"},
+		},
+		{
+			desc:     "other commands are unchanged",
+			args:     []string{"crictl", "ps", "-r", "unix:///run/crio.sock"},
+			expected: []string{"crictl", "ps", "-r", "unix:///run/crio.sock"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			NewWithT(t).Expect(completionArgs(app, tc.args)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestListCompletionCandidates(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	cacheDir := t.TempDir()
+	cfg := &CrictlConfig{
+		RuntimeEndpoint:        "unix:///run/completion-test.sock",
+		runtimeServiceOverride: newFakeListRuntimeSvc(),
+	}
+
+	all, err := listCompletionCandidates(context.Background(), cfg, completeContainers, cacheDir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(all).To(ContainElements(
+		completionCandidate{Value: "ccc111"},
+		completionCandidate{Value: "default/web/nginx", Running: true},
+		completionCandidate{Value: "kube-system/db/exporter"},
+	))
+
+	// The running containers are served from the cache of all containers.
+	cfg.runtimeServiceOverride = fakeRuntimeSvc{}
+
+	running, err := listCompletionCandidates(context.Background(), cfg, completeRunningContainers, cacheDir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(running).To(ConsistOf(
+		completionCandidate{Value: "ccc222", Running: true},
+		completionCandidate{Value: "default/web/nginx", Running: true},
+		completionCandidate{Value: "ddd111", Running: true},
+		completionCandidate{Value: "kube-system/db/postgres", Running: true},
+	))
+}
+
+func TestCompletionCache(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	_, err := readCompletionCache(path, time.Minute)
+	g.Expect(err).To(HaveOccurred())
+
+	candidates := []completionCandidate{{Value: "a", Running: true}, {Value: "b"}}
+	g.Expect(writeCompletionCache(path, candidates)).To(Succeed())
+
+	cached, err := readCompletionCache(path, time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cached).To(Equal(candidates))
+
+	_, err = readCompletionCache(path, 0)
+	g.Expect(err).To(HaveOccurred())
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"go.opentelemetry.io/otel/trace/noop"
+	"sigs.k8s.io/yaml"
 
 	"sigs.k8s.io/cri-tools/pkg/common"
 )
@@ -46,15 +51,25 @@ EXAMPLES:
    # Show the full configuration
    crictl config --list
 
+   # Show the effective configuration and where each value comes from
+   crictl config view --effective
+
+   # Validate the configuration files and environment
+   crictl config validate
+
+   # List the contexts and switch to the context "remote"
+   crictl config get-contexts
+   crictl config use-context remote
+
 CRICTL OPTIONS:
-	 runtime-endpoint:	Container Runtime Interface (CRI) runtime endpoint (default: "")
-	 image-endpoint:	Container Runtime Interface (CRI) image endpoint (default: "")
-	 timeout:	Timeout of connecting to server (default: 2)
-	 debug:	Enable debug output (default: false)
-	 pull-image-on-create:	Enable pulling image on create requests (default: false)
-	 disable-pull-on-run:	Disable pulling image on run requests (default: false)
-	 max-retries:	Max retries for connecting to an explicitly set endpoint (default: 3, 0 to disable, negative for infinite)`,
+` + configOptionsUsage(),
 	UseShortOptionHandling: true,
+	Subcommands: []*cli.Command{
+		configUseContextCommand,
+		configGetContextsCommand,
+		configViewCommand,
+		configValidateCommand,
+	},
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:  "get",
@@ -83,28 +98,17 @@ CRICTL OPTIONS:
 		}
 
 		if c.IsSet("get") {
-			get := c.String("get")
-			switch get {
-			case common.RuntimeEndpoint:
-				fmt.Println(config.RuntimeEndpoint)
-			case common.ImageEndpoint:
-				fmt.Println(config.ImageEndpoint)
-			case common.Timeout:
-				fmt.Println(config.Timeout)
-			case common.Debug:
-				fmt.Println(config.Debug)
-			case common.PullImageOnCreate:
-				fmt.Println(config.PullImageOnCreate)
-			case common.DisablePullOnRun:
-				fmt.Println(config.DisablePullOnRun)
-			case common.MaxRetries:
-				fmt.Println(config.MaxRetries)
-			default:
-				return fmt.Errorf("no configuration option named %s", get)
+			option, err := common.LookupConfigOption(c.String("get"))
+			if err != nil {
+				return err
 			}
 
+			fmt.Println(option.Get(config))
+
 			return nil
 		} else if c.IsSet("set") {
+			keys := []string{}
+
 			settings := c.StringSlice("set")
 			for _, setting := range settings {
 				options := strings.SplitSeq(setting, ",")
@@ -120,20 +124,26 @@ CRICTL OPTIONS:
 					if err := setValue(key, value, config); err != nil {
 						return err
 					}
+
+					keys = append(keys, key)
 				}
 			}
 
-			return common.WriteConfig(config, configFile)
+			if err := common.WriteConfig(config, configFile); err != nil {
+				return err
+			}
+
+			warnOverriddenOptions(c, configFile, keys)
+
+			return nil
 		} else if c.Bool("list") {
 			display := newDefaultTableDisplay()
 			display.AddRow([]string{columnKey, columnValue})
-			display.AddRow([]string{common.RuntimeEndpoint, config.RuntimeEndpoint})
-			display.AddRow([]string{common.ImageEndpoint, config.ImageEndpoint})
-			display.AddRow([]string{common.Timeout, strconv.Itoa(config.Timeout)})
-			display.AddRow([]string{common.Debug, strconv.FormatBool(config.Debug)})
-			display.AddRow([]string{common.PullImageOnCreate, strconv.FormatBool(config.PullImageOnCreate)})
-			display.AddRow([]string{common.DisablePullOnRun, strconv.FormatBool(config.DisablePullOnRun)})
-			display.AddRow([]string{common.MaxRetries, strconv.Itoa(config.MaxRetries)})
+
+			for _, option := range common.ConfigOptions {
+				display.AddRow([]string{option.Key, option.Get(config)})
+			}
+
 			display.ClearScreen()
 			display.Flush()
 
@@ -153,54 +163,418 @@ CRICTL OPTIONS:
 			return fmt.Errorf("set %q to %q: %w", key, value, err)
 		}
 
-		return common.WriteConfig(config, configFile)
+		if err := common.WriteConfig(config, configFile); err != nil {
+			return err
+		}
+
+		warnOverriddenOptions(c, configFile, []string{key})
+
+		return nil
 	},
 }
 
+// warnOverriddenOptions warns about the options just written to the config
+// file, which are overridden by a later config layer, the selected context or
+// an environment variable, so that the written value is not used.
+func warnOverriddenOptions(c *cli.Context, configFile string, keys []string) {
+	files, err := configFilesFromContext(c)
+	if err != nil {
+		logrus.Debugf("Unable to check for overridden options: %v", err)
+
+		return
+	}
+
+	overridden, err := overriddenOptions(files, c.String("context"), configFile, keys)
+	if err != nil {
+		logrus.Debugf("Unable to check for overridden options: %v", err)
+
+		return
+	}
+
+	for _, key := range keys {
+		if source, ok := overridden[key]; ok {
+			logrus.Warnf("Option %q set in %s is overridden by %s", key, configFile, source)
+		}
+	}
+}
+
+// overriddenOptions returns the sources of the keys, whose effective values
+// of the layered config files do not come from configFile.
+func overriddenOptions(files []string, contextName, configFile string, keys []string) (map[string]string, error) {
+	config, err := common.LoadServerConfig(files, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	overridden := map[string]string{}
+
+	for _, key := range keys {
+		if source, ok := config.Sources[key]; ok && source != configFile {
+			overridden[key] = source
+		}
+	}
+
+	return overridden, nil
+}
+
+// configOptionsUsage returns the help text of all config options.
+func configOptionsUsage() string {
+	lines := make([]string, 0, len(common.ConfigOptions))
+
+	for _, option := range common.ConfigOptions {
+		def := option.Default
+		if option.Type != common.OptionTypeBool && option.Type != common.OptionTypeInt && option.Type != common.OptionTypeDuration {
+			def = fmt.Sprintf("%q", def)
+		}
+
+		lines = append(lines, fmt.Sprintf("\t %s:\t%s (default: %s)", option.Key, option.Usage, def))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func setValue(key, value string, config *common.Config) error {
-	switch key {
-	case common.RuntimeEndpoint:
-		config.RuntimeEndpoint = value
-	case common.ImageEndpoint:
-		config.ImageEndpoint = value
-	case common.Timeout:
-		n, err := strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("parse timeout value '%s': %w", value, err)
+	option, err := common.LookupConfigOption(key)
+	if err != nil {
+		return err
+	}
+
+	if key == common.CurrentContext && value != "" {
+		if _, err := config.GetContext(value); err != nil {
+			return err
+		}
+	}
+
+	return option.Set(config, value)
+}
+
+// Sources of effective config values, besides files, contexts and
+// environment variables of the config.
+const (
+	sourceDefault = "default"
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+)
+
+// effectiveOption is a single effective config value and its source.
+type effectiveOption struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+var configViewCommand = &cli.Command{
+	Name:  "view",
+	Usage: "Show the config file or the effective configuration",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "effective",
+			Usage: "Show the effective configuration of files, context, environment variables and flags, including the source of each value",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output format of the effective configuration, One of: json|yaml|table",
+			Value:   outputTypeTable,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.ShowSubcommandHelp(c)
+		}
+
+		if !c.Bool("effective") {
+			data, err := os.ReadFile(c.String("config"))
+			if err != nil {
+				return fmt.Errorf("read config file: %w", err)
+			}
+
+			fmt.Print(string(data))
+
+			return nil
 		}
 
-		config.Timeout = n
-	case common.Debug:
-		debug, err := strconv.ParseBool(value)
+		cfg := configFromContext(c)
+		options := effectiveOptions(c, cfg)
+		resolveEffectiveEndpoints(c.Context, cfg, options)
+
+		switch c.String("output") {
+		case outputTypeJSON, outputTypeYAML:
+			data, err := json.MarshalIndent(options, "", "  ")
+			if err != nil {
+				return fmt.Errorf("marshal effective config: %w", err)
+			}
+
+			if c.String("output") == outputTypeYAML {
+				if data, err = yaml.JSONToYAML(data); err != nil {
+					return fmt.Errorf("marshal effective config: %w", err)
+				}
+			}
+
+			fmt.Println(strings.TrimSpace(string(data)))
+		case outputTypeTable:
+			display := newDefaultTableDisplay()
+			display.AddRow([]string{columnKey, columnValue, columnSource})
+
+			for _, o := range options {
+				display.AddRow([]string{o.Key, o.Value, o.Source})
+			}
+
+			display.Flush()
+		default:
+			return fmt.Errorf("unsupported output format %q", c.String("output"))
+		}
+
+		return nil
+	},
+}
+
+// effectiveOptions returns all options of the resolved config in schema
+// order, followed by the context only options.
+func effectiveOptions(c *cli.Context, cfg *CrictlConfig) []effectiveOption {
+	config := &common.Config{
+		RuntimeEndpoint:        cfg.RuntimeEndpoint,
+		ImageEndpoint:          cfg.ImageEndpoint,
+		Timeout:                cfg.Timeout,
+		Debug:                  cfg.Debug,
+		PullImageOnCreate:      cfg.PullImageOnCreate,
+		DisablePullOnRun:       cfg.DisablePullOnRun,
+		MaxRetries:             cfg.MaxRetries,
+		RPCRetries:             cfg.RPCRetries,
+		TLSCA:                  cfg.TLSCA,
+		TLSCert:                cfg.TLSCert,
+		TLSKey:                 cfg.TLSKey,
+		TLSSNI:                 cfg.TLSSNI,
+		ReadOnly:               cfg.ReadOnly,
+		ReadOnlyAllowStreaming: cfg.ReadOnlyAllowStreaming,
+		AuditLog:               cfg.AuditLog,
+		CurrentContext:         cfg.Context,
+	}
+	ctx := &common.Context{
+		RuntimeHandler: cfg.RuntimeHandler,
+		Output:         cfg.Output,
+	}
+
+	options := []effectiveOption{}
+
+	for _, option := range common.ConfigOptions {
+		flagName := option.Key
+		if option.Key == common.CurrentContext {
+			flagName = "context"
+		}
+
+		options = append(options, effectiveOption{
+			Key:    option.Key,
+			Value:  option.Get(config),
+			Source: effectiveSource(c, cfg.Sources, option.Key, flagName),
+		})
+	}
+
+	for _, option := range common.ContextOptions {
+		if option.Key == common.ContextName {
+			continue
+		}
+
+		if _, err := common.LookupConfigOption(option.Key); err == nil {
+			continue
+		}
+
+		options = append(options, effectiveOption{
+			Key:    option.Key,
+			Value:  option.Get(ctx),
+			Source: effectiveSource(c, cfg.Sources, option.Key, ""),
+		})
+	}
+
+	return options
+}
+
+// resolveEffectiveEndpoints replaces unset and "auto" endpoints by the ones
+// used when connecting, which may require the endpoint discovery.
+func resolveEffectiveEndpoints(ctx context.Context, cfg *CrictlConfig, options []effectiveOption) {
+	var runtimeEndpoint *effectiveOption
+
+	for i := range options {
+		option := &options[i]
+
+		switch option.Key {
+		case common.RuntimeEndpoint:
+			runtimeEndpoint = option
+		case common.ImageEndpoint:
+			if option.Value == "" && runtimeEndpoint != nil && runtimeEndpoint.Value != common.EndpointAuto && runtimeEndpoint.Value != "" {
+				option.Value = runtimeEndpoint.Value
+				option.Source = runtimeEndpoint.Source
+
+				continue
+			}
+		default:
+			continue
+		}
+
+		if option.Value != "" && option.Value != common.EndpointAuto {
+			continue
+		}
+
+		discovered, err := cfg.discoverEndpoint(ctx, cfg.Timeout, noop.NewTracerProvider())
 		if err != nil {
-			return fmt.Errorf("parse debug value '%s': %w", value, err)
+			logrus.Warnf("Unable to discover the endpoint: %v", err)
+
+			return
+		}
+
+		option.Value = discovered.runtimeEndpoint
+		if option.Key == common.ImageEndpoint && discovered.imageEndpoint != "" {
+			option.Value = discovered.imageEndpoint
+		}
+
+		option.Source = "discovered from " + discovered.source
+	}
+}
+
+// effectiveSource returns where the value of the option comes from. Global
+// flags take precedence over the sources of the config.
+func effectiveSource(c *cli.Context, sources map[string]string, key, flagName string) string {
+	for _, flag := range c.App.Flags {
+		if !slices.Contains(flag.Names(), flagName) || !c.IsSet(flagName) {
+			continue
+		}
+
+		if envFlag, ok := flag.(cli.DocGenerationFlag); ok {
+			for _, env := range envFlag.GetEnvVars() {
+				if _, ok := os.LookupEnv(env); ok {
+					return sourceEnv + " " + env
+				}
+			}
 		}
 
-		config.Debug = debug
-	case common.PullImageOnCreate:
-		pi, err := strconv.ParseBool(value)
+		return sourceFlag + " --" + flagName
+	}
+
+	if source, ok := sources[key]; ok {
+		return source
+	}
+
+	return sourceDefault
+}
+
+var configValidateCommand = &cli.Command{
+	Name:  "validate",
+	Usage: "Validate the config files, the selected context and the CRICTL_* environment variables",
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.ShowSubcommandHelp(c)
+		}
+
+		files, err := configFilesFromContext(c)
 		if err != nil {
-			return fmt.Errorf("parse pull-image-on-create value '%s': %w", value, err)
+			return err
+		}
+
+		if c.IsSet("config") {
+			if _, err := os.Stat(c.String("config")); err != nil {
+				return fmt.Errorf("validate config: %w", err)
+			}
+		}
+
+		if err := common.ValidateConfigFiles(files, c.String("context")); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+
+		checked := []string{}
+
+		for _, file := range files {
+			if _, err := os.Stat(file); err == nil {
+				checked = append(checked, file)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("validate config: %w", err)
+			}
+		}
+
+		fmt.Printf("Configuration is valid (files: %s)\n", strings.Join(checked, ", "))
+
+		return nil
+	},
+}
+
+var configUseContextCommand = &cli.Command{
+	Name:      "use-context",
+	Usage:     "Set the current-context in the config file",
+	ArgsUsage: "NAME",
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.ShowSubcommandHelp(c)
 		}
 
-		config.PullImageOnCreate = pi
-	case common.DisablePullOnRun:
-		pi, err := strconv.ParseBool(value)
+		configFile := c.String("config")
+
+		config, err := common.ReadConfig(configFile)
 		if err != nil {
-			return fmt.Errorf("parse disable-pull-on-run value '%s': %w", value, err)
+			return fmt.Errorf("load config file: %w", err)
+		}
+
+		if err := setValue(common.CurrentContext, c.Args().First(), config); err != nil {
+			return err
+		}
+
+		if err := common.WriteConfig(config, configFile); err != nil {
+			return err
+		}
+
+		warnOverriddenOptions(c, configFile, []string{common.CurrentContext})
+
+		fmt.Printf("Switched to context %q\n", config.CurrentContext)
+
+		return nil
+	},
+}
+
+var configGetContextsCommand = &cli.Command{
+	Name:  "get-contexts",
+	Usage: "List the contexts of the config file",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Usage:   "Only display context names",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.ShowSubcommandHelp(c)
 		}
 
-		config.DisablePullOnRun = pi
-	case common.MaxRetries:
-		n, err := strconv.Atoi(value)
+		config, err := common.ReadConfig(c.String("config"))
 		if err != nil {
-			return fmt.Errorf("parse max-retries value '%s': %w", value, err)
+			return fmt.Errorf("load config file: %w", err)
 		}
 
-		config.MaxRetries = n
-	default:
-		return fmt.Errorf("no configuration option named %s", key)
-	}
+		current := config.CurrentContext
+		if c.IsSet("context") {
+			current = c.String("context")
+		}
 
-	return nil
+		if c.Bool("quiet") {
+			for _, ctx := range config.Contexts {
+				fmt.Println(ctx.Name)
+			}
+
+			return nil
+		}
+
+		display := newDefaultTableDisplay()
+		display.AddRow([]string{columnCurrent, columnName, columnRuntimeEP, columnImageEP})
+
+		for _, ctx := range config.Contexts {
+			marker := ""
+			if ctx.Name == current {
+				marker = "*"
+			}
+
+			display.AddRow([]string{marker, ctx.Name, ctx.RuntimeEndpoint, ctx.ImageEndpoint})
+		}
+
+		display.Flush()
+
+		return nil
+	},
 }
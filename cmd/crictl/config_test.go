@@ -0,0 +1,111 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+
+	"sigs.k8s.io/cri-tools/pkg/common"
+)
+
+func TestEffectiveOptions(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("runtime-endpoint", "", "")
+	g.Expect(fs.Parse([]string{"--runtime-endpoint", "unix:///run/flag.sock"})).To(Succeed())
+
+	app := &cli.App{Flags: []cli.Flag{&cli.StringFlag{Name: "runtime-endpoint"}}}
+	c := cli.NewContext(app, fs, nil)
+
+	cfg := &CrictlConfig{
+		RuntimeEndpoint: "unix:///run/flag.sock",
+		Timeout:         1500 * time.Millisecond,
+		MaxRetries:      3,
+		Context:         "remote",
+		RuntimeHandler:  "kata",
+		TLSCA:           "/etc/crictl/ca.crt",
+		Sources: map[string]string{
+			common.RuntimeEndpoint: "/etc/crictl.yaml",
+			common.Timeout:         "env CRICTL_TIMEOUT",
+			common.CurrentContext:  "/etc/crictl.yaml",
+			common.RuntimeHandler:  `context "remote"`,
+			common.TLSCA:           `context "remote"`,
+		},
+	}
+
+	g.Expect(effectiveOptions(c, cfg)).To(ContainElements(
+		effectiveOption{Key: common.RuntimeEndpoint, Value: "unix:///run/flag.sock", Source: "flag --runtime-endpoint"},
+		effectiveOption{Key: common.Timeout, Value: "1.5s", Source: "env CRICTL_TIMEOUT"},
+		effectiveOption{Key: common.MaxRetries, Value: "3", Source: sourceDefault},
+		effectiveOption{Key: common.CurrentContext, Value: "remote", Source: "/etc/crictl.yaml"},
+		effectiveOption{Key: common.RuntimeHandler, Value: "kata", Source: `context "remote"`},
+		effectiveOption{Key: common.TLSCA, Value: "/etc/crictl/ca.crt", Source: `context "remote"`},
+	))
+}
+
+func TestSetValue(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	config := &common.Config{Contexts: []*common.Context{{Name: "remote"}}}
+
+	g.Expect(setValue(common.Timeout, "10s", config)).To(Succeed())
+	g.Expect(config.Timeout).To(Equal(10 * time.Second))
+	g.Expect(setValue(common.CurrentContext, "remote", config)).To(Succeed())
+	g.Expect(setValue(common.CurrentContext, "missing", config)).To(MatchError(common.ErrContextNotFound))
+	g.Expect(setValue(common.Debug, "foo", config)).NotTo(Succeed())
+	g.Expect(setValue("foo", "bar", config)).To(MatchError("no configuration option named foo"))
+}
+
+func TestOverriddenOptions(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	system := filepath.Join(dir, "crictl.yaml")
+	g.Expect(os.WriteFile(system, []byte(`
+runtime-endpoint: unix:///run/system.sock
+timeout: 5
+debug: true
+current-context: remote
+contexts:
+  - name: remote
+    runtime-endpoint: unix:///run/remote.sock
+`), 0o600)).To(Succeed())
+
+	user := filepath.Join(dir, "user.yaml")
+	g.Expect(os.WriteFile(user, []byte("timeout: 10\n"), 0o600)).To(Succeed())
+
+	overridden, err := overriddenOptions([]string{system, user}, "", system,
+		[]string{common.RuntimeEndpoint, common.Timeout, common.Debug, common.CurrentContext})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(overridden).To(Equal(map[string]string{
+		common.RuntimeEndpoint: `context "remote"`,
+		common.Timeout:         user,
+	}))
+}
@@ -156,8 +156,10 @@ var createContainerCommand = &cli.Command{
 	Name:        "create",
 	Usage:       "Create a new container",
 	ArgsUsage:   "POD container-config.[json|yaml] pod-config.[json|yaml]",
-	Flags:       createPullFlags,
+	Flags:       append(createPullFlags, dryRunFlags()...),
 	Subcommands: subcommands,
+	Before:      beginDryRun,
+	After:       endDryRun,
 	Action: func(c *cli.Context) (err error) {
 		if c.Args().Len() != 3 {
 			return cli.ShowSubcommandHelp(c)
@@ -204,7 +206,9 @@ var createContainerCommand = &cli.Command{
 			return fmt.Errorf("creating container: %w", err)
 		}
 
-		fmt.Println(ctrID)
+		if !dryRunning(runtimeClient) {
+			fmt.Println(ctrID)
+		}
 
 		return nil
 	},
@@ -224,8 +228,12 @@ var startContainerCommand = &cli.Command{
 			return err
 		}
 
-		for i := range c.NArg() {
-			containerID := c.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
+
+		for _, containerID := range containerIDs {
 			if err := StartContainer(c.Context, runtimeClient, containerID); err != nil {
 				return fmt.Errorf("starting the container %q: %w", containerID, err)
 			}
@@ -239,7 +247,7 @@ var updateContainerCommand = &cli.Command{
 	Name:      "update",
 	Usage:     "Update one or more running containers",
 	ArgsUsage: "CONTAINER-ID [CONTAINER-ID...]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.Int64Flag{
 			Name:  "cpu-count",
 			Usage: "(Windows only) Number of CPUs available to the container",
@@ -276,7 +284,9 @@ var updateContainerCommand = &cli.Command{
 			Name:  "oom-score-adj",
 			Usage: "OOM Killer score to use",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(c *cli.Context) error {
 		if c.NArg() == 0 {
 			return errIDEmpty
@@ -299,8 +309,12 @@ var updateContainerCommand = &cli.Command{
 			OomScoreAdj:        c.Int64("oom-score-adj"),
 		}
 
-		for i := range c.NArg() {
-			containerID := c.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
+
+		for _, containerID := range containerIDs {
 			if err := UpdateContainerResources(c.Context, runtimeClient, containerID, options); err != nil {
 				return fmt.Errorf("updating container resources for %q: %w", containerID, err)
 			}
@@ -315,7 +329,7 @@ var stopContainerCommand = &cli.Command{
 	Usage:                  "Stop one or more running containers",
 	ArgsUsage:              "CONTAINER-ID [CONTAINER-ID...]",
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.Int64Flag{
 			Name:    "timeout",
 			Aliases: []string{"t"},
@@ -326,7 +340,9 @@ var stopContainerCommand = &cli.Command{
 			Aliases: []string{"a"},
 			Usage:   "Stop all running containers",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(c *cli.Context) error {
 		runtimeClient, err := configFromContext(c).GetRuntimeService(c.Context, 0)
 		if err != nil {
@@ -364,7 +380,10 @@ var stopContainerCommand = &cli.Command{
 				return cli.Exit("you must specify at least one CONTAINER-ID or use --all", 1)
 			}
 
-			containerIDs = c.Args().Slice()
+			containerIDs, err = resolveContainerIDs(c.Context, runtimeClient, c.Args().Slice())
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, containerID := range containerIDs {
@@ -382,7 +401,7 @@ var removeContainerCommand = &cli.Command{
 	Usage:                  "Remove one or more containers",
 	ArgsUsage:              "CONTAINER-ID [CONTAINER-ID...]",
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:    "force",
 			Aliases: []string{"f"},
@@ -398,7 +417,9 @@ var removeContainerCommand = &cli.Command{
 			Aliases: []string{"a"},
 			Usage:   "Remove all containers",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(ctx *cli.Context) error {
 		runtimeClient, err := configFromContext(ctx).GetRuntimeService(ctx.Context, 0)
 		if err != nil {
@@ -412,6 +433,13 @@ var removeContainerCommand = &cli.Command{
 			return err
 		}
 
+		if !ctx.Bool("all") {
+			ids, err = resolveContainerIDs(ctx.Context, runtimeClient, ids)
+			if err != nil {
+				return err
+			}
+		}
+
 		funcs := []func() error{}
 		for _, id := range ids {
 			funcs = append(funcs, func() error {
@@ -536,7 +564,10 @@ var containerStatusCommand = &cli.Command{
 			return err
 		}
 
-		ids := c.Args().Slice()
+		ids, err := resolveContainerIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
 
 		if len(ids) == 0 {
 			opts := &listOptions{
@@ -575,7 +606,7 @@ var containerStatusCommand = &cli.Command{
 			c.Context,
 			runtimeClient,
 			ids,
-			c.String("output"),
+			outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			c.String("template"),
 			c.Bool("quiet"),
 		); err != nil {
@@ -661,6 +692,10 @@ var listContainersCommand = &cli.Command{
 			Name:  "namespace",
 			Usage: "Filter by pod namespace regular expression pattern",
 		},
+		&cli.BoolFlag{
+			Name:  "k8s",
+			Usage: "Show Kubernetes namespace, pod and container names instead of IDs",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.NArg() != 0 {
@@ -686,7 +721,7 @@ var listContainersCommand = &cli.Command{
 			state:              c.String("state"),
 			verbose:            c.Bool("verbose"),
 			quiet:              c.Bool("quiet"),
-			output:             c.String("output"),
+			output:             outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			all:                c.Bool("all"),
 			nameRegexp:         c.String("name"),
 			latest:             c.Bool("latest"),
@@ -694,6 +729,7 @@ var listContainersCommand = &cli.Command{
 			noTrunc:            c.Bool("no-trunc"),
 			image:              c.String("image"),
 			resolveImagePath:   c.Bool("resolve-image-path"),
+			k8s:                c.Bool("k8s"),
 		}
 
 		opts.labels, err = parseLabelStringSlice(c.StringSlice("label"))
@@ -713,8 +749,10 @@ var runContainerCommand = &cli.Command{
 	Name:        "run",
 	Usage:       "Run a new container inside a sandbox",
 	ArgsUsage:   "container-config.[json|yaml] pod-config.[json|yaml]",
-	Flags:       runPullFlags,
+	Flags:       append(runPullFlags, dryRunFlags()...),
 	Subcommands: subcommands,
+	Before:      beginDryRun,
+	After:       endDryRun,
 	Action: func(c *cli.Context) (err error) {
 		if c.Args().Len() != 2 {
 			return cli.ShowSubcommandHelp(c)
@@ -753,7 +791,7 @@ var runContainerCommand = &cli.Command{
 			return err
 		}
 
-		if err = RunContainer(c.Context, imageClient, runtimeClient, opts, c.String("runtime")); err != nil {
+		if err = RunContainer(c.Context, imageClient, runtimeClient, opts, runtimeHandler(c)); err != nil {
 			return fmt.Errorf("running container: %w", err)
 		}
 
@@ -790,9 +828,12 @@ var checkpointContainerCommand = &cli.Command{
 			return err
 		}
 
-		for i := range c.NArg() {
-			containerID := c.Args().Get(i)
+		containerIDs, err := resolveContainerIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
 
+		for _, containerID := range containerIDs {
 			err := CheckpointContainer(
 				c.Context,
 				runtimeClient,
@@ -933,7 +974,9 @@ func StartContainer(ctx context.Context, client internalapi.RuntimeService, id s
 		return err
 	}
 
-	fmt.Println(id)
+	if !dryRunning(client) {
+		fmt.Println(id)
+	}
 
 	return nil
 }
@@ -997,7 +1040,9 @@ func UpdateContainerResources(ctx context.Context, client internalapi.RuntimeSer
 		return err
 	}
 
-	fmt.Println(id)
+	if !dryRunning(client) {
+		fmt.Println(id)
+	}
 
 	return nil
 }
@@ -1017,7 +1062,9 @@ func StopContainer(ctx context.Context, client internalapi.RuntimeService, id st
 		return err
 	}
 
-	fmt.Println(id)
+	if !dryRunning(client) {
+		fmt.Println(id)
+	}
 
 	return nil
 }
@@ -1066,7 +1113,9 @@ func RemoveContainer(ctx context.Context, client internalapi.RuntimeService, id
 		return err
 	}
 
-	fmt.Println(id)
+	if !dryRunning(client) {
+		fmt.Println(id)
+	}
 
 	return nil
 }
@@ -1267,6 +1316,10 @@ func OutputContainers(ctx context.Context, runtimeClient internalapi.RuntimeServ
 		return fmt.Errorf("unsupported output format %q", opts.output)
 	}
 
+	if opts.k8s && !opts.verbose {
+		return outputK8sContainers(ctx, runtimeClient, imageClient, r, opts)
+	}
+
 	display := newDefaultTableDisplay()
 	if !opts.verbose && !opts.quiet {
 		display.AddRow([]string{columnContainer, columnImage, columnCreated, columnState, columnName, columnAttempt, columnPodID, columnPodName, columnNamespace})
@@ -1374,6 +1427,68 @@ func OutputContainers(ctx context.Context, runtimeClient internalapi.RuntimeServ
 	return nil
 }
 
+// outputK8sContainers prints the containers using their Kubernetes
+// namespace, pod and container names instead of the IDs.
+func outputK8sContainers(
+	ctx context.Context,
+	runtimeClient internalapi.RuntimeService,
+	imageClient internalapi.ImageManagerService,
+	containers []*pb.Container,
+	opts *listOptions,
+) error {
+	sandboxes, err := InterruptableRPC(ctx, func(ctx context.Context) ([]*pb.PodSandbox, error) {
+		return runtimeClient.ListPodSandbox(ctx, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("call list sandboxes RPC: %w", err)
+	}
+
+	sandboxByID := make(map[string]*pb.PodSandbox, len(sandboxes))
+	for _, s := range sandboxes {
+		sandboxByID[s.GetId()] = s
+	}
+
+	display := newDefaultTableDisplay()
+	if !opts.quiet {
+		display.AddRow([]string{columnNamespace, columnPodName, columnContainer, columnImage, columnCreated, columnState, columnAttempt})
+	}
+
+	for _, c := range containers {
+		namespace, pod, name := k8sContainerRef(c, sandboxByID[c.GetPodSandboxId()])
+
+		if opts.quiet {
+			fmt.Printf("%s/%s/%s\n", namespace, pod, name)
+
+			continue
+		}
+
+		image := c.GetImage().GetImage()
+		if opts.resolveImagePath {
+			image, err = getRepoImage(ctx, imageClient, image)
+			if err != nil {
+				return fmt.Errorf("failed to fetch repo image %w", err)
+			}
+		} else if digest, err := godigest.Parse(image); err == nil && !opts.noTrunc {
+			image = getTruncatedID(digest.String(), string(digest.Algorithm())+":")
+		}
+
+		containerState, err := convertContainerState(c.GetState())
+		if err != nil {
+			return err
+		}
+
+		createdAt := time.Unix(0, c.GetCreatedAt())
+		display.AddRow([]string{
+			namespace, pod, name, image,
+			units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago",
+			containerState,
+			strconv.FormatUint(uint64(c.GetMetadata().GetAttempt()), 10),
+		})
+	}
+
+	return display.Flush()
+}
+
 func convertContainerState(state pb.ContainerState) (string, error) {
 	switch state {
 	case pb.ContainerState_CONTAINER_CREATED:
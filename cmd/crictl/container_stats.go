@@ -105,12 +105,19 @@ var statsCommand = &cli.Command{
 			id = c.Args().First()
 		}
 
+		if id != "" {
+			id, err = resolveContainerID(c.Context, runtimeClient, id)
+			if err != nil {
+				return err
+			}
+		}
+
 		opts := &statsOptions{
 			all:    c.Bool("all"),
 			id:     id,
 			podID:  c.String("pod"),
 			sample: time.Duration(c.Int("seconds")) * time.Second,
-			output: c.String("output"),
+			output: outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			watch:  c.Bool("watch"),
 		}
 
@@ -19,6 +19,10 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -47,6 +51,10 @@ func newCrictlConfig(ctx *cli.Context, config *common.ServerConfiguration) *Cric
 		logrus.SetLevel(logrus.DebugLevel)
 	}
 
+	if ctx.Bool("timing") {
+		cfg.timing = newTimingRecorder(os.Stderr)
+	}
+
 	return cfg
 }
 
@@ -73,7 +81,11 @@ func newCrictlConfigFromFlags(ctx *cli.Context) *CrictlConfig {
 
 	cfg.Debug = ctx.Bool("debug")
 	cfg.MaxRetries = ctx.Int("max-retries")
+	cfg.RPCRetries = ctx.Int("rpc-retries")
 	cfg.DisablePullOnRun = false
+	cfg.ReadOnly = ctx.Bool("read-only")
+	cfg.ReadOnlyAllowStreaming = ctx.Bool("read-only-allow-streaming")
+	cfg.setTLSFromFlags(ctx)
 
 	return cfg
 }
@@ -116,18 +128,100 @@ func newCrictlConfigFromFile(ctx *cli.Context, config *common.ServerConfiguratio
 		cfg.Debug = config.Debug
 	}
 
-	if ctx.IsSet("max-retries") {
-		cfg.MaxRetries = ctx.Int("max-retries")
-	} else {
+	if _, ok := config.Sources[common.MaxRetries]; ok && !ctx.IsSet("max-retries") {
 		cfg.MaxRetries = config.MaxRetries
+	} else {
+		cfg.MaxRetries = ctx.Int("max-retries")
+	}
+
+	cfg.RPCRetries = config.RPCRetries
+	if ctx.IsSet("rpc-retries") {
+		cfg.RPCRetries = ctx.Int("rpc-retries")
 	}
 
 	cfg.PullImageOnCreate = config.PullImageOnCreate
 	cfg.DisablePullOnRun = config.DisablePullOnRun
+	cfg.Context = config.Context
+	cfg.TLSCA = config.TLSCA
+	cfg.TLSCert = config.TLSCert
+	cfg.TLSKey = config.TLSKey
+	cfg.TLSSNI = config.TLSSNI
+	cfg.RuntimeHandler = config.RuntimeHandler
+	cfg.Output = config.Output
+	cfg.Sources = config.Sources
+	cfg.setTLSFromFlags(ctx)
+
+	cfg.ReadOnly = config.ReadOnly
+	if ctx.IsSet("read-only") {
+		cfg.ReadOnly = ctx.Bool("read-only")
+	}
+
+	cfg.ReadOnlyAllowStreaming = config.ReadOnlyAllowStreaming
+	if ctx.IsSet("read-only-allow-streaming") {
+		cfg.ReadOnlyAllowStreaming = ctx.Bool("read-only-allow-streaming")
+	}
+
+	cfg.AuditLog = config.AuditLog
+	if cfg.AuditLog != "" {
+		cfg.audit = newAuditLog(cfg.AuditLog)
+	}
 
 	return cfg
 }
 
+// setTLSFromFlags overrides the configured TLS settings with the global TLS
+// flags.
+func (cfg *CrictlConfig) setTLSFromFlags(ctx *cli.Context) {
+	for flag, value := range map[string]*string{
+		flagTLSCA:   &cfg.TLSCA,
+		flagTLSCert: &cfg.TLSCert,
+		flagTLSKey:  &cfg.TLSKey,
+		flagTLSSNI:  &cfg.TLSSNI,
+	} {
+		if ctx.IsSet(flag) {
+			*value = ctx.String(flag)
+		}
+	}
+}
+
+// configFilesFromContext returns the config files to be layered. An
+// explicitly set config file is used exclusively. Otherwise the system wide
+// config file, or the one in the program's directory if it does not exist, is
+// overridden by the user config file.
+func configFilesFromContext(ctx *cli.Context) ([]string, error) {
+	configFile := ctx.String("config")
+	if ctx.IsSet("config") {
+		return []string{configFile}, nil
+	}
+
+	if _, err := os.Stat(configFile); errors.Is(err, os.ErrNotExist) {
+		exePath, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("get executable path: %w", err)
+		}
+
+		// If the config file was not found, try looking in the program's
+		// directory as a fallback. This is to accommodate where the config
+		// file is placed with the cri tools binary.
+		nextConfigFile := filepath.Join(filepath.Dir(exePath), "crictl.yaml")
+		if _, err := os.Stat(nextConfigFile); err == nil {
+			logrus.Debugf("Config %q does not exist, using %q", configFile, nextConfigFile)
+			configFile = nextConfigFile
+		}
+	}
+
+	files := []string{configFile}
+
+	userConfigFile, err := common.UserConfigPath()
+	if err != nil {
+		logrus.Debugf("Skipping user config file: %v", err)
+
+		return files, nil
+	}
+
+	return append(files, userConfigFile), nil
+}
+
 // CrictlConfig holds all resolved runtime configuration for a crictl session.
 type CrictlConfig struct {
 	RuntimeEndpoint      string
@@ -139,10 +233,38 @@ type CrictlConfig struct {
 	PullImageOnCreate    bool
 	DisablePullOnRun     bool
 	MaxRetries           int
-	TracerProvider       *sdktrace.TracerProvider
+	// RPCRetries is the number of retries for idempotent RPCs failing with
+	// a transient error.
+	RPCRetries int
+	// Context is the name of the selected config context, if any.
+	Context string
+	// TLSCA, TLSCert, TLSKey and TLSSNI are used for tls:// endpoints and as
+	// defaults for TLS streaming.
+	TLSCA   string
+	TLSCert string
+	TLSKey  string
+	TLSSNI  string
+	// ReadOnly rejects all mutating RPCs of the CRI clients.
+	ReadOnly bool
+	// ReadOnlyAllowStreaming allows exec, attach and port-forward in read-only mode.
+	ReadOnlyAllowStreaming bool
+	// AuditLog is the path of the log the mutating invocations are appended to.
+	AuditLog string
+	// RuntimeHandler is the default runtime handler for new pods.
+	RuntimeHandler string
+	// Output is the default output format for commands supporting it.
+	Output string
+	// Sources maps the option keys to where their value comes from.
+	Sources        map[string]string
+	TracerProvider *sdktrace.TracerProvider
 	// RootSpan is the root OpenTelemetry span for the command.
 	RootSpan trace.Span
 
+	audit                  *auditLog
+	discovered             *discoveredEndpoint
+	timing                 *timingRecorder
+	dryRun                 *dryRunRecorder
+	endpointProxies        map[string]*common.EndpointProxy
 	runtimeServiceOverride internalapi.RuntimeService
 	imageServiceOverride   internalapi.ImageManagerService
 }
@@ -153,10 +275,84 @@ func configFromContext(ctx *cli.Context) *CrictlConfig {
 	return cfg
 }
 
+// outputFormat returns the value of the "output" flag. If the flag is not
+// set, the configured default output is used if the command supports it.
+func outputFormat(ctx *cli.Context, supported ...string) string {
+	if ctx.IsSet("output") {
+		return ctx.String("output")
+	}
+
+	if cfg := configFromContext(ctx); cfg != nil && slices.Contains(supported, cfg.Output) {
+		return cfg.Output
+	}
+
+	return ctx.String("output")
+}
+
+// runtimeHandler returns the value of the "runtime" flag, falling back to the
+// configured default runtime handler.
+func runtimeHandler(ctx *cli.Context) string {
+	if ctx.IsSet("runtime") {
+		return ctx.String("runtime")
+	}
+
+	if cfg := configFromContext(ctx); cfg != nil && cfg.RuntimeHandler != "" {
+		return cfg.RuntimeHandler
+	}
+
+	return ctx.String("runtime")
+}
+
 // GetRuntimeService returns the runtime service client. If an override is set
 // (for testing), it is returned directly. Otherwise a new gRPC connection is
-// created using the configured endpoint and timeout.
+// created using the configured endpoint and timeout. All RPCs are timed for
+// the report of --timing, if enabled. Idempotent RPCs failing
+// with a transient error are retried, if enabled. In read-only mode, all
+// mutating RPCs of the client fail. The mutating RPCs, including the rejected
+// ones, are recorded in the audit log, if enabled, while they are only
+// recorded for printing in dry-run mode.
 func (cfg *CrictlConfig) GetRuntimeService(ctx context.Context, timeout time.Duration) (internalapi.RuntimeService, error) {
+	var (
+		service internalapi.RuntimeService
+		err     error
+	)
+
+	if cfg.timing != nil {
+		service, err = timedConnect(cfg.timing, timingConnectRuntime, func() (internalapi.RuntimeService, error) {
+			return cfg.newRuntimeService(ctx, timeout)
+		})
+	} else {
+		service, err = cfg.newRuntimeService(ctx, timeout)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.timing != nil {
+		service = &timingRuntimeService{RuntimeService: service, recorder: cfg.timing}
+	}
+
+	if cfg.RPCRetries > 0 {
+		service = &retryRuntimeService{RuntimeService: service, policy: newRetryPolicy(cfg.RPCRetries)}
+	}
+
+	if cfg.ReadOnly {
+		service = &readOnlyRuntimeService{RuntimeService: service, allowStreaming: cfg.ReadOnlyAllowStreaming}
+	}
+
+	if cfg.audit != nil {
+		service = &auditRuntimeService{RuntimeService: service, log: cfg.audit}
+	}
+
+	if cfg.dryRun != nil {
+		service = &dryRunRuntimeService{RuntimeService: service, recorder: cfg.dryRun}
+	}
+
+	return service, nil
+}
+
+func (cfg *CrictlConfig) newRuntimeService(ctx context.Context, timeout time.Duration) (internalapi.RuntimeService, error) {
 	if cfg.runtimeServiceOverride != nil {
 		return cfg.runtimeServiceOverride, nil
 	}
@@ -179,46 +375,77 @@ func (cfg *CrictlConfig) GetRuntimeService(ctx context.Context, timeout time.Dur
 		tp = cfg.TracerProvider
 	}
 
-	if !cfg.RuntimeEndpointIsSet {
-		logrus.Warningf("runtime connect using default endpoints: %v. "+
-			"As the default settings are now deprecated, you should set the "+
-			"endpoint instead.", defaultRuntimeEndpoints)
-		logrus.Debug("Note that performance maybe affected as each default " +
-			"connection attempt takes n-seconds to complete before timing out " +
-			"and going to the next in sequence.")
-
-		var (
-			res internalapi.RuntimeService
-			err error
-		)
-
-		for _, endPoint := range defaultRuntimeEndpoints {
-			logrus.Debugf("Connect using endpoint %q with %q timeout", endPoint, t)
-
-			res, err = remote.NewRemoteRuntimeService(ctx, endPoint, t, tp, false)
-			if err != nil {
-				logrus.Error(err)
-
-				continue
-			}
-
-			logrus.Debugf("Connected successfully using endpoint: %s", endPoint)
-
-			break
+	if !cfg.RuntimeEndpointIsSet || cfg.RuntimeEndpoint == common.EndpointAuto {
+		discovered, err := cfg.discoverEndpoint(ctx, t, tp)
+		if err != nil {
+			return nil, err
 		}
 
-		return res, err
+		cfg.logDiscoveredEndpoint("Runtime", discovered.runtimeEndpoint, discovered.source, cfg.RuntimeEndpointIsSet)
+
+		return remote.NewRemoteRuntimeService(ctx, discovered.runtimeEndpoint, t, tp, false)
 	}
 
 	return connectWithRetry(ctx, cfg.MaxRetries, func() (internalapi.RuntimeService, error) {
-		return remote.NewRemoteRuntimeService(ctx, cfg.RuntimeEndpoint, t, tp, false)
+		endpoint, err := cfg.localEndpoint(ctx, cfg.RuntimeEndpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return remote.NewRemoteRuntimeService(ctx, endpoint, t, tp, false)
 	})
 }
 
 // GetImageService returns the image service client. If an override is set
 // (for testing), it is returned directly. Otherwise a new gRPC connection is
-// created using the configured endpoint and timeout.
+// created using the configured endpoint and timeout. All RPCs are timed for
+// the report of --timing, if enabled. Idempotent RPCs failing
+// with a transient error are retried, if enabled. In read-only mode, all
+// mutating RPCs of the client fail. The mutating RPCs, including the rejected
+// ones, are recorded in the audit log, if enabled, while they are only
+// recorded for printing in dry-run mode.
 func (cfg *CrictlConfig) GetImageService(ctx context.Context) (internalapi.ImageManagerService, error) {
+	var (
+		service internalapi.ImageManagerService
+		err     error
+	)
+
+	if cfg.timing != nil {
+		service, err = timedConnect(cfg.timing, timingConnectImage, func() (internalapi.ImageManagerService, error) {
+			return cfg.newImageService(ctx)
+		})
+	} else {
+		service, err = cfg.newImageService(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.timing != nil {
+		service = &timingImageService{ImageManagerService: service, recorder: cfg.timing}
+	}
+
+	if cfg.RPCRetries > 0 {
+		service = &retryImageService{ImageManagerService: service, policy: newRetryPolicy(cfg.RPCRetries)}
+	}
+
+	if cfg.ReadOnly {
+		service = &readOnlyImageService{ImageManagerService: service}
+	}
+
+	if cfg.audit != nil {
+		service = &auditImageService{ImageManagerService: service, log: cfg.audit}
+	}
+
+	if cfg.dryRun != nil {
+		service = &dryRunImageService{ImageManagerService: service, recorder: cfg.dryRun}
+	}
+
+	return service, nil
+}
+
+func (cfg *CrictlConfig) newImageService(ctx context.Context) (internalapi.ImageManagerService, error) {
 	if cfg.imageServiceOverride != nil {
 		return cfg.imageServiceOverride, nil
 	}
@@ -239,40 +466,118 @@ func (cfg *CrictlConfig) GetImageService(ctx context.Context) (internalapi.Image
 		tp = cfg.TracerProvider
 	}
 
-	if !cfg.ImageEndpointIsSet {
-		logrus.Warningf("Image connect using default endpoints: %v. "+
-			"As the default settings are now deprecated, you should set the "+
-			"endpoint instead.", defaultRuntimeEndpoints)
-		logrus.Debug("Note that performance maybe affected as each default " +
-			"connection attempt takes n-seconds to complete before timing out " +
-			"and going to the next in sequence.")
+	if !cfg.ImageEndpointIsSet || cfg.ImageEndpoint == common.EndpointAuto {
+		discovered, err := cfg.discoverEndpoint(ctx, cfg.Timeout, tp)
+		if err != nil {
+			return nil, err
+		}
 
-		var (
-			res internalapi.ImageManagerService
-			err error
-		)
+		endpoint := discovered.imageEndpoint
+		if endpoint == "" {
+			endpoint = discovered.runtimeEndpoint
+		}
 
-		for _, endPoint := range defaultRuntimeEndpoints {
-			logrus.Debugf("Connect using endpoint %q with %q timeout", endPoint, cfg.Timeout)
+		cfg.logDiscoveredEndpoint("Image", endpoint, discovered.source, cfg.ImageEndpointIsSet)
 
-			res, err = remote.NewRemoteImageService(ctx, endPoint, cfg.Timeout, tp, false)
-			if err != nil {
-				logrus.Error(err)
+		return remote.NewRemoteImageService(ctx, endpoint, cfg.Timeout, tp, false)
+	}
 
-				continue
-			}
+	return connectWithRetry(ctx, cfg.MaxRetries, func() (internalapi.ImageManagerService, error) {
+		endpoint, err := cfg.localEndpoint(ctx, cfg.ImageEndpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return remote.NewRemoteImageService(ctx, endpoint, cfg.Timeout, tp, false)
+	})
+}
+
+// localEndpoint returns the endpoint to be used by the CRI client. The
+// connections to tcp:// and tls:// endpoints are forwarded by a local socket,
+// which is shared by the runtime and image service.
+func (cfg *CrictlConfig) localEndpoint(ctx context.Context, endpoint string) (string, error) {
+	if !common.IsRemoteEndpoint(endpoint) {
+		return endpoint, nil
+	}
+
+	if proxy, ok := cfg.endpointProxies[endpoint]; ok {
+		return proxy.Endpoint, nil
+	}
+
+	proxy, err := common.NewEndpointProxy(ctx, endpoint, &common.EndpointTLSConfig{
+		CAFile:     cfg.TLSCA,
+		CertFile:   cfg.TLSCert,
+		KeyFile:    cfg.TLSKey,
+		ServerName: cfg.TLSSNI,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.endpointProxies == nil {
+		cfg.endpointProxies = map[string]*common.EndpointProxy{}
+	}
+
+	cfg.endpointProxies[endpoint] = proxy
 
-			logrus.Debugf("Connected successfully using endpoint: %s", endPoint)
+	return proxy.Endpoint, nil
+}
 
-			break
+// closeEndpointProxies stops forwarding all remote endpoints.
+func (cfg *CrictlConfig) closeEndpointProxies() {
+	for endpoint, proxy := range cfg.endpointProxies {
+		if err := proxy.Close(); err != nil {
+			logrus.Debugf("Unable to close forwarding of %s: %v", endpoint, err)
 		}
+	}
+
+	cfg.endpointProxies = nil
+}
 
-		return res, err
+// discoverEndpoint runs the automatic endpoint discovery, at most once per
+// crictl invocation.
+func (cfg *CrictlConfig) discoverEndpoint(ctx context.Context, timeout time.Duration, tp trace.TracerProvider) (*discoveredEndpoint, error) {
+	if cfg.discovered != nil {
+		return cfg.discovered, nil
 	}
 
-	return connectWithRetry(ctx, cfg.MaxRetries, func() (internalapi.ImageManagerService, error) {
-		return remote.NewRemoteImageService(ctx, cfg.ImageEndpoint, cfg.Timeout, tp, false)
+	discovery := newEndpointDiscovery(func(ctx context.Context, endpoint string) error {
+		service, err := remote.NewRemoteRuntimeService(ctx, endpoint, timeout, tp, false)
+		if err != nil {
+			return err
+		}
+
+		if closer, ok := service.(interface{ Close(context.Context) error }); ok {
+			if err := closer.Close(ctx); err != nil {
+				logrus.Debugf("Unable to close probe connection to %q: %v", endpoint, err)
+			}
+		}
+
+		return nil
 	})
+
+	discovered, err := discovery.discover(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.discovered = discovered
+
+	return discovered, nil
+}
+
+// logDiscoveredEndpoint reports the discovered endpoint and its source. The
+// deprecated implicit discovery is reported as warning.
+func (cfg *CrictlConfig) logDiscoveredEndpoint(kind, endpoint, source string, explicit bool) {
+	if explicit {
+		logrus.Debugf("%s endpoint %q discovered from %s", kind, endpoint, source)
+
+		return
+	}
+
+	logrus.Warnf("%s endpoint is not set, using %q discovered from %s. "+
+		"As the default settings are now deprecated, you should set the "+
+		"endpoint or %q instead.", kind, endpoint, source, common.EndpointAuto)
 }
 
 func connectWithRetry[T any](
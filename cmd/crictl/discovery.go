@@ -0,0 +1,358 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// kubeletRuntimeEndpointFlag is the kubelet flag of the runtime endpoint.
+	kubeletRuntimeEndpointFlag = "container-runtime-endpoint"
+
+	// kubeletImageEndpointFlag is the kubelet flag of the image endpoint.
+	kubeletImageEndpointFlag = "image-service-endpoint"
+
+	// kubeletConfigFlag is the kubelet flag of the kubelet config file.
+	kubeletConfigFlag = "config"
+
+	// sourceDefaultSocket is the source of the default runtime endpoints.
+	sourceDefaultSocket = "default socket"
+)
+
+var (
+	// defaultKubeletConfigPath is the kubelet config file written by kubeadm.
+	defaultKubeletConfigPath = "/var/lib/kubelet/config.yaml"
+
+	// kubeletUnitPaths are the systemd units, drop-ins and environment files
+	// which may contain kubelet flags.
+	kubeletUnitPaths = []string{
+		"/etc/systemd/system/kubelet.service",
+		"/usr/lib/systemd/system/kubelet.service",
+		"/lib/systemd/system/kubelet.service",
+		"/etc/systemd/system/kubelet.service.d/*.conf",
+		"/usr/lib/systemd/system/kubelet.service.d/*.conf",
+		"/var/lib/kubelet/kubeadm-flags.env",
+		"/etc/default/kubelet",
+		"/etc/sysconfig/kubelet",
+	}
+)
+
+// discoveredEndpoint is the result of the automatic endpoint discovery.
+type discoveredEndpoint struct {
+	runtimeEndpoint string
+	// imageEndpoint is empty if the discovered source does not set it.
+	imageEndpoint string
+	// source describes where the endpoint has been found.
+	source string
+}
+
+// endpointCandidate is a runtime endpoint to be probed.
+type endpointCandidate struct {
+	runtimeEndpoint string
+	imageEndpoint   string
+	source          string
+}
+
+// endpointDiscovery discovers the CRI endpoints of the node. The candidates
+// are collected in the order of precedence from the command line of a running
+// kubelet, the kubelet config file, the kubelet systemd units and the default
+// sockets. All candidates are probed in parallel and the first reachable one
+// in the order of precedence wins, without waiting for the probes of lower
+// precedence.
+type endpointDiscovery struct {
+	procDir            string
+	kubeletConfigPath  string
+	kubeletUnitPaths   []string
+	defaultEndpoints   []string
+	probe              func(ctx context.Context, endpoint string) error
+	maxParallelProbing int
+}
+
+func newEndpointDiscovery(probe func(ctx context.Context, endpoint string) error) *endpointDiscovery {
+	return &endpointDiscovery{
+		procDir:            "/proc",
+		kubeletConfigPath:  defaultKubeletConfigPath,
+		kubeletUnitPaths:   kubeletUnitPaths,
+		defaultEndpoints:   defaultRuntimeEndpoints,
+		probe:              probe,
+		maxParallelProbing: 8,
+	}
+}
+
+// discover returns the first reachable runtime endpoint.
+func (d *endpointDiscovery) discover(ctx context.Context, timeout time.Duration) (*discoveredEndpoint, error) {
+	candidates := d.candidates()
+	if len(candidates) == 0 {
+		return nil, errors.New("discover endpoint: no candidates found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The result of every probe is sent to its own channel, so that the
+	// results are collected in the order of precedence as soon as they are
+	// known, while the probes run in parallel.
+	results := make([]chan error, len(candidates))
+
+	sem := make(chan struct{}, d.maxParallelProbing)
+
+	for i, c := range candidates {
+		results[i] = make(chan error, 1)
+
+		go func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] <- ctx.Err()
+
+				return
+			}
+			defer func() { <-sem }()
+
+			err := d.probeCandidate(ctx, c.runtimeEndpoint)
+			logrus.Debugf("Probed endpoint %q from %s: %v", c.runtimeEndpoint, c.source, err)
+
+			results[i] <- err
+		}()
+	}
+
+	errs := make([]error, 0, len(candidates))
+
+	// Return the first reachable endpoint once all probes of higher
+	// precedence have failed. The remaining probes are canceled.
+	for i, c := range candidates {
+		err := <-results[i]
+		if err == nil {
+			return &discoveredEndpoint{
+				runtimeEndpoint: c.runtimeEndpoint,
+				imageEndpoint:   c.imageEndpoint,
+				source:          c.source,
+			}, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s (%s): %w", c.runtimeEndpoint, c.source, err))
+	}
+
+	return nil, fmt.Errorf("discover endpoint: no reachable endpoint found:\n%w", errors.Join(errs...))
+}
+
+// probeCandidate skips missing unix sockets before probing the endpoint,
+// because connecting to them would block until the timeout.
+func (d *endpointDiscovery) probeCandidate(ctx context.Context, endpoint string) error {
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme == "unix" {
+		if _, err := os.Stat(u.Path); err != nil {
+			return fmt.Errorf("socket not found: %w", err)
+		}
+	}
+
+	return d.probe(ctx, endpoint)
+}
+
+// candidates returns all unique endpoint candidates in the order of
+// precedence.
+func (d *endpointDiscovery) candidates() []*endpointCandidate {
+	candidates := []*endpointCandidate{}
+	seen := map[string]bool{}
+
+	add := func(runtimeEndpoint, imageEndpoint, source string) {
+		if runtimeEndpoint == "" {
+			return
+		}
+
+		runtimeEndpoint = normalizeEndpoint(runtimeEndpoint)
+		if seen[runtimeEndpoint] {
+			return
+		}
+
+		if imageEndpoint != "" {
+			imageEndpoint = normalizeEndpoint(imageEndpoint)
+		}
+
+		seen[runtimeEndpoint] = true
+		candidates = append(candidates, &endpointCandidate{runtimeEndpoint, imageEndpoint, source})
+	}
+
+	configPaths := []string{}
+
+	// Flags of a running kubelet take precedence over its config file.
+	pid, args := d.kubeletCommandLine()
+	if args != nil {
+		source := fmt.Sprintf("kubelet command line (pid %d)", pid)
+		add(kubeletFlag(args, kubeletRuntimeEndpointFlag), kubeletFlag(args, kubeletImageEndpointFlag), source)
+
+		if path := kubeletFlag(args, kubeletConfigFlag); path != "" {
+			configPaths = append(configPaths, path)
+		}
+	}
+
+	units := d.kubeletUnits()
+	for _, unit := range units {
+		if path := kubeletFlag(unit.args, kubeletConfigFlag); path != "" {
+			configPaths = append(configPaths, path)
+		}
+	}
+
+	for _, path := range append(configPaths, d.kubeletConfigPath) {
+		runtimeEndpoint, imageEndpoint, err := readKubeletConfigEndpoints(path)
+		if err != nil {
+			logrus.Debugf("Skipping kubelet config %q: %v", path, err)
+
+			continue
+		}
+
+		add(runtimeEndpoint, imageEndpoint, "kubelet config "+path)
+	}
+
+	for _, unit := range units {
+		add(kubeletFlag(unit.args, kubeletRuntimeEndpointFlag), kubeletFlag(unit.args, kubeletImageEndpointFlag), "kubelet unit "+unit.path)
+	}
+
+	for _, endpoint := range d.defaultEndpoints {
+		add(endpoint, "", sourceDefaultSocket)
+	}
+
+	return candidates
+}
+
+// kubeletCommandLine returns the PID and arguments of the first running
+// kubelet process found in the proc file system.
+func (d *endpointDiscovery) kubeletCommandLine() (pid int, args []string) {
+	entries, err := os.ReadDir(d.procDir)
+	if err != nil {
+		logrus.Debugf("Unable to read %s: %v", d.procDir, err)
+
+		return 0, nil
+	}
+
+	for _, entry := range entries {
+		if _, err := fmt.Sscan(entry.Name(), &pid); err != nil || !entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(d.procDir, entry.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			continue
+		}
+
+		args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		if filepath.Base(args[0]) == "kubelet" {
+			return pid, args[1:]
+		}
+	}
+
+	return 0, nil
+}
+
+// kubeletUnit are the kubelet arguments found in a systemd unit file.
+type kubeletUnit struct {
+	path string
+	args []string
+}
+
+// kubeletUnits returns the arguments of all existing kubelet unit files.
+func (d *endpointDiscovery) kubeletUnits() []kubeletUnit {
+	units := []kubeletUnit{}
+
+	for _, pattern := range d.kubeletUnitPaths {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				logrus.Debugf("Skipping kubelet unit %q: %v", path, err)
+
+				continue
+			}
+
+			units = append(units, kubeletUnit{path: path, args: unitArgs(string(data))})
+		}
+	}
+
+	return units
+}
+
+// unitArgs splits the content of a unit or environment file into words, which
+// is sufficient to find flags in ExecStart lines and KUBELET_*_ARGS variables.
+func unitArgs(content string) []string {
+	content = strings.ReplaceAll(content, "\\\n", " ")
+
+	return strings.FieldsFunc(content, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '"' || r == '\'' || r == '='
+	})
+}
+
+// kubeletFlag returns the value of the flag from the arguments, supporting
+// the "--flag=value", "--flag value" and single dash forms.
+func kubeletFlag(args []string, name string) (value string) {
+	for i, arg := range args {
+		trimmed := strings.TrimLeft(arg, "-")
+		if len(arg)-len(trimmed) == 0 {
+			continue
+		}
+
+		if v, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			value = v
+		} else if trimmed == name && i+1 < len(args) {
+			value = args[i+1]
+		}
+	}
+
+	// The last occurrence wins, like for the kubelet itself.
+	return value
+}
+
+// readKubeletConfigEndpoints returns the endpoints of a KubeletConfiguration.
+func readKubeletConfigEndpoints(path string) (runtimeEndpoint, imageEndpoint string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	config := struct {
+		ContainerRuntimeEndpoint string `json:"containerRuntimeEndpoint"`
+		ImageServiceEndpoint     string `json:"imageServiceEndpoint"`
+	}{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("parse kubelet config: %w", err)
+	}
+
+	return config.ContainerRuntimeEndpoint, config.ImageServiceEndpoint, nil
+}
+
+// normalizeEndpoint adds the unix scheme to plain socket paths, like the
+// kubelet does.
+func normalizeEndpoint(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+
+	return "unix://" + endpoint
+}
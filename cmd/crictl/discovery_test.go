@@ -0,0 +1,203 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newTestEndpointDiscovery returns a discovery using the temporary directory
+// and a probe which only succeeds for the reachable endpoints.
+func newTestEndpointDiscovery(t *testing.T, reachable ...string) (d *endpointDiscovery, dir string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	d = newEndpointDiscovery(func(_ context.Context, endpoint string) error {
+		if slices.Contains(reachable, endpoint) {
+			return nil
+		}
+
+		return errors.New("connection refused")
+	})
+	d.procDir = filepath.Join(dir, "proc")
+	d.kubeletConfigPath = filepath.Join(dir, "kubelet", "config.yaml")
+	d.kubeletUnitPaths = []string{filepath.Join(dir, "systemd", "*.conf")}
+	d.defaultEndpoints = []string{"unix://" + filepath.Join(dir, "containerd.sock"), "unix://" + filepath.Join(dir, "crio.sock")}
+
+	return d, dir
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEndpointDiscovery(t *testing.T) {
+	t.Parallel()
+
+	t.Run("kubelet command line takes precedence", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		d, dir := newTestEndpointDiscovery(t)
+		crio := filepath.Join(dir, "crio.sock")
+		configPath := filepath.Join(dir, "custom-config.yaml")
+
+		writeTestFile(t, crio, "")
+		writeTestFile(t, filepath.Join(dir, "proc", "42", "cmdline"), strings.Join([]string{
+			"/usr/bin/kubelet", "--config", configPath, "--container-runtime-endpoint=" + crio, "",
+		}, "\x00"))
+		writeTestFile(t, filepath.Join(dir, "proc", "1", "cmdline"), "/sbin/init\x00")
+		writeTestFile(t, configPath, "containerRuntimeEndpoint: unix:///run/other.sock\n")
+		d.probe = func(context.Context, string) error { return nil }
+
+		candidates := d.candidates()
+		g.Expect(candidates).To(HaveLen(3))
+		g.Expect(candidates[0].runtimeEndpoint).To(Equal("unix://" + crio))
+		g.Expect(candidates[0].source).To(Equal("kubelet command line (pid 42)"))
+		g.Expect(candidates[1].source).To(Equal("kubelet config " + configPath))
+
+		discovered, err := d.discover(context.Background(), time.Second)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(discovered.runtimeEndpoint).To(Equal("unix://" + crio))
+	})
+
+	t.Run("unreachable kubelet config falls back to default socket", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		d, dir := newTestEndpointDiscovery(t)
+		containerd := filepath.Join(dir, "containerd.sock")
+		kubeletSock := filepath.Join(dir, "kubelet.sock")
+
+		writeTestFile(t, containerd, "")
+		writeTestFile(t, kubeletSock, "")
+		writeTestFile(t, d.kubeletConfigPath,
+			"kind: KubeletConfiguration\ncontainerRuntimeEndpoint: unix://"+kubeletSock+"\nimageServiceEndpoint: unix:///run/image.sock\n")
+		d.probe = func(_ context.Context, endpoint string) error {
+			if endpoint == "unix://"+containerd {
+				return nil
+			}
+
+			return errors.New("connection refused")
+		}
+
+		discovered, err := d.discover(context.Background(), time.Second)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(discovered.runtimeEndpoint).To(Equal("unix://" + containerd))
+		g.Expect(discovered.source).To(Equal(sourceDefaultSocket))
+	})
+
+	t.Run("systemd unit and image endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		d, dir := newTestEndpointDiscovery(t)
+		crio := filepath.Join(dir, "crio.sock")
+		unit := filepath.Join(dir, "systemd", "10-kubeadm.conf")
+
+		writeTestFile(t, crio, "")
+		writeTestFile(t, unit, "[Service]\nEnvironment=\"KUBELET_ARGS=--image-service-endpoint unix:///run/image.sock "+
+			"--container-runtime-endpoint="+crio+"\"\nExecStart=/usr/bin/kubelet $KUBELET_ARGS\n")
+		d.probe = func(context.Context, string) error { return nil }
+
+		discovered, err := d.discover(context.Background(), time.Second)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(discovered.runtimeEndpoint).To(Equal("unix://" + crio))
+		g.Expect(discovered.imageEndpoint).To(Equal("unix:///run/image.sock"))
+		g.Expect(discovered.source).To(Equal("kubelet unit " + unit))
+	})
+
+	t.Run("does not wait for probes of lower precedence", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		d, dir := newTestEndpointDiscovery(t)
+		containerd := filepath.Join(dir, "containerd.sock")
+		crio := filepath.Join(dir, "crio.sock")
+
+		writeTestFile(t, containerd, "")
+		writeTestFile(t, crio, "")
+		d.probe = func(ctx context.Context, endpoint string) error {
+			if endpoint == "unix://"+containerd {
+				return nil
+			}
+
+			<-ctx.Done()
+
+			return ctx.Err()
+		}
+
+		start := time.Now()
+		discovered, err := d.discover(context.Background(), time.Minute)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(discovered.runtimeEndpoint).To(Equal("unix://" + containerd))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+	})
+
+	t.Run("no reachable endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		d, _ := newTestEndpointDiscovery(t)
+
+		_, err := d.discover(context.Background(), time.Second)
+		g.Expect(err).To(MatchError(ContainSubstring("socket not found")))
+	})
+}
+
+func TestKubeletFlag(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{desc: "equal sign", args: []string{"--container-runtime-endpoint=unix:///a.sock"}, expected: "unix:///a.sock"},
+		{desc: "separate value", args: []string{"-container-runtime-endpoint", "/a.sock"}, expected: "/a.sock"},
+		{desc: "last one wins", args: []string{"--container-runtime-endpoint=a", "--container-runtime-endpoint=b"}, expected: "b"},
+		{desc: "not a flag", args: []string{"container-runtime-endpoint=a"}},
+		{desc: "missing value", args: []string{"--container-runtime-endpoint"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if got := kubeletFlag(tc.args, kubeletRuntimeEndpointFlag); got != tc.expected {
+				t.Errorf("kubeletFlag(%v) = %q, want %q", tc.args, got, tc.expected)
+			}
+		})
+	}
+}
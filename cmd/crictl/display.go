@@ -46,6 +46,10 @@ const (
 	columnCPU        = "CPU %"
 	columnKey        = "KEY"
 	columnValue      = "VALUE"
+	columnCurrent    = "CURRENT"
+	columnRuntimeEP  = "RUNTIME ENDPOINT"
+	columnImageEP    = "IMAGE ENDPOINT"
+	columnSource     = "SOURCE"
 )
 
 // display use to output something on screen with table format.
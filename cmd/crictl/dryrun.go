@@ -0,0 +1,286 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/runtime/protoiface"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	flagDryRun       = "dry-run"
+	flagDryRunOutput = "dry-run-output"
+
+	// dryRunPodSandboxID and dryRunContainerID are returned instead of the
+	// IDs of the pods and containers which would have been created.
+	dryRunPodSandboxID = "<dry-run-pod-sandbox-id>"
+	dryRunContainerID  = "<dry-run-container-id>"
+)
+
+// dryRunFlags returns the flags of the commands supporting dry-run mode.
+func dryRunFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  flagDryRun,
+			Usage: "Print the CRI requests which modify the runtime instead of sending them",
+		},
+		&cli.StringFlag{
+			Name:  flagDryRunOutput,
+			Value: outputTypeJSON,
+			Usage: "Output format of the dry-run report, one of: json|yaml",
+		},
+	}
+}
+
+// beginDryRun enables the dry-run mode if requested. It is the Before hook of
+// the commands supporting dry-run mode.
+func beginDryRun(c *cli.Context) error {
+	if !c.Bool(flagDryRun) {
+		return nil
+	}
+
+	format := c.String(flagDryRunOutput)
+	if format != outputTypeJSON && format != outputTypeYAML {
+		return fmt.Errorf("unsupported --%s %q, expected %s or %s", flagDryRunOutput, format, outputTypeJSON, outputTypeYAML)
+	}
+
+	configFromContext(c).dryRun = &dryRunRecorder{format: format, out: os.Stdout}
+
+	return nil
+}
+
+// endDryRun prints the dry-run report. It is the After hook of the commands
+// supporting dry-run mode.
+func endDryRun(c *cli.Context) error {
+	cfg := configFromContext(c)
+	if cfg == nil || cfg.dryRun == nil {
+		return nil
+	}
+
+	recorder := cfg.dryRun
+	cfg.dryRun = nil
+
+	return recorder.print()
+}
+
+// dryRunning returns true if the client records the RPCs modifying the
+// runtime instead of sending them. The commands don't print the placeholder
+// results of these RPCs then, which would be mixed with the dry-run report.
+func dryRunning(client any) bool {
+	switch client.(type) {
+	case *dryRunRuntimeService, *dryRunImageService:
+		return true
+	default:
+		return false
+	}
+}
+
+// dryRunReport is the output of dry-run mode.
+type dryRunReport struct {
+	// Requests are the CRI requests which would have been sent, in order.
+	Requests []dryRunRequest `json:"requests"`
+	// Affected are the objects which would have been modified.
+	Affected []dryRunObject `json:"affected"`
+}
+
+type dryRunRequest struct {
+	RPC     string          `json:"rpc"`
+	Request json.RawMessage `json:"request"`
+}
+
+type dryRunObject struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Action string `json:"action"`
+}
+
+// dryRunRecorder records the requests of mutating RPCs in dry-run mode.
+type dryRunRecorder struct {
+	format string
+	out    io.Writer
+
+	mu     sync.Mutex
+	report dryRunReport
+}
+
+func (r *dryRunRecorder) record(rpc string, request protoiface.MessageV1, affected dryRunObject) error {
+	data, err := protojson.Marshal(protoadapt.MessageV2Of(request))
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", rpc, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Requests = append(r.report.Requests, dryRunRequest{RPC: rpc, Request: data})
+	r.report.Affected = append(r.report.Affected, affected)
+
+	return nil
+}
+
+func (r *dryRunRecorder) print() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.report.Requests == nil {
+		r.report.Requests = []dryRunRequest{}
+		r.report.Affected = []dryRunObject{}
+	}
+
+	data := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(&r.report); err != nil {
+		return fmt.Errorf("marshal dry-run report: %w", err)
+	}
+
+	output := data.Bytes()
+
+	if r.format == outputTypeYAML {
+		var err error
+
+		output, err = yaml.JSONToYAML(output)
+		if err != nil {
+			return fmt.Errorf("convert dry-run report to YAML: %w", err)
+		}
+	}
+
+	if _, err := r.out.Write(output); err != nil {
+		return fmt.Errorf("write dry-run report: %w", err)
+	}
+
+	return nil
+}
+
+// dryRunRuntimeService records all RPCs which modify the runtime instead of
+// sending them. All other RPCs are sent to resolve the affected objects.
+type dryRunRuntimeService struct {
+	internalapi.RuntimeService
+
+	recorder *dryRunRecorder
+}
+
+func (d *dryRunRuntimeService) RunPodSandbox(_ context.Context, config *runtimeapi.PodSandboxConfig, runtimeHandler string) (string, error) {
+	return dryRunPodSandboxID, d.recorder.record("RunPodSandbox",
+		&runtimeapi.RunPodSandboxRequest{Config: config, RuntimeHandler: runtimeHandler},
+		dryRunObject{Kind: "PodSandbox", ID: dryRunPodSandboxID, Action: "create"})
+}
+
+func (d *dryRunRuntimeService) StopPodSandbox(_ context.Context, podSandboxID string) error {
+	return d.recorder.record("StopPodSandbox",
+		&runtimeapi.StopPodSandboxRequest{PodSandboxId: podSandboxID},
+		dryRunObject{Kind: "PodSandbox", ID: podSandboxID, Action: "stop"})
+}
+
+func (d *dryRunRuntimeService) RemovePodSandbox(_ context.Context, podSandboxID string) error {
+	return d.recorder.record("RemovePodSandbox",
+		&runtimeapi.RemovePodSandboxRequest{PodSandboxId: podSandboxID},
+		dryRunObject{Kind: "PodSandbox", ID: podSandboxID, Action: "remove"})
+}
+
+func (d *dryRunRuntimeService) UpdatePodSandboxResources(_ context.Context, req *runtimeapi.UpdatePodSandboxResourcesRequest) (*runtimeapi.UpdatePodSandboxResourcesResponse, error) {
+	return &runtimeapi.UpdatePodSandboxResourcesResponse{}, d.recorder.record("UpdatePodSandboxResources", req,
+		dryRunObject{Kind: "PodSandbox", ID: req.GetPodSandboxId(), Action: "update"})
+}
+
+func (d *dryRunRuntimeService) CreateContainer(_ context.Context, podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	return dryRunContainerID, d.recorder.record("CreateContainer",
+		&runtimeapi.CreateContainerRequest{PodSandboxId: podSandboxID, Config: config, SandboxConfig: sandboxConfig},
+		dryRunObject{Kind: "Container", ID: dryRunContainerID, Action: "create"})
+}
+
+func (d *dryRunRuntimeService) StartContainer(_ context.Context, containerID string) error {
+	return d.recorder.record("StartContainer",
+		&runtimeapi.StartContainerRequest{ContainerId: containerID},
+		dryRunObject{Kind: "Container", ID: containerID, Action: "start"})
+}
+
+func (d *dryRunRuntimeService) StopContainer(_ context.Context, containerID string, timeout int64) error {
+	return d.recorder.record("StopContainer",
+		&runtimeapi.StopContainerRequest{ContainerId: containerID, Timeout: timeout},
+		dryRunObject{Kind: "Container", ID: containerID, Action: "stop"})
+}
+
+func (d *dryRunRuntimeService) RemoveContainer(_ context.Context, containerID string) error {
+	return d.recorder.record("RemoveContainer",
+		&runtimeapi.RemoveContainerRequest{ContainerId: containerID},
+		dryRunObject{Kind: "Container", ID: containerID, Action: "remove"})
+}
+
+func (d *dryRunRuntimeService) UpdateContainerResources(_ context.Context, containerID string, resources *runtimeapi.ContainerResources) error {
+	return d.recorder.record("UpdateContainerResources",
+		&runtimeapi.UpdateContainerResourcesRequest{ContainerId: containerID, Linux: resources.GetLinux(), Windows: resources.GetWindows()},
+		dryRunObject{Kind: "Container", ID: containerID, Action: "update"})
+}
+
+func (d *dryRunRuntimeService) ReopenContainerLog(_ context.Context, containerID string) error {
+	return d.recorder.record("ReopenContainerLog",
+		&runtimeapi.ReopenContainerLogRequest{ContainerId: containerID},
+		dryRunObject{Kind: "Container", ID: containerID, Action: "reopen-log"})
+}
+
+func (d *dryRunRuntimeService) CheckpointContainer(_ context.Context, req *runtimeapi.CheckpointContainerRequest) error {
+	return d.recorder.record("CheckpointContainer", req,
+		dryRunObject{Kind: "Container", ID: req.GetContainerId(), Action: "checkpoint"})
+}
+
+func (d *dryRunRuntimeService) UpdateRuntimeConfig(_ context.Context, runtimeConfig *runtimeapi.RuntimeConfig) error {
+	return d.recorder.record("UpdateRuntimeConfig",
+		&runtimeapi.UpdateRuntimeConfigRequest{RuntimeConfig: runtimeConfig},
+		dryRunObject{Kind: "RuntimeConfig", Action: "update"})
+}
+
+// dryRunImageService records all RPCs which modify the images instead of
+// sending them.
+type dryRunImageService struct {
+	internalapi.ImageManagerService
+
+	recorder *dryRunRecorder
+}
+
+func (d *dryRunImageService) PullImage(_ context.Context, image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig, podSandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	request := &runtimeapi.PullImageRequest{Image: image, SandboxConfig: podSandboxConfig}
+	if auth != nil {
+		// Credentials must not be printed.
+		request.Auth = &runtimeapi.AuthConfig{Username: auth.GetUsername()}
+	}
+
+	return image.GetImage(), d.recorder.record("PullImage", request,
+		dryRunObject{Kind: "Image", ID: image.GetImage(), Action: "pull"})
+}
+
+func (d *dryRunImageService) RemoveImage(_ context.Context, image *runtimeapi.ImageSpec) error {
+	return d.recorder.record("RemoveImage",
+		&runtimeapi.RemoveImageRequest{Image: image},
+		dryRunObject{Kind: "Image", ID: image.GetImage(), Action: "remove"})
+}
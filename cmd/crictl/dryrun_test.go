@@ -0,0 +1,185 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc     string
+		format   string
+		readOnly bool
+		expected string
+	}{
+		{
+			desc:   "json",
+			format: outputTypeJSON,
+			expected: `{
+  "requests": [
+    {
+      "rpc": "RunPodSandbox",
+      "request": {
+        "config": {
+          "metadata": {
+            "name": "nginx",
+            "namespace": "default"
+          }
+        },
+        "runtimeHandler": "kata"
+      }
+    },
+    {
+      "rpc": "CreateContainer",
+      "request": {
+        "podSandboxId": "<dry-run-pod-sandbox-id>"
+      }
+    },
+    {
+      "rpc": "RemoveImage",
+      "request": {
+        "image": {
+          "image": "busybox"
+        }
+      }
+    }
+  ],
+  "affected": [
+    {
+      "kind": "PodSandbox",
+      "id": "<dry-run-pod-sandbox-id>",
+      "action": "create"
+    },
+    {
+      "kind": "Container",
+      "id": "<dry-run-container-id>",
+      "action": "create"
+    },
+    {
+      "kind": "Image",
+      "id": "busybox",
+      "action": "remove"
+    }
+  ]
+}
+`,
+		},
+		{
+			desc:     "yaml in read-only mode",
+			format:   outputTypeYAML,
+			readOnly: true,
+			expected: `affected:
+- action: create
+  id: <dry-run-pod-sandbox-id>
+  kind: PodSandbox
+- action: create
+  id: <dry-run-container-id>
+  kind: Container
+- action: remove
+  id: busybox
+  kind: Image
+requests:
+- request:
+    config:
+      metadata:
+        name: nginx
+        namespace: default
+    runtimeHandler: kata
+  rpc: RunPodSandbox
+- request:
+    podSandboxId: <dry-run-pod-sandbox-id>
+  rpc: CreateContainer
+- request:
+    image:
+      image: busybox
+  rpc: RemoveImage
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			out := &bytes.Buffer{}
+			cfg := &CrictlConfig{
+				ReadOnly:               tc.readOnly,
+				dryRun:                 &dryRunRecorder{format: tc.format, out: out},
+				runtimeServiceOverride: fakeRuntimeSvc{},
+				imageServiceOverride:   fakeImageSvc{},
+			}
+
+			rs, err := cfg.GetRuntimeService(ctx, 0)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			is, err := cfg.GetImageService(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(dryRunning(rs)).To(BeTrue())
+			g.Expect(dryRunning(is)).To(BeTrue())
+			g.Expect(dryRunning(fakeImageSvc{})).To(BeFalse())
+
+			podID, err := rs.RunPodSandbox(ctx, &runtimeapi.PodSandboxConfig{
+				Metadata: &runtimeapi.PodSandboxMetadata{Name: "nginx", Namespace: "default"},
+			}, "kata")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(podID).To(Equal(dryRunPodSandboxID))
+
+			ctrID, err := rs.CreateContainer(ctx, podID, nil, nil)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ctrID).To(Equal(dryRunContainerID))
+
+			g.Expect(is.RemoveImage(ctx, &runtimeapi.ImageSpec{Image: "busybox"})).To(Succeed())
+
+			g.Expect(cfg.dryRun.print()).To(Succeed())
+			g.Expect(out.String()).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestDryRunRedactsCredentials(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	out := &bytes.Buffer{}
+	cfg := &CrictlConfig{
+		dryRun:               &dryRunRecorder{format: outputTypeJSON, out: out},
+		imageServiceOverride: fakeImageSvc{},
+	}
+
+	is, err := cfg.GetImageService(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = is.PullImage(ctx, &runtimeapi.ImageSpec{Image: "busybox"}, &runtimeapi.AuthConfig{Username: "user", Password: "secret"}, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(cfg.dryRun.print()).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`"username": "user"`))
+	g.Expect(out.String()).NotTo(ContainSubstring("secret"))
+}
@@ -21,6 +21,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -28,6 +32,14 @@ import (
 	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
+// eventTypes maps the --type values to the container event types.
+var eventTypes = map[string]pb.ContainerEventType{
+	"created": pb.ContainerEventType_CONTAINER_CREATED_EVENT,
+	"started": pb.ContainerEventType_CONTAINER_STARTED_EVENT,
+	"stopped": pb.ContainerEventType_CONTAINER_STOPPED_EVENT,
+	"deleted": pb.ContainerEventType_CONTAINER_DELETED_EVENT,
+}
+
 var eventsCommand = &cli.Command{
 	Name:                   "events",
 	Usage:                  "Stream the events of containers",
@@ -38,20 +50,52 @@ var eventsCommand = &cli.Command{
 			Name:    "output",
 			Aliases: []string{"o"},
 			Value:   outputTypeJSON,
-			Usage:   "Output format, One of: json|yaml|go-template",
+			Usage:   "Output format, One of: json|yaml|go-template|table",
 		},
 		&cli.StringFlag{
 			Name:  "template",
 			Usage: "The template string is only used when output is go-template; The Template format is golang template",
 		},
+		&cli.StringSliceFlag{
+			Name:  "container",
+			Usage: "Filter by container ID prefix or name, can be specified multiple times",
+		},
+		&cli.StringSliceFlag{
+			Name:  "pod",
+			Usage: "Filter by pod ID prefix, name or namespace/name, can be specified multiple times",
+		},
+		&cli.StringSliceFlag{
+			Name:  "type",
+			Usage: "Filter by event type, one of: created|started|stopped|deleted, can be specified multiple times",
+		},
+		&cli.StringSliceFlag{
+			Name:  "label",
+			Usage: "Filter by key=value label of the container or its pod",
+		},
+		&cli.StringSliceFlag{
+			Name:  "sink",
+			Usage: "Deliver every event as JSON to a sink, one of: http(s)://URL|file:PATH[?max-size=SIZE&max-files=N]|exec:COMMAND, can be specified multiple times",
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Usage:   "Do not print the events, only deliver them to the sinks",
+		},
+		&cli.BoolFlag{
+			Name:  "reconnect",
+			Value: true,
+			Usage: "Reconnect with backoff if the event stream is lost, for example because the runtime restarts",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.NArg() != 0 {
 			return cli.ShowSubcommandHelp(c)
 		}
 
-		switch format := c.String("output"); format {
-		case outputTypeJSON, outputTypeYAML:
+		format := outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable)
+
+		switch format {
+		case outputTypeJSON, outputTypeYAML, outputTypeTable:
 			if c.String("template") != "" {
 				return fmt.Errorf("template can't be used with %q format", format)
 			}
@@ -63,12 +107,37 @@ var eventsCommand = &cli.Command{
 			return fmt.Errorf("don't support %q format", format)
 		}
 
+		filter, err := newEventFilter(c)
+		if err != nil {
+			return err
+		}
+
+		opts := &eventsOptions{
+			filter:   filter,
+			output:   format,
+			template: c.String("template"),
+			quiet:    c.Bool("quiet"),
+		}
+
+		for _, spec := range c.StringSlice("sink") {
+			sink, err := newEventSink(spec)
+			if err != nil {
+				return err
+			}
+
+			opts.sinks = append(opts.sinks, sink)
+		}
+
 		runtimeClient, err := configFromContext(c).GetRuntimeService(c.Context, 0)
 		if err != nil {
 			return err
 		}
 
-		if err = Events(c, runtimeClient); err != nil {
+		if c.Bool("reconnect") {
+			opts.reconnect = newRetryPolicy(0)
+		}
+
+		if err = Events(c.Context, runtimeClient, opts); err != nil {
 			return fmt.Errorf("getting container events: %w", err)
 		}
 
@@ -76,7 +145,32 @@ var eventsCommand = &cli.Command{
 	},
 }
 
-func Events(cliContext *cli.Context, client internalapi.RuntimeService) error {
+type eventsOptions struct {
+	filter   *eventFilter
+	output   string
+	template string
+	// quiet does not print the events, which are only delivered to the sinks.
+	quiet bool
+	sinks []eventSink
+	// reconnect is the backoff of reconnecting to a lost event stream, or
+	// nil to return on a lost stream. Its retries are not limited.
+	reconnect *retryPolicy
+}
+
+func Events(ctx context.Context, client internalapi.RuntimeService, opts *eventsOptions) error {
+	sinks := make([]eventSink, 0, len(opts.sinks))
+	for _, sink := range opts.sinks {
+		sinks = append(sinks, newQueuedSink(ctx, sink, sinkQueueSize))
+	}
+
+	defer func() {
+		for _, sink := range sinks {
+			if err := sink.close(); err != nil {
+				logrus.Warnf("Unable to close sink %s: %v", sink, err)
+			}
+		}
+	}()
+
 	errCh := make(chan error, 1)
 
 	containerEventsCh := make(chan *pb.ContainerEventResponse)
@@ -84,27 +178,250 @@ func Events(cliContext *cli.Context, client internalapi.RuntimeService) error {
 	go func() {
 		logrus.Debug("getting container events")
 
-		_, err := InterruptableRPC(cliContext.Context, func(ctx context.Context) (any, error) {
-			return nil, client.GetContainerEvents(ctx, containerEventsCh, nil)
+		_, err := InterruptableRPC(ctx, func(ctx context.Context) (any, error) {
+			return nil, subscribeEvents(ctx, client, containerEventsCh, opts.reconnect)
 		})
-		if errors.Is(err, io.EOF) {
-			errCh <- nil
-
-			return
-		}
-
 		errCh <- err
 	}()
 
+	table := &eventTable{out: os.Stdout}
+
 	for {
 		select {
 		case err := <-errCh:
 			return err
 		case e := <-containerEventsCh:
-			err := outputEvent(e, cliContext.String("output"), cliContext.String("template"))
+			if !opts.filter.matches(e) {
+				continue
+			}
+
+			deliverEvent(ctx, e, sinks)
+
+			if opts.quiet {
+				continue
+			}
+
+			var err error
+			if opts.output == outputTypeTable {
+				err = table.print(e)
+			} else {
+				err = outputEvent(e, opts.output, opts.template)
+			}
+
 			if err != nil {
 				fmt.Printf("failed to format container event with the error: %s\n", err)
 			}
 		}
 	}
 }
+
+// subscribeEvents sends the container events to the channel until the stream
+// ends. If the stream is lost and the policy is set, it reconnects with
+// backoff and reports the gap, in which events may have been missed.
+func subscribeEvents(ctx context.Context, client internalapi.RuntimeService, eventsCh chan *pb.ContainerEventResponse, policy *retryPolicy) error {
+	var lost time.Time
+
+	delay := time.Duration(0)
+	if policy != nil {
+		delay = policy.delay
+	}
+
+	for {
+		err := client.GetContainerEvents(ctx, eventsCh, func(pb.RuntimeService_GetContainerEventsClient) {
+			if lost.IsZero() {
+				return
+			}
+
+			now := time.Now()
+			logrus.Warnf("Reconnected to the event stream after %v, events between %s and %s may have been missed",
+				now.Sub(lost).Round(time.Millisecond), lost.Format(time.RFC3339), now.Format(time.RFC3339))
+
+			lost = time.Time{}
+			delay = policy.delay
+		})
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if policy == nil || !(errors.Is(err, io.EOF) || isTransientError(err)) {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if lost.IsZero() {
+			lost = time.Now()
+			logrus.Warnf("Lost the event stream: %v, reconnecting", err)
+		}
+
+		logrus.Debugf("Reconnecting to the event stream in %v", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, policy.maxDelay)
+	}
+}
+
+// eventFilter selects the container events to print. Empty criteria match
+// all events, every set criterion has to match.
+type eventFilter struct {
+	// containers are container ID prefixes or names.
+	containers []string
+	// pods are pod ID prefixes, names or namespace/name references.
+	pods   []string
+	types  []pb.ContainerEventType
+	labels map[string]string
+}
+
+func newEventFilter(c *cli.Context) (*eventFilter, error) {
+	labels, err := parseLabelStringSlice(c.StringSlice("label"))
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &eventFilter{
+		containers: c.StringSlice("container"),
+		pods:       c.StringSlice("pod"),
+		labels:     labels,
+	}
+
+	for _, name := range c.StringSlice("type") {
+		eventType, ok := eventTypes[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown event type %q, expected one of: created|started|stopped|deleted", name)
+		}
+
+		filter.types = append(filter.types, eventType)
+	}
+
+	return filter, nil
+}
+
+func (f *eventFilter) matches(e *pb.ContainerEventResponse) bool {
+	if len(f.types) > 0 && !slices.Contains(f.types, e.GetContainerEventType()) {
+		return false
+	}
+
+	container := eventContainer(e)
+
+	if len(f.containers) > 0 && !slices.ContainsFunc(f.containers, func(ref string) bool {
+		return strings.HasPrefix(e.GetContainerId(), ref) ||
+			(container != nil && container.GetMetadata().GetName() == ref)
+	}) {
+		return false
+	}
+
+	pod := e.GetPodSandboxStatus()
+
+	if len(f.pods) > 0 && !slices.ContainsFunc(f.pods, func(ref string) bool {
+		return pod != nil && (strings.HasPrefix(pod.GetId(), ref) ||
+			pod.GetMetadata().GetName() == ref ||
+			pod.GetMetadata().GetNamespace()+"/"+pod.GetMetadata().GetName() == ref)
+	}) {
+		return false
+	}
+
+	for key, value := range f.labels {
+		if container.GetLabels()[key] != value && pod.GetLabels()[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// eventContainer returns the status of the container of the event, or nil if
+// the runtime did not send it, for example for the events of pods.
+func eventContainer(e *pb.ContainerEventResponse) *pb.ContainerStatus {
+	for _, status := range e.GetContainersStatuses() {
+		if status.GetId() == e.GetContainerId() {
+			return status
+		}
+	}
+
+	return nil
+}
+
+// eventTypeName returns the --type value of the event type.
+func eventTypeName(eventType pb.ContainerEventType) string {
+	for name, t := range eventTypes {
+		if t == eventType {
+			return name
+		}
+	}
+
+	return eventType.String()
+}
+
+// eventTableColumns are the columns of the table output with their widths.
+// The events are printed as they arrive, so the widths are fixed.
+var eventTableColumns = []struct {
+	name  string
+	width int
+}{
+	{"TIME", 29},
+	{"TYPE", 7},
+	{columnContainer, truncatedIDLen},
+	{columnName, 25},
+	{columnPodName, 30},
+	{columnNamespace, 0},
+}
+
+// eventTable prints the events as table rows, starting with a header.
+type eventTable struct {
+	out           io.Writer
+	headerPrinted bool
+}
+
+func (t *eventTable) print(e *pb.ContainerEventResponse) error {
+	if !t.headerPrinted {
+		header := make([]string, 0, len(eventTableColumns))
+		for _, column := range eventTableColumns {
+			header = append(header, column.name)
+		}
+
+		if err := t.printRow(header); err != nil {
+			return err
+		}
+
+		t.headerPrinted = true
+	}
+
+	pod := e.GetPodSandboxStatus()
+
+	return t.printRow([]string{
+		time.Unix(0, e.GetCreatedAt()).Format("2006-01-02T15:04:05.000Z07:00"),
+		eventTypeName(e.GetContainerEventType()),
+		getTruncatedID(e.GetContainerId(), ""),
+		eventContainer(e).GetMetadata().GetName(),
+		pod.GetMetadata().GetName(),
+		pod.GetMetadata().GetNamespace(),
+	})
+}
+
+func (t *eventTable) printRow(row []string) error {
+	var line strings.Builder
+
+	for i, value := range row {
+		if i == len(row)-1 {
+			line.WriteString(value)
+
+			break
+		}
+
+		fmt.Fprintf(&line, "%-*s   ", eventTableColumns[i].width, value)
+	}
+
+	if _, err := fmt.Fprintln(t.out, line.String()); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+
+	return nil
+}
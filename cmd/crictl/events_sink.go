@@ -0,0 +1,445 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+const (
+	// defaultSinkFileMaxSize is the size a file sink is rotated at.
+	defaultSinkFileMaxSize = 10 * 1024 * 1024
+	// defaultSinkFileMaxFiles is the number of rotated files a file sink keeps.
+	defaultSinkFileMaxFiles = 5
+
+	// webhookTimeout limits a single webhook request.
+	webhookTimeout = 10 * time.Second
+	// webhookRetries is the number of retries of a failed webhook request.
+	webhookRetries = 3
+
+	// sinkQueueSize is the number of events queued for a sink, before new
+	// events are dropped.
+	sinkQueueSize = 1000
+	// sinkCloseTimeout limits the delivery of the queued events on exit.
+	sinkCloseTimeout = 5 * time.Second
+)
+
+// eventSink delivers the container events, as single line JSON, to an
+// external destination.
+type eventSink interface {
+	send(ctx context.Context, event []byte) error
+	close() error
+	String() string
+}
+
+// newEventSink creates a sink of a --sink value, which is one of:
+//
+//	http(s)://URL                                    POST every event to the webhook
+//	file:PATH[?max-size=SIZE&max-files=N]            append every event to the rotating file
+//	exec:COMMAND                                     run the shell command with every event on stdin
+func newEventSink(spec string) (eventSink, error) {
+	switch {
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		if _, err := url.ParseRequestURI(spec); err != nil {
+			return nil, fmt.Errorf("invalid webhook sink %q: %w", spec, err)
+		}
+
+		return &webhookSink{
+			url:    spec,
+			client: &http.Client{Timeout: webhookTimeout},
+			policy: newRetryPolicy(webhookRetries),
+		}, nil
+
+	case strings.HasPrefix(spec, "file:"):
+		return newFileSink(strings.TrimPrefix(spec, "file:"))
+
+	case strings.HasPrefix(spec, "exec:"):
+		command := strings.TrimSpace(strings.TrimPrefix(spec, "exec:"))
+		if command == "" {
+			return nil, fmt.Errorf("no command in sink %q", spec)
+		}
+
+		return &commandSink{command: command}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported sink %q, expected http(s)://URL, file:PATH or exec:COMMAND", spec)
+	}
+}
+
+// deliverEvent sends the event to all sinks. Failures are logged, so that a
+// failing sink neither stops the stream nor the other sinks.
+func deliverEvent(ctx context.Context, e *pb.ContainerEventResponse, sinks []eventSink) {
+	if len(sinks) == 0 {
+		return
+	}
+
+	event, err := protojson.MarshalOptions{EmitDefaultValues: true}.Marshal(e)
+	if err != nil {
+		logrus.Errorf("Unable to marshal container event: %v", err)
+
+		return
+	}
+
+	for _, sink := range sinks {
+		if err := sink.send(ctx, event); err != nil {
+			logrus.Errorf("Unable to deliver container event to sink %s: %v", sink, err)
+		}
+	}
+}
+
+// queuedSink delivers the events to a sink in the background, so that a slow
+// sink stalls neither the event stream nor the other sinks. If the queue is
+// full, new events are dropped.
+type queuedSink struct {
+	eventSink
+
+	events chan []byte
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	// dropped is the number of dropped events and overflowing is set while
+	// the queue is full, to warn only once per overflow.
+	dropped     atomic.Int64
+	overflowing bool
+}
+
+// newQueuedSink starts delivering the queued events to the sink until it is
+// closed.
+func newQueuedSink(ctx context.Context, sink eventSink, size int) *queuedSink {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s := &queuedSink{
+		eventSink: sink,
+		events:    make(chan []byte, size),
+		done:      make(chan struct{}),
+		cancel:    cancel,
+	}
+
+	go func() {
+		defer close(s.done)
+
+		for event := range s.events {
+			if ctx.Err() != nil {
+				s.dropped.Add(1)
+
+				continue
+			}
+
+			if err := sink.send(ctx, event); err != nil {
+				logrus.Errorf("Unable to deliver container event to sink %s: %v", sink, err)
+			}
+		}
+	}()
+
+	return s
+}
+
+// send queues the event and never fails.
+func (s *queuedSink) send(_ context.Context, event []byte) error {
+	select {
+	case s.events <- event:
+		s.overflowing = false
+	default:
+		if !s.overflowing {
+			logrus.Warnf("Dropping container events for sink %s, which is too slow to deliver the %d queued events", s, cap(s.events))
+		}
+
+		s.overflowing = true
+		s.dropped.Add(1)
+	}
+
+	return nil
+}
+
+// close delivers the queued events within sinkCloseTimeout, drops the
+// remaining ones and closes the sink.
+func (s *queuedSink) close() error {
+	close(s.events)
+
+	select {
+	case <-s.done:
+	case <-time.After(sinkCloseTimeout):
+		s.cancel()
+		<-s.done
+	}
+
+	s.cancel()
+
+	if dropped := s.dropped.Load(); dropped > 0 {
+		logrus.Warnf("Dropped %d container events for sink %s", dropped, s)
+	}
+
+	return s.eventSink.close()
+}
+
+// webhookSink POSTs every event to a URL, retrying failed requests with
+// backoff.
+type webhookSink struct {
+	url    string
+	client *http.Client
+	policy *retryPolicy
+}
+
+func (s *webhookSink) String() string {
+	return s.url
+}
+
+func (s *webhookSink) send(ctx context.Context, event []byte) error {
+	delay := s.policy.delay
+
+	for attempt := 0; ; attempt++ {
+		retryable, err := s.post(ctx, event)
+		if err == nil || !retryable || attempt == s.policy.maxRetries {
+			return err
+		}
+
+		logrus.Debugf("Webhook %s failed: %v, retry %d/%d in %v", s.url, err, attempt+1, s.policy.maxRetries, delay)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, s.policy.maxDelay)
+	}
+}
+
+// post sends the event once and returns whether a failure may be retried.
+func (s *webhookSink) post(ctx context.Context, event []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(event))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("post event: %w", err)
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		logrus.Debugf("Unable to close the webhook response: %v", err)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError,
+			fmt.Errorf("post event: unexpected status %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+func (s *webhookSink) close() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// fileSink appends every event as a line to a file. If the file would
+// exceed its maximum size, it is rotated to PATH.1, and the older files to
+// PATH.2 up to PATH.<maxFiles>.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+}
+
+func newFileSink(spec string) (*fileSink, error) {
+	path, query, _ := strings.Cut(spec, "?")
+
+	// Accept file:///path as well as file:/path.
+	if strings.HasPrefix(path, "//") {
+		path = path[2:]
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("no path in sink %q", "file:"+spec)
+	}
+
+	sink := &fileSink{path: path, maxSize: defaultSinkFileMaxSize, maxFiles: defaultSinkFileMaxFiles}
+
+	options, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options of file sink %q: %w", path, err)
+	}
+
+	for key := range options {
+		value := options.Get(key)
+
+		switch key {
+		case "max-size":
+			if sink.maxSize, err = units.RAMInBytes(value); err != nil || sink.maxSize < 0 {
+				return nil, fmt.Errorf("invalid max-size %q of file sink %q", value, path)
+			}
+		case "max-files":
+			if sink.maxFiles, err = strconv.Atoi(value); err != nil || sink.maxFiles < 0 {
+				return nil, fmt.Errorf("invalid max-files %q of file sink %q", value, path)
+			}
+		default:
+			return nil, fmt.Errorf("unknown option %q of file sink %q, expected max-size or max-files", key, path)
+		}
+	}
+
+	return sink, nil
+}
+
+func (s *fileSink) String() string {
+	return s.path
+}
+
+func (s *fileSink) send(_ context.Context, event []byte) error {
+	line := slices.Concat(event, []byte{'\n'})
+
+	if s.file != nil && s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open event file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(fmt.Errorf("stat event file: %w", err), file.Close())
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the file and shifts it and the rotated files by one, dropping
+// the oldest one. The next event opens a new file.
+func (s *fileSink) rotate() error {
+	if err := s.close(); err != nil {
+		return err
+	}
+
+	if s.maxFiles == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove event file: %w", err)
+		}
+
+		return nil
+	}
+
+	for i := s.maxFiles - 1; i >= 0; i-- {
+		from := s.path
+		if i > 0 {
+			from = fmt.Sprintf("%s.%d", s.path, i)
+		}
+
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", s.path, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotate event file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSink) close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	file := s.file
+	s.file = nil
+	s.size = 0
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close event file: %w", err)
+	}
+
+	return nil
+}
+
+// commandSink runs a shell command for every event, with the event on stdin.
+// The output of the command is written to stderr, to keep stdout for the
+// events.
+type commandSink struct {
+	command string
+}
+
+func (s *commandSink) String() string {
+	return s.command
+}
+
+func (s *commandSink) send(ctx context.Context, event []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.command)
+	}
+
+	cmd.Stdin = bytes.NewReader(slices.Concat(event, []byte{'\n'}))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run %q: %w", s.command, err)
+	}
+
+	return nil
+}
+
+func (s *commandSink) close() error {
+	return nil
+}
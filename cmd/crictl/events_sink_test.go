@@ -0,0 +1,248 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func TestNewEventSink(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		spec        string
+		expected    eventSink
+		expectedErr string
+	}{
+		{spec: "https://example.com/events", expected: &webhookSink{url: "https://example.com/events"}},
+		{spec: "file:/var/log/events.jsonl", expected: &fileSink{path: "/var/log/events.jsonl", maxSize: 10 * 1024 * 1024, maxFiles: 5}},
+		{spec: "file:///var/log/events.jsonl?max-size=1M&max-files=2", expected: &fileSink{path: "/var/log/events.jsonl", maxSize: 1024 * 1024, maxFiles: 2}},
+		{spec: "exec:/usr/local/bin/on-event --node worker-1", expected: &commandSink{command: "/usr/local/bin/on-event --node worker-1"}},
+		{spec: "file:/var/log/events.jsonl?max-size=big", expectedErr: `invalid max-size "big"`},
+		{spec: "file:/var/log/events.jsonl?compress=true", expectedErr: `unknown option "compress"`},
+		{spec: "file:", expectedErr: "no path"},
+		{spec: "exec:", expectedErr: "no command"},
+		{spec: "syslog://localhost", expectedErr: "unsupported sink"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+
+			sink, err := newEventSink(tc.spec)
+			if tc.expectedErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedErr)))
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+
+			if webhook, ok := sink.(*webhookSink); ok {
+				g.Expect(webhook.url).To(Equal(tc.expected.String()))
+
+				return
+			}
+
+			g.Expect(sink).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	var (
+		mu       sync.Mutex
+		requests int
+		received []map[string]any
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		var event map[string]any
+		if err := json.Unmarshal(body, &event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		received = append(received, event)
+	}))
+	defer server.Close()
+
+	sink := &webhookSink{
+		url:    server.URL,
+		client: server.Client(),
+		policy: &retryPolicy{maxRetries: 1, delay: time.Millisecond, maxDelay: time.Millisecond},
+	}
+
+	deliverEvent(context.Background(), testEvent(runtimeapi.ContainerEventType_CONTAINER_STOPPED_EVENT), []eventSink{sink})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	g.Expect(requests).To(Equal(2))
+	g.Expect(received).To(HaveLen(1))
+	g.Expect(received[0]).To(HaveKeyWithValue("containerEventType", "CONTAINER_STOPPED_EVENT"))
+
+	// Client errors are not retried.
+	sink.url = server.URL + "/%zz"
+	g.Expect(sink.send(context.Background(), []byte("{}"))).NotTo(Succeed())
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := newFileSink(path + "?max-size=10&max-files=2")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, event := range []string{"one", "two", "three", "four"} {
+		g.Expect(sink.send(context.Background(), []byte(event))).To(Succeed())
+	}
+
+	g.Expect(sink.close()).To(Succeed())
+
+	for file, expected := range map[string]string{
+		path:        "four\n",
+		path + ".1": "three\n",
+		path + ".2": "one\ntwo\n",
+	} {
+		content, err := os.ReadFile(file)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(content)).To(Equal(expected))
+	}
+
+	g.Expect(path + ".3").NotTo(BeAnExistingFile())
+}
+
+func TestCommandSink(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("tee is not available on Windows")
+	}
+
+	g := NewWithT(t)
+	out := filepath.Join(t.TempDir(), "event file.json")
+
+	// The command is run by a shell, which handles quoted arguments.
+	sink, err := newEventSink("exec:tee '" + out + "'")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sink.send(context.Background(), []byte(`{"containerId":"4dfb"}`))).To(Succeed())
+
+	content, err := os.ReadFile(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal(`{"containerId":"4dfb"}` + "\n"))
+
+	sink, err = newEventSink("exec:false")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sink.send(context.Background(), []byte("{}"))).NotTo(Succeed())
+}
+
+// blockingSink records the events, blocking every delivery until unblocked.
+type blockingSink struct {
+	unblock chan struct{}
+
+	mu     sync.Mutex
+	events []string
+	closed bool
+}
+
+func (s *blockingSink) String() string {
+	return "blocking"
+}
+
+func (s *blockingSink) send(_ context.Context, event []byte) error {
+	<-s.unblock
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, string(event))
+
+	return nil
+}
+
+func (s *blockingSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	return nil
+}
+
+func TestQueuedSink(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	blocking := &blockingSink{unblock: make(chan struct{})}
+	sink := newQueuedSink(context.Background(), blocking, 2)
+
+	// The first event is delivered and blocks, the next two are queued and
+	// the last one is dropped, without blocking the sender.
+	g.Expect(sink.send(context.Background(), []byte("1"))).To(Succeed())
+	g.Eventually(func() int { return len(sink.events) }).Should(BeZero())
+
+	for _, event := range []string{"2", "3", "4"} {
+		g.Expect(sink.send(context.Background(), []byte(event))).To(Succeed())
+	}
+
+	g.Expect(sink.dropped.Load()).To(BeEquivalentTo(1))
+
+	close(blocking.unblock)
+	g.Expect(sink.close()).To(Succeed())
+	g.Expect(blocking.events).To(Equal([]string{"1", "2", "3"}))
+	g.Expect(blocking.closed).To(BeTrue())
+}
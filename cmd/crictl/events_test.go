@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func testEvent(eventType runtimeapi.ContainerEventType) *runtimeapi.ContainerEventResponse {
+	return &runtimeapi.ContainerEventResponse{
+		ContainerId:        "4dfb5f2d0c1e8a9b",
+		ContainerEventType: eventType,
+		CreatedAt:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).UnixNano(),
+		PodSandboxStatus: &runtimeapi.PodSandboxStatus{
+			Id:       "9a8b7c6d5e4f",
+			Metadata: &runtimeapi.PodSandboxMetadata{Name: "nginx-7d9c", Namespace: "default"},
+			Labels:   map[string]string{"app": "nginx"},
+		},
+		ContainersStatuses: []*runtimeapi.ContainerStatus{{
+			Id:       "4dfb5f2d0c1e8a9b",
+			Metadata: &runtimeapi.ContainerMetadata{Name: "web"},
+			Labels:   map[string]string{"tier": "frontend"},
+		}},
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	t.Parallel()
+
+	started := testEvent(runtimeapi.ContainerEventType_CONTAINER_STARTED_EVENT)
+
+	testCases := []struct {
+		desc     string
+		filter   eventFilter
+		expected bool
+	}{
+		{desc: "no criteria", expected: true},
+		{desc: "container ID prefix", filter: eventFilter{containers: []string{"4dfb"}}, expected: true},
+		{desc: "container name", filter: eventFilter{containers: []string{"other", "web"}}, expected: true},
+		{desc: "other container", filter: eventFilter{containers: []string{"db"}}, expected: false},
+		{desc: "pod ID prefix", filter: eventFilter{pods: []string{"9a8b"}}, expected: true},
+		{desc: "pod name", filter: eventFilter{pods: []string{"nginx-7d9c"}}, expected: true},
+		{desc: "pod namespace and name", filter: eventFilter{pods: []string{"default/nginx-7d9c"}}, expected: true},
+		{desc: "pod in other namespace", filter: eventFilter{pods: []string{"kube-system/nginx-7d9c"}}, expected: false},
+		{
+			desc:     "type",
+			filter:   eventFilter{types: []runtimeapi.ContainerEventType{runtimeapi.ContainerEventType_CONTAINER_STARTED_EVENT}},
+			expected: true,
+		},
+		{
+			desc:     "other type",
+			filter:   eventFilter{types: []runtimeapi.ContainerEventType{runtimeapi.ContainerEventType_CONTAINER_STOPPED_EVENT}},
+			expected: false,
+		},
+		{desc: "container and pod labels", filter: eventFilter{labels: map[string]string{"app": "nginx", "tier": "frontend"}}, expected: true},
+		{desc: "other label value", filter: eventFilter{labels: map[string]string{"app": "redis"}}, expected: false},
+		{
+			desc:     "one criterion does not match",
+			filter:   eventFilter{containers: []string{"web"}, pods: []string{"other"}},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+			g.Expect(tc.filter.matches(started)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestEventTable(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	out := &bytes.Buffer{}
+	table := &eventTable{out: out}
+
+	g.Expect(table.print(testEvent(runtimeapi.ContainerEventType_CONTAINER_CREATED_EVENT))).To(Succeed())
+	g.Expect(table.print(testEvent(runtimeapi.ContainerEventType_CONTAINER_DELETED_EVENT))).To(Succeed())
+
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Local().Format("2006-01-02T15:04:05.000Z07:00")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	g.Expect(lines).To(HaveLen(3))
+	g.Expect(string(lines[0])).To(MatchRegexp(`^TIME\s+TYPE\s+CONTAINER\s+NAME\s+POD\s+NAMESPACE$`))
+	g.Expect(string(lines[1])).To(MatchRegexp(`^` + timestamp + `\s+created\s+4dfb5f2d0c1e8\s+web\s+nginx-7d9c\s+default$`))
+	g.Expect(string(lines[2])).To(MatchRegexp(`^` + timestamp + `\s+deleted\s+`))
+	// The columns are aligned.
+	g.Expect(bytes.Index(lines[1], []byte("web"))).To(Equal(bytes.Index(lines[0], []byte("NAME"))))
+}
+
+// fakeEventsRuntimeSvc fails the event streams with the next of its errors.
+// The last stream sends an event and blocks until the context is done.
+type fakeEventsRuntimeSvc struct {
+	internalapi.RuntimeService
+
+	errs        []error
+	connections int
+}
+
+func (f *fakeEventsRuntimeSvc) GetContainerEvents(ctx context.Context, ch chan *runtimeapi.ContainerEventResponse, established func(runtimeapi.RuntimeService_GetContainerEventsClient)) error {
+	if len(f.errs) > 0 && status.Code(f.errs[0]) == codes.Unavailable {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+
+		return err
+	}
+
+	f.connections++
+
+	if established != nil {
+		established(nil)
+	}
+
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+
+		return err
+	}
+
+	ch <- testEvent(runtimeapi.ContainerEventType_CONTAINER_STARTED_EVENT)
+
+	<-ctx.Done()
+
+	return ctx.Err()
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	t.Parallel()
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	policy := &retryPolicy{delay: time.Millisecond, maxDelay: time.Millisecond}
+
+	t.Run("reconnects after the stream is lost", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		client := &fakeEventsRuntimeSvc{errs: []error{io.EOF, unavailable, unavailable}}
+		ch := make(chan *runtimeapi.ContainerEventResponse)
+		errCh := make(chan error, 1)
+
+		go func() { errCh <- subscribeEvents(ctx, client, ch, policy) }()
+
+		g.Eventually(ch).Should(Receive())
+		cancel()
+		g.Eventually(errCh).Should(Receive(MatchError(context.Canceled)))
+		g.Expect(client.connections).To(Equal(2))
+	})
+
+	t.Run("returns at the end of the stream without reconnect", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		client := &fakeEventsRuntimeSvc{errs: []error{io.EOF}}
+
+		g.Expect(subscribeEvents(context.Background(), client, nil, nil)).To(Succeed())
+	})
+
+	t.Run("returns permanent errors", func(t *testing.T) {
+		t.Parallel()
+
+		g := NewWithT(t)
+		client := &fakeEventsRuntimeSvc{errs: []error{status.Error(codes.Unimplemented, "unknown method")}}
+
+		err := subscribeEvents(context.Background(), client, nil, policy)
+		g.Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+	})
+}
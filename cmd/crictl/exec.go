@@ -48,9 +48,12 @@ const (
 var runtimeExecCommand = &cli.Command{
 	Name:      "exec",
 	Usage:     "Run a command in a running container",
-	ArgsUsage: "[CONTAINER-ID] COMMAND [ARG...]",
+	ArgsUsage: "[CONTAINER-ID|NAMESPACE/POD[/CONTAINER]] COMMAND [ARG...]",
 	Description: `The CONTAINER-ID is only required if none of the following filter flags are set:
---image, --label, --last, --latest, --name, --pod, --state`,
+--image, --label, --last, --latest, --name, --pod, --state
+
+Instead of a CONTAINER-ID, a unique ID prefix or a NAMESPACE/POD/CONTAINER
+reference can be used. NAMESPACE/POD selects the only container of the pod.`,
 	UseShortOptionHandling: true,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -214,6 +217,11 @@ var runtimeExecCommand = &cli.Command{
 			}
 		} else if c.NArg() < 2 {
 			return cli.ShowSubcommandHelp(c)
+		} else {
+			ids, err = resolveContainerIDs(c.Context, runtimeClient, ids)
+			if err != nil {
+				return err
+			}
 		}
 
 		opts := execOptions{
@@ -290,10 +298,10 @@ const (
 
 func tlsConfigFromFlags(ctx *cli.Context) (*rest.TLSClientConfig, error) {
 	cfg := &rest.TLSClientConfig{
-		ServerName: ctx.String(flagTLSSNI),
-		CAFile:     ctx.String(flagTLSCA),
-		CertFile:   ctx.String(flagTLSCert),
-		KeyFile:    ctx.String(flagTLSKey),
+		ServerName: tlsFlagOrConfig(ctx, flagTLSSNI),
+		CAFile:     tlsFlagOrConfig(ctx, flagTLSCA),
+		CertFile:   tlsFlagOrConfig(ctx, flagTLSCert),
+		KeyFile:    tlsFlagOrConfig(ctx, flagTLSKey),
 	}
 	if cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" {
 		return &rest.TLSClientConfig{Insecure: true}, nil
@@ -309,6 +317,27 @@ func tlsConfigFromFlags(ctx *cli.Context) (*rest.TLSClientConfig, error) {
 	return cfg, nil
 }
 
+// tlsFlagOrConfig returns the value of a TLS flag, falling back to the
+// configured value if the flag is not set.
+func tlsFlagOrConfig(ctx *cli.Context, flag string) string {
+	cfg := configFromContext(ctx)
+	if ctx.IsSet(flag) || cfg == nil {
+		return ctx.String(flag)
+	}
+
+	value := map[string]string{
+		flagTLSSNI:  cfg.TLSSNI,
+		flagTLSCA:   cfg.TLSCA,
+		flagTLSCert: cfg.TLSCert,
+		flagTLSKey:  cfg.TLSKey,
+	}[flag]
+	if value == "" {
+		return ctx.String(flag)
+	}
+
+	return value
+}
+
 // ExecSync sends an ExecSyncRequest to the server, and parses
 // the returned ExecSyncResponse. The function returns the corresponding exit
 // code beside an general error.
@@ -18,9 +18,11 @@ package main
 
 import (
 	"context"
+	"flag"
 	"testing"
 	"time"
 
+	"github.com/urfave/cli/v2"
 	internalapi "k8s.io/cri-api/pkg/apis"
 )
 
@@ -59,3 +61,39 @@ type fakeRuntimeSvc struct {
 type fakeImageSvc struct {
 	internalapi.ImageManagerService
 }
+
+func TestOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc     string
+		args     []string
+		output   string
+		expected string
+	}{
+		{desc: "flag default", expected: outputTypeTable},
+		{desc: "config default", output: outputTypeJSON, expected: outputTypeJSON},
+		{desc: "unsupported config default", output: "go-template", expected: outputTypeTable},
+		{desc: "flag overrides config", args: []string{"--output", "yaml"}, output: outputTypeJSON, expected: outputTypeYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("output", outputTypeTable, "")
+
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			app := &cli.App{Metadata: map[string]any{configKey: &CrictlConfig{Output: tc.output}}}
+			c := cli.NewContext(app, fs, nil)
+
+			if got := outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable); got != tc.expected {
+				t.Errorf("outputFormat() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -199,7 +199,7 @@ var listImageCommand = &cli.Command{
 			return fmt.Errorf("listing images: %w", err)
 		}
 
-		switch c.String("output") {
+		switch outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable) {
 		case outputTypeJSON:
 			return outputProtobufObjAsJSON(r)
 		case outputTypeYAML:
@@ -336,7 +336,7 @@ var imageStatusCommand = &cli.Command{
 
 		verbose := !(c.Bool("quiet"))
 
-		output := c.String("output")
+		output := outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable)
 		if output == "" { // default to json output
 			output = outputTypeJSON
 		}
@@ -422,7 +422,7 @@ This behavior differs from 'docker rmi', 'nerdctl rmi', and 'ctr image rm', whic
 the specified tag. To remove only a specific tag, use the container runtime's native CLI tool
 (e.g., 'nerdctl', 'ctr', or 'podman').`,
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:    "all",
 			Aliases: []string{"a"},
@@ -433,7 +433,9 @@ the specified tag. To remove only a specific tag, use the container runtime's na
 			Aliases: []string{"q"},
 			Usage:   "Remove all unused images",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(cliCtx *cli.Context) error {
 		cfg := configFromContext(cliCtx)
 
@@ -560,6 +562,10 @@ the specified tag. To remove only a specific tag, use the container runtime's na
 					return nil
 				}
 
+				if dryRunning(imageClient) {
+					return nil
+				}
+
 				if len(status.GetImage().GetRepoTags()) == 0 {
 					// RepoTags is nil when pulling image by repoDigest,
 					// so print deleted using that instead.
@@ -603,7 +609,7 @@ var imageFsInfoCommand = &cli.Command{
 			return err
 		}
 
-		output := c.String("output")
+		output := outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable)
 		if output == "" { // default to json output
 			output = outputTypeJSON
 		}
@@ -98,5 +98,5 @@ func Info(cliContext *cli.Context, client internalapi.RuntimeService) error {
 
 	data := []statusData{{json: statusJSON, runtimeHandlers: string(handlers), features: string(features), info: r.GetInfo()}}
 
-	return outputStatusData(data, cliContext.String("output"), cliContext.String("template"))
+	return outputStatusData(data, outputFormat(cliContext, outputTypeJSON, outputTypeYAML), cliContext.String("template"))
 }
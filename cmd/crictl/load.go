@@ -0,0 +1,174 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/registry"
+)
+
+var loadImageCommand = &cli.Command{
+	Name:  "load",
+	Usage: "Load images from docker save archives or OCI image layouts",
+	Description: "The images are served by a temporary registry on 127.0.0.1 and pulled by the runtime, " +
+		"so they are named like 127.0.0.1:PORT/library/busybox:latest. " +
+		"The runtime has to pull from 127.0.0.1 over plain HTTP.",
+	UseShortOptionHandling: true,
+	ArgsUsage:              "FILE [FILE...]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:        "port",
+			Usage:       "Port of the temporary registry on 127.0.0.1, which is part of the names of the loaded images",
+			DefaultText: "a random port",
+		},
+		&cli.StringFlag{
+			Name:      "pod-config",
+			Usage:     "Use `pod-config.[json|yaml]` to override the pull context",
+			TakesFile: true,
+		},
+		&cli.DurationFlag{
+			Name:    "pull-timeout",
+			Aliases: []string{"pt"},
+			Usage:   "Maximum time to be used for pulling an image, disabled if set to 0s",
+			EnvVars: []string{"CRICTL_PULL_TIMEOUT"},
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Usage:   "Only show the image IDs",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() == 0 {
+			return cli.ShowSubcommandHelp(c)
+		}
+
+		imageClient, err := configFromContext(c).GetImageService(c.Context)
+		if err != nil {
+			return err
+		}
+
+		var sandbox *pb.PodSandboxConfig
+		if c.IsSet("pod-config") {
+			sandbox, err = loadPodSandboxConfig(c.String("pod-config"))
+			if err != nil {
+				return fmt.Errorf("load podSandboxConfig: %w", err)
+			}
+		}
+
+		images, err := loadImages(c.Context, imageClient, c.Args().Slice(), c.Int("port"), sandbox, c.Duration("pull-timeout"))
+
+		for _, image := range images {
+			if c.Bool("quiet") {
+				fmt.Println(image.id)
+
+				continue
+			}
+
+			fmt.Printf("Loaded image %s\n", image.name)
+			fmt.Printf("  ID:   %s\n", image.id)
+			fmt.Printf("  Tags: %s\n", strings.Join(image.tags, ", "))
+		}
+
+		return err
+	},
+}
+
+// loadedImage is an image loaded into the runtime.
+type loadedImage struct {
+	name string
+	id   string
+	tags []string
+}
+
+// loadImages serves the images of the archives from a temporary registry on
+// 127.0.0.1 and pulls them by the image service. It returns the images
+// loaded before an error as well.
+func loadImages(ctx context.Context, client internalapi.ImageManagerService, files []string, port int, sandbox *pb.PodSandboxConfig, timeout time.Duration) ([]loadedImage, error) {
+	r := registry.New()
+
+	var names []string
+
+	for _, file := range files {
+		loaded, err := r.Load(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range loaded {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return pullRegistryImages(ctx, client, r, names, port, sandbox, timeout)
+}
+
+// pullRegistryImages serves the images of the registry on 127.0.0.1 and pulls
+// the named ones by the image service. It returns the images loaded before
+// an error as well.
+func pullRegistryImages(ctx context.Context, client internalapi.ImageManagerService, r *registry.Registry, names []string, port int, sandbox *pb.PodSandboxConfig, timeout time.Duration) ([]loadedImage, error) {
+	if err := r.Start(net.JoinHostPort("127.0.0.1", strconv.Itoa(port))); err != nil {
+		return nil, fmt.Errorf("start registry: %w", err)
+	}
+
+	defer func() {
+		if err := r.Close(); err != nil {
+			logrus.Warnf("Unable to stop the registry: %v", err)
+		}
+	}()
+
+	logrus.Debugf("Serving images %v from registry %s", names, r.Address())
+
+	images := make([]loadedImage, 0, len(names))
+
+	for _, name := range names {
+		image := r.Address() + "/" + name
+
+		resp, err := PullImageWithSandbox(ctx, client, image, nil, sandbox, nil, timeout)
+		if err != nil {
+			return images, fmt.Errorf("pulling image %s: %w", image, err)
+		}
+
+		loaded := loadedImage{name: image, id: resp.GetImageRef()}
+
+		status, err := ImageStatus(ctx, client, image, false)
+		if err != nil {
+			logrus.Warnf("Unable to get the status of image %s: %v", image, err)
+		} else if status.GetImage() != nil {
+			loaded.id = status.GetImage().GetId()
+			loaded.tags = status.GetImage().GetRepoTags()
+		}
+
+		images = append(images, loaded)
+	}
+
+	return images, nil
+}
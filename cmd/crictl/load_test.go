@@ -0,0 +1,118 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/registry"
+)
+
+// fakeLoadImageSvc pulls the manifests of the images from the registry.
+type fakeLoadImageSvc struct {
+	internalapi.ImageManagerService
+
+	mu     sync.Mutex
+	images map[string]string
+}
+
+func (f *fakeLoadImageSvc) PullImage(ctx context.Context, image *runtimeapi.ImageSpec, _ *runtimeapi.AuthConfig, _ *runtimeapi.PodSandboxConfig) (string, error) {
+	host, name, _ := strings.Cut(image.GetImage(), "/")
+	repository, tag, _ := strings.Cut(name, ":")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/v2/%s/manifests/%s", host, repository, tag), http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.images[image.GetImage()] = resp.Header.Get("Docker-Content-Digest")
+
+	return f.images[image.GetImage()], nil
+}
+
+func (f *fakeLoadImageSvc) ImageStatus(_ context.Context, image *runtimeapi.ImageSpec, _ bool) (*runtimeapi.ImageStatusResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id, ok := f.images[image.GetImage()]
+	if !ok {
+		return &runtimeapi.ImageStatusResponse{}, nil
+	}
+
+	return &runtimeapi.ImageStatusResponse{Image: &runtimeapi.Image{Id: id, RepoTags: []string{image.GetImage()}}}, nil
+}
+
+func TestPullRegistryImages(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	r := registry.New()
+
+	for _, name := range []string{"busybox:latest", "example.com/app:v1"} {
+		_, err := r.AddImage(name, map[string][]byte{"name": []byte(name)})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	client := &fakeLoadImageSvc{images: map[string]string{}}
+
+	images, err := pullRegistryImages(t.Context(), client, r, r.Images(), 0, nil, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(images).To(HaveLen(2))
+
+	for i, name := range []string{"app:v1", "library/busybox:latest"} {
+		g.Expect(images[i].name).To(HavePrefix("127.0.0.1:"))
+		g.Expect(images[i].name).To(HaveSuffix("/" + name))
+		g.Expect(images[i].id).To(HavePrefix("sha256:"))
+		g.Expect(images[i].tags).To(Equal([]string{images[i].name}))
+	}
+}
+
+func TestLoadImagesErrors(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	client := &fakeLoadImageSvc{images: map[string]string{}}
+
+	_, err := loadImages(t.Context(), client, []string{filepath.Join(t.TempDir(), "missing.tar")}, 0, nil, 0)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(client.images).To(BeEmpty())
+}
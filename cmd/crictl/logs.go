@@ -64,7 +64,7 @@ func NewLogOptions(follow, timestamps bool, since time.Time, tailLines, limitByt
 var logsCommand = &cli.Command{
 	Name:                   "logs",
 	Usage:                  "Fetch the logs of a container",
-	ArgsUsage:              "CONTAINER-ID",
+	ArgsUsage:              "CONTAINER-ID|NAMESPACE/POD[/CONTAINER]",
 	UseShortOptionHandling: true,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
@@ -122,6 +122,11 @@ var logsCommand = &cli.Command{
 			return err
 		}
 
+		containerID, err = resolveContainerID(c.Context, runtimeService, containerID)
+		if err != nil {
+			return err
+		}
+
 		if c.Bool("reopen") {
 			if _, err := InterruptableRPC(c.Context, func(ctx context.Context) (any, error) {
 				return nil, runtimeService.ReopenContainerLog(ctx, containerID)
@@ -18,7 +18,6 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -59,6 +58,8 @@ func getTimeout(timeDuration time.Duration) time.Duration {
 
 func main() {
 	if err := run(); err != nil {
+		// Exit with the exit code of the command, like the one of exec.
+		cli.HandleExitCoder(err)
 		logrus.Error(err)
 		os.Exit(1) //nolint:forbidigo // intentional exit in main() after error handling
 	}
@@ -85,6 +86,7 @@ func run() error {
 		runtimePortForwardCommand,
 		listContainersCommand,
 		pullImageCommand,
+		loadImageCommand,
 		runContainerCommand,
 		runPodCommand,
 		removeContainerCommand,
@@ -102,6 +104,7 @@ func run() error {
 		podMetricsCommand,
 		metricDescriptorsCommand,
 		completionCommand,
+		completeCommand,
 		checkpointContainerCommand,
 		runtimeConfigCommand,
 		eventsCommand,
@@ -111,9 +114,9 @@ func run() error {
 	slices.SortFunc(app.Commands, func(a, b *cli.Command) int { return strings.Compare(a.Name, b.Name) })
 
 	runtimeEndpointUsage := fmt.Sprintf("Endpoint of CRI container runtime "+
-		"service (default: uses in order the first successful one of %v). "+
-		"Default is now deprecated and the endpoint should be set instead.",
-		defaultRuntimeEndpoints)
+		"service, or %q to discover it from the kubelet and the default "+
+		"sockets %v (default: %q). Not setting the endpoint is deprecated.",
+		common.EndpointAuto, defaultRuntimeEndpoints, common.EndpointAuto)
 
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{
@@ -143,6 +146,35 @@ func run() error {
 			Usage: "Timeout of connecting to the server in seconds (e.g. 2s, 20s.). " +
 				"0 or less is set to default",
 		},
+		&cli.StringFlag{
+			Name:    "context",
+			EnvVars: []string{"CRICTL_CONTEXT"},
+			Usage:   "Name of the config context to use, overrides the current-context of the config file",
+		},
+		&cli.StringFlag{
+			Name:  flagTLSCA,
+			Usage: "Path to the CA certificate used to verify tls:// endpoints and TLS streaming servers",
+		},
+		&cli.StringFlag{
+			Name:  flagTLSCert,
+			Usage: "Path to the client certificate for tls:// endpoints and TLS streaming",
+		},
+		&cli.StringFlag{
+			Name:  flagTLSKey,
+			Usage: "Path to the client key for tls:// endpoints and TLS streaming",
+		},
+		&cli.StringFlag{
+			Name:  flagTLSSNI,
+			Usage: "Server name used to verify the certificate of tls:// endpoints and TLS streaming servers (default: the endpoint host)",
+		},
+		&cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "Reject all RPCs which modify the runtime, like creating, removing or pulling. Can be set in the config file or by the CRICTL_READ_ONLY environment variable",
+		},
+		&cli.BoolFlag{
+			Name:  "read-only-allow-streaming",
+			Usage: "Allow exec, attach and port-forward in read-only mode",
+		},
 		&cli.BoolFlag{
 			Name:    "debug",
 			Aliases: []string{"D"},
@@ -153,6 +185,14 @@ func run() error {
 			Value: 3,
 			Usage: "Max retries for connecting to an explicitly set endpoint with exponential backoff (0 to disable, negative for infinite)",
 		},
+		&cli.IntFlag{
+			Name:  "rpc-retries",
+			Usage: "Max retries for idempotent RPCs failing with a transient error with exponential backoff (0 to disable)",
+		},
+		&cli.BoolFlag{
+			Name:  "timing",
+			Usage: "Print the duration, response size and status code of all CRI RPCs to stderr at exit",
+		},
 		&cli.BoolFlag{
 			Name:  "enable-tracing",
 			Usage: "Enable OpenTelemetry tracing.",
@@ -163,9 +203,33 @@ func run() error {
 			Value: -1,
 		},
 		&cli.StringFlag{
-			Name:  "tracing-endpoint",
-			Usage: "Address to which the gRPC tracing collector will send spans to.",
-			Value: "127.0.0.1:4317",
+			Name: "tracing-endpoint",
+			Usage: "Address to which the tracing collector will send spans to, as host:port or http(s):// URL " +
+				"(default: OTEL_EXPORTER_OTLP_ENDPOINT, or 127.0.0.1:4317 for grpc and 127.0.0.1:4318 for http/protobuf).",
+		},
+		&cli.StringFlag{
+			Name:  "tracing-protocol",
+			Usage: "OTLP protocol of the tracing collector, one of: grpc|http/protobuf (default: OTEL_EXPORTER_OTLP_PROTOCOL or grpc).",
+		},
+		&cli.StringFlag{
+			Name:  "tracing-ca",
+			Usage: "Path to the CA certificate to verify the tracing collector, enables TLS for host:port endpoints.",
+		},
+		&cli.StringFlag{
+			Name:  "tracing-cert",
+			Usage: "Path to the client certificate for the tracing collector, enables TLS for host:port endpoints.",
+		},
+		&cli.StringFlag{
+			Name:  "tracing-key",
+			Usage: "Path to the client key for the tracing collector.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tracing-header",
+			Usage: "Header in the form key=value sent to the tracing collector, can be specified multiple times.",
+		},
+		&cli.StringFlag{
+			Name:  "tracing-file",
+			Usage: "Append the spans as OTLP JSON lines to the file instead of sending them to a collector, \"-\" for stdout.",
 		},
 		&cli.StringFlag{
 			Name:  "profile-cpu",
@@ -189,8 +253,6 @@ func run() error {
 	app.Before = func(context *cli.Context) (err error) {
 		var config *common.ServerConfiguration
 
-		var exePath string
-
 		cpuProfilePath := context.String("profile-cpu")
 		if cpuProfilePath != "" {
 			cpuProfilePath, err = filepath.Abs(cpuProfilePath)
@@ -210,42 +272,56 @@ func run() error {
 			}
 		}
 
-		if exePath, err = os.Executable(); err != nil {
-			return fmt.Errorf("get executable path: %w", err)
+		configFiles, err := configFilesFromContext(context)
+		if err != nil {
+			return err
 		}
 
-		if config, err = common.GetServerConfigFromFile(context.String("config"), exePath); err != nil {
-			if context.IsSet("config") {
-				// crictl config can create a missing file; let it through.
-				isConfigCmd := context.Args().First() == "config"
-				if !isConfigCmd || !errors.Is(err, os.ErrNotExist) {
-					return fmt.Errorf("get server config: %w", err)
-				}
+		// crictl config can create a missing file or fix an invalid one; let
+		// it through.
+		isConfigCmd := context.Args().First() == "config"
+
+		if context.IsSet("config") && !isConfigCmd {
+			if _, err := os.Stat(context.String("config")); err != nil {
+				return fmt.Errorf("get server config: load config file: %w", err)
 			}
 		}
 
+		config, err = common.LoadServerConfig(configFiles, context.String("context"))
+		if err != nil {
+			if !isConfigCmd {
+				return fmt.Errorf("get server config: %w", err)
+			}
+
+			logrus.Debugf("Ignoring invalid config for config command: %v", err)
+		}
+
 		cfg := newCrictlConfig(context, config)
 
 		// Configure tracing if enabled
 		if context.IsSet("enable-tracing") {
-			cfg.TracerProvider, err = tracing.Init(
-				context.Context,
-				context.String("tracing-endpoint"),
-				context.Int("tracing-sampling-rate-per-million"),
-			)
+			var tracingOpts *tracing.Options
+
+			tracingOpts, err = tracingOptions(context)
+			if err != nil {
+				return err
+			}
+
+			cfg.TracerProvider, err = tracing.InitWithOptions(context.Context, tracingOpts)
 			if err != nil {
 				return fmt.Errorf("init tracing: %w", err)
 			}
 
 			// Start a root span named after the invoked command so that all
 			// command operations are recorded as children of it. The span is
-			// ended in run() once app.Run returns.
+			// ended in run() once app.Run returns. If crictl is invoked by a
+			// traced script, the root span is a child of its TRACEPARENT.
 			spanName := "crictl"
 			if context.Args().Present() {
 				spanName = context.Args().First()
 			}
 
-			ctx, span := cfg.TracerProvider.Tracer("sigs.k8s.io/cri-tools/cmd/crictl").Start(context.Context, spanName)
+			ctx, span := cfg.TracerProvider.Tracer("sigs.k8s.io/cri-tools/cmd/crictl").Start(tracing.ContextWithEnvParent(context.Context), spanName)
 			cfg.RootSpan = span
 			cfg.RootSpan.SetAttributes(attribute.String("command", spanName))
 
@@ -284,6 +360,10 @@ func run() error {
 		return nil
 	}
 
+	// Return the errors with exit codes from app.Run, so that the audit log
+	// and the timing report are written before exiting in main.
+	app.ExitErrHandler = deferExitCoder
+
 	// sort all flags
 	for _, cmd := range app.Commands {
 		sort.Sort(cli.FlagsByName(cmd.Flags))
@@ -291,7 +371,7 @@ func run() error {
 
 	sort.Sort(cli.FlagsByName(app.Flags))
 
-	err := app.Run(os.Args)
+	err := app.Run(completionArgs(app, os.Args))
 
 	// Record the command result on the root span and ensure that all spans are
 	// processed before exiting.
@@ -316,8 +396,53 @@ func run() error {
 
 				cancel()
 			}
+
+			if auditErr := cfg.writeAuditLog(os.Args, err); auditErr != nil {
+				logrus.Errorf("Unable to write audit log: %v", auditErr)
+			}
+
+			if cfg.timing != nil {
+				if timingErr := cfg.timing.print(); timingErr != nil {
+					logrus.Errorf("Unable to print timing report: %v", timingErr)
+				}
+			}
+
+			cfg.closeEndpointProxies()
 		}
 	}
 
 	return err
 }
+
+// deferExitCoder is the exit error handler of the app, which does not exit
+// like the default handler does.
+func deferExitCoder(*cli.Context, error) {}
+
+// tracingOptions returns the options of the span exporter set by the global
+// tracing flags.
+func tracingOptions(c *cli.Context) (*tracing.Options, error) {
+	opts := &tracing.Options{
+		Protocol:     c.String("tracing-protocol"),
+		Endpoint:     c.String("tracing-endpoint"),
+		CAFile:       c.String("tracing-ca"),
+		CertFile:     c.String("tracing-cert"),
+		KeyFile:      c.String("tracing-key"),
+		File:         c.String("tracing-file"),
+		SamplingRate: c.Int("tracing-sampling-rate-per-million"),
+	}
+
+	for _, header := range c.StringSlice("tracing-header") {
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --tracing-header %q, expected key=value", header)
+		}
+
+		if opts.Headers == nil {
+			opts.Headers = map[string]string{}
+		}
+
+		opts.Headers[key] = value
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+)
+
+//nolint:paralleltest // replaces the global exiter of cli
+func TestDeferExitCoder(t *testing.T) {
+	g := NewWithT(t)
+
+	exiter := cli.OsExiter
+
+	t.Cleanup(func() { cli.OsExiter = exiter })
+
+	cli.OsExiter = func(code int) {
+		t.Fatalf("unexpected exit with code %d in app.Run", code)
+	}
+
+	afterCalled := false
+	app := &cli.App{
+		ExitErrHandler: deferExitCoder,
+		Commands: []*cli.Command{{
+			Name:   "exec",
+			Action: func(*cli.Context) error { return cli.Exit("non-zero exit code", 3) },
+		}},
+		After: func(*cli.Context) error {
+			afterCalled = true
+
+			return nil
+		},
+	}
+
+	err := app.Run([]string{"crictl", "exec"})
+	g.Expect(afterCalled).To(BeTrue())
+
+	var exitErr cli.ExitCoder
+	g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+	g.Expect(exitErr.ExitCode()).To(Equal(3))
+}
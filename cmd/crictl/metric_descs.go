@@ -53,7 +53,7 @@ var metricDescriptorsCommand = &cli.Command{
 		}
 
 		opts := metricDescriptorsOptions{
-			output: c.String("output"),
+			output: outputFormat(c, outputTypeJSON, outputTypeYAML),
 		}
 
 		switch opts.output {
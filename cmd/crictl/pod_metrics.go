@@ -61,7 +61,7 @@ var podMetricsCommand = &cli.Command{
 		}
 
 		opts := podMetricsOptions{
-			output: c.String("output"),
+			output: outputFormat(c, outputTypeJSON, outputTypeYAML),
 			watch:  c.Bool("watch"),
 		}
 
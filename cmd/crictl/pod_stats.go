@@ -97,7 +97,7 @@ var podStatsCommand = &cli.Command{
 		opts := podStatsOptions{
 			id:     id,
 			sample: time.Duration(c.Int("seconds")) * time.Second,
-			output: c.String("output"),
+			output: outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			watch:  c.Bool("watch"),
 		}
 
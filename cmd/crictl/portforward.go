@@ -34,7 +34,7 @@ import (
 var runtimePortForwardCommand = &cli.Command{
 	Name:      "port-forward",
 	Usage:     "Forward local port to a pod",
-	ArgsUsage: "POD-ID [LOCAL_PORT:]REMOTE_PORT",
+	ArgsUsage: "POD-ID|NAMESPACE/POD [LOCAL_PORT:]REMOTE_PORT",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:    transportFlag,
@@ -71,8 +71,13 @@ var runtimePortForwardCommand = &cli.Command{
 			return err
 		}
 
+		id, err := resolvePodSandboxID(c.Context, runtimeClient, c.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
 		opts := portforwardOptions{
-			id:        c.Args().Get(0),
+			id:        id,
 			ports:     c.Args().Tail(),
 			transport: c.String(transportFlag),
 		}
@@ -0,0 +1,136 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// errReadOnly is returned for all mutating RPCs in read-only mode.
+var errReadOnly = errors.New("read-only mode is enabled")
+
+func readOnlyError(rpc string) error {
+	return fmt.Errorf("%w: %s is not allowed, use --read-only=false to override", errReadOnly, rpc)
+}
+
+// readOnlyRuntimeService rejects all RPCs of the runtime service which modify
+// the runtime. The streaming RPCs are rejected as well, unless allowed.
+type readOnlyRuntimeService struct {
+	internalapi.RuntimeService
+
+	allowStreaming bool
+}
+
+func (*readOnlyRuntimeService) CreateContainer(context.Context, string, *runtimeapi.ContainerConfig, *runtimeapi.PodSandboxConfig) (string, error) {
+	return "", readOnlyError("CreateContainer")
+}
+
+func (*readOnlyRuntimeService) StartContainer(context.Context, string) error {
+	return readOnlyError("StartContainer")
+}
+
+func (*readOnlyRuntimeService) StopContainer(context.Context, string, int64) error {
+	return readOnlyError("StopContainer")
+}
+
+func (*readOnlyRuntimeService) RemoveContainer(context.Context, string) error {
+	return readOnlyError("RemoveContainer")
+}
+
+func (*readOnlyRuntimeService) UpdateContainerResources(context.Context, string, *runtimeapi.ContainerResources) error {
+	return readOnlyError("UpdateContainerResources")
+}
+
+func (*readOnlyRuntimeService) ReopenContainerLog(context.Context, string) error {
+	return readOnlyError("ReopenContainerLog")
+}
+
+func (*readOnlyRuntimeService) CheckpointContainer(context.Context, *runtimeapi.CheckpointContainerRequest) error {
+	return readOnlyError("CheckpointContainer")
+}
+
+func (*readOnlyRuntimeService) RunPodSandbox(context.Context, *runtimeapi.PodSandboxConfig, string) (string, error) {
+	return "", readOnlyError("RunPodSandbox")
+}
+
+func (*readOnlyRuntimeService) StopPodSandbox(context.Context, string) error {
+	return readOnlyError("StopPodSandbox")
+}
+
+func (*readOnlyRuntimeService) RemovePodSandbox(context.Context, string) error {
+	return readOnlyError("RemovePodSandbox")
+}
+
+func (*readOnlyRuntimeService) UpdatePodSandboxResources(context.Context, *runtimeapi.UpdatePodSandboxResourcesRequest) (*runtimeapi.UpdatePodSandboxResourcesResponse, error) {
+	return nil, readOnlyError("UpdatePodSandboxResources")
+}
+
+func (*readOnlyRuntimeService) UpdateRuntimeConfig(context.Context, *runtimeapi.RuntimeConfig) error {
+	return readOnlyError("UpdateRuntimeConfig")
+}
+
+func (r *readOnlyRuntimeService) ExecSync(ctx context.Context, containerID string, cmd []string, timeout time.Duration) (stdout, stderr []byte, err error) {
+	if !r.allowStreaming {
+		return nil, nil, readOnlyError("ExecSync")
+	}
+
+	return r.RuntimeService.ExecSync(ctx, containerID, cmd, timeout)
+}
+
+func (r *readOnlyRuntimeService) Exec(ctx context.Context, req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	if !r.allowStreaming {
+		return nil, readOnlyError("Exec")
+	}
+
+	return r.RuntimeService.Exec(ctx, req)
+}
+
+func (r *readOnlyRuntimeService) Attach(ctx context.Context, req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	if !r.allowStreaming {
+		return nil, readOnlyError("Attach")
+	}
+
+	return r.RuntimeService.Attach(ctx, req)
+}
+
+func (r *readOnlyRuntimeService) PortForward(ctx context.Context, req *runtimeapi.PortForwardRequest) (*runtimeapi.PortForwardResponse, error) {
+	if !r.allowStreaming {
+		return nil, readOnlyError("PortForward")
+	}
+
+	return r.RuntimeService.PortForward(ctx, req)
+}
+
+// readOnlyImageService rejects all RPCs of the image service which modify
+// the images.
+type readOnlyImageService struct {
+	internalapi.ImageManagerService
+}
+
+func (*readOnlyImageService) PullImage(context.Context, *runtimeapi.ImageSpec, *runtimeapi.AuthConfig, *runtimeapi.PodSandboxConfig) (string, error) {
+	return "", readOnlyError("PullImage")
+}
+
+func (*readOnlyImageService) RemoveImage(context.Context, *runtimeapi.ImageSpec) error {
+	return readOnlyError("RemoveImage")
+}
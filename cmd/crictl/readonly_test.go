@@ -0,0 +1,147 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeReadRuntimeSvc implements the RPCs used to verify that read-only mode
+// passes them through.
+type fakeReadRuntimeSvc struct {
+	internalapi.RuntimeService
+}
+
+func (fakeReadRuntimeSvc) ListPodSandbox(context.Context, *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	return []*runtimeapi.PodSandbox{{Id: "pod"}}, nil
+}
+
+func (fakeReadRuntimeSvc) Exec(context.Context, *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	return &runtimeapi.ExecResponse{Url: "http://localhost"}, nil
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		desc           string
+		allowStreaming bool
+		call           func(internalapi.RuntimeService, internalapi.ImageManagerService) error
+		expectReadOnly bool
+	}{
+		{
+			desc: "RunPodSandbox is rejected",
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				_, err := rs.RunPodSandbox(ctx, &runtimeapi.PodSandboxConfig{}, "")
+
+				return err
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc: "RemoveContainer is rejected",
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				return rs.RemoveContainer(ctx, "container")
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc: "UpdateRuntimeConfig is rejected",
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				return rs.UpdateRuntimeConfig(ctx, &runtimeapi.RuntimeConfig{})
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc: "PullImage is rejected",
+			call: func(_ internalapi.RuntimeService, is internalapi.ImageManagerService) error {
+				_, err := is.PullImage(ctx, &runtimeapi.ImageSpec{Image: "busybox"}, nil, nil)
+
+				return err
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc: "RemoveImage is rejected",
+			call: func(_ internalapi.RuntimeService, is internalapi.ImageManagerService) error {
+				return is.RemoveImage(ctx, &runtimeapi.ImageSpec{Image: "busybox"})
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc: "ListPodSandbox is allowed",
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				_, err := rs.ListPodSandbox(ctx, nil)
+
+				return err
+			},
+		},
+		{
+			desc: "Exec is rejected by default",
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				_, err := rs.Exec(ctx, &runtimeapi.ExecRequest{})
+
+				return err
+			},
+			expectReadOnly: true,
+		},
+		{
+			desc:           "Exec is allowed with streaming",
+			allowStreaming: true,
+			call: func(rs internalapi.RuntimeService, _ internalapi.ImageManagerService) error {
+				_, err := rs.Exec(ctx, &runtimeapi.ExecRequest{})
+
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+
+			cfg := &CrictlConfig{
+				ReadOnly:               true,
+				ReadOnlyAllowStreaming: tc.allowStreaming,
+				runtimeServiceOverride: fakeReadRuntimeSvc{},
+				imageServiceOverride:   fakeImageSvc{},
+			}
+
+			rs, err := cfg.GetRuntimeService(ctx, 0)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			is, err := cfg.GetImageService(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = tc.call(rs, is)
+			if tc.expectReadOnly {
+				g.Expect(err).To(MatchError(errReadOnly))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/kubelet/pkg/types"
+)
+
+const (
+	// fullIDLen is the length of a full, non-truncated container or sandbox ID.
+	fullIDLen = 64
+
+	// maxAmbiguousCandidates limits the number of candidates listed in an
+	// ambiguity error.
+	maxAmbiguousCandidates = 5
+)
+
+// idResolver resolves user provided container and pod references into IDs.
+//
+// A reference is either a full ID, a unique ID prefix, or a Kubernetes style
+// reference in the form of "namespace/pod" (pods and single container pods) or
+// "namespace/pod/container" (containers). The container and sandbox lists are
+// fetched at most once per resolver.
+type idResolver struct {
+	client     internalapi.RuntimeService
+	containers []*pb.Container
+	sandboxes  []*pb.PodSandbox
+}
+
+func newIDResolver(client internalapi.RuntimeService) *idResolver {
+	return &idResolver{client: client}
+}
+
+// resolveContainerID resolves a single container reference into an ID.
+func resolveContainerID(ctx context.Context, client internalapi.RuntimeService, ref string) (string, error) {
+	return newIDResolver(client).containerID(ctx, ref)
+}
+
+// resolveContainerIDs resolves all container references into IDs.
+func resolveContainerIDs(ctx context.Context, client internalapi.RuntimeService, refs []string) ([]string, error) {
+	r := newIDResolver(client)
+	ids := make([]string, 0, len(refs))
+
+	for _, ref := range refs {
+		id, err := r.containerID(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// resolvePodSandboxID resolves a single pod reference into a sandbox ID.
+func resolvePodSandboxID(ctx context.Context, client internalapi.RuntimeService, ref string) (string, error) {
+	return newIDResolver(client).podSandboxID(ctx, ref)
+}
+
+// resolvePodSandboxIDs resolves all pod references into sandbox IDs.
+func resolvePodSandboxIDs(ctx context.Context, client internalapi.RuntimeService, refs []string) ([]string, error) {
+	r := newIDResolver(client)
+	ids := make([]string, 0, len(refs))
+
+	for _, ref := range refs {
+		id, err := r.podSandboxID(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r *idResolver) containerID(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		return "", errIDEmpty
+	}
+
+	if strings.Contains(ref, "/") {
+		return r.containerIDFromK8sRef(ctx, ref)
+	}
+
+	if isFullID(ref) {
+		return ref, nil
+	}
+
+	containers, err := r.listContainers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveIDPrefix(ref, containers, "container")
+}
+
+func (r *idResolver) podSandboxID(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		return "", errIDEmpty
+	}
+
+	if strings.Contains(ref, "/") {
+		return r.podSandboxIDFromK8sRef(ctx, ref)
+	}
+
+	if isFullID(ref) {
+		return ref, nil
+	}
+
+	sandboxes, err := r.listSandboxes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveIDPrefix(ref, sandboxes, "pod")
+}
+
+func (r *idResolver) podSandboxIDFromK8sRef(ctx context.Context, ref string) (string, error) {
+	namespace, name, _, err := parseK8sRef(ref, false)
+	if err != nil {
+		return "", err
+	}
+
+	sandboxes, err := r.sandboxesByName(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	ready := []*pb.PodSandbox{}
+
+	for _, s := range sandboxes {
+		if s.GetState() == pb.PodSandboxState_SANDBOX_READY {
+			ready = append(ready, s)
+		}
+	}
+
+	switch len(ready) {
+	case 0:
+		// Fall back to the most recently created sandbox.
+		return sandboxes[0].GetId(), nil
+	case 1:
+		return ready[0].GetId(), nil
+	default:
+		return "", ambiguousRefError(ref, "pod", ready)
+	}
+}
+
+func (r *idResolver) containerIDFromK8sRef(ctx context.Context, ref string) (string, error) {
+	namespace, name, containerName, err := parseK8sRef(ref, true)
+	if err != nil {
+		return "", err
+	}
+
+	sandboxes, err := r.sandboxesByName(ctx, namespace, name)
+	if err != nil {
+		return "", err
+	}
+
+	sandboxIDs := map[string]bool{}
+	for _, s := range sandboxes {
+		sandboxIDs[s.GetId()] = true
+	}
+
+	containers, err := r.listContainers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []*pb.Container{}
+	names := []string{}
+
+	for _, c := range containers {
+		if !sandboxIDs[c.GetPodSandboxId()] {
+			continue
+		}
+
+		if containerName != "" && c.GetMetadata().GetName() != containerName {
+			continue
+		}
+
+		candidates = append(candidates, c)
+
+		if !slices.Contains(names, c.GetMetadata().GetName()) {
+			names = append(names, c.GetMetadata().GetName())
+		}
+	}
+
+	if len(candidates) == 0 {
+		if containerName != "" {
+			return "", fmt.Errorf("no container %q found in pod %s/%s", containerName, namespace, name)
+		}
+
+		return "", fmt.Errorf("no containers found in pod %s/%s", namespace, name)
+	}
+
+	if len(names) > 1 {
+		slices.Sort(names)
+
+		return "", fmt.Errorf(
+			"reference %q is ambiguous: pod %s/%s has multiple containers (%s), use %s/%s/<container>",
+			ref, namespace, name, strings.Join(names, ", "), namespace, name,
+		)
+	}
+
+	running := []*pb.Container{}
+
+	for _, c := range candidates {
+		if c.GetState() == pb.ContainerState_CONTAINER_RUNNING {
+			running = append(running, c)
+		}
+	}
+
+	switch len(running) {
+	case 0:
+		// Fall back to the most recently created container, which is the
+		// latest restart attempt.
+		return slices.MaxFunc(candidates, func(a, b *pb.Container) int {
+			return cmp.Compare(a.GetCreatedAt(), b.GetCreatedAt())
+		}).GetId(), nil
+	case 1:
+		return running[0].GetId(), nil
+	default:
+		return "", ambiguousRefError(ref, "container", running)
+	}
+}
+
+// sandboxesByName returns all sandboxes matching the namespace and name,
+// sorted by their creation time in descending order.
+func (r *idResolver) sandboxesByName(ctx context.Context, namespace, name string) ([]*pb.PodSandbox, error) {
+	sandboxes, err := r.listSandboxes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*pb.PodSandbox{}
+
+	for _, s := range sandboxes {
+		if s.GetMetadata().GetNamespace() == namespace && s.GetMetadata().GetName() == name {
+			matches = append(matches, s)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no pod %q found in namespace %q", name, namespace)
+	}
+
+	slices.SortFunc(matches, func(a, b *pb.PodSandbox) int {
+		return cmp.Compare(b.GetCreatedAt(), a.GetCreatedAt()) // descending
+	})
+
+	return matches, nil
+}
+
+func (r *idResolver) listContainers(ctx context.Context) ([]*pb.Container, error) {
+	if r.containers != nil {
+		return r.containers, nil
+	}
+
+	containers, err := InterruptableRPC(ctx, func(ctx context.Context) ([]*pb.Container, error) {
+		return r.client.ListContainers(ctx, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list containers to resolve reference: %w", err)
+	}
+
+	r.containers = containers
+
+	return containers, nil
+}
+
+func (r *idResolver) listSandboxes(ctx context.Context) ([]*pb.PodSandbox, error) {
+	if r.sandboxes != nil {
+		return r.sandboxes, nil
+	}
+
+	sandboxes, err := InterruptableRPC(ctx, func(ctx context.Context) ([]*pb.PodSandbox, error) {
+		return r.client.ListPodSandbox(ctx, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list pod sandboxes to resolve reference: %w", err)
+	}
+
+	r.sandboxes = sandboxes
+
+	return sandboxes, nil
+}
+
+// parseK8sRef splits a "namespace/pod[/container]" reference. The container
+// part is only accepted if withContainer is true.
+func parseK8sRef(ref string, withContainer bool) (namespace, name, container string, err error) {
+	parts := strings.Split(ref, "/")
+
+	switch {
+	case len(parts) == 2:
+		namespace, name = parts[0], parts[1]
+	case len(parts) == 3 && withContainer:
+		namespace, name, container = parts[0], parts[1], parts[2]
+		if container == "" {
+			return "", "", "", fmt.Errorf("invalid reference %q: container name is empty", ref)
+		}
+	default:
+		if withContainer {
+			return "", "", "", fmt.Errorf("invalid reference %q: expected NAMESPACE/POD or NAMESPACE/POD/CONTAINER", ref)
+		}
+
+		return "", "", "", fmt.Errorf("invalid reference %q: expected NAMESPACE/POD", ref)
+	}
+
+	if namespace == "" || name == "" {
+		return "", "", "", fmt.Errorf("invalid reference %q: namespace and pod name must not be empty", ref)
+	}
+
+	return namespace, name, container, nil
+}
+
+// resolveIDPrefix returns the ID of the item matching ref exactly or by a
+// unique prefix. If nothing matches, ref is returned unchanged to let the
+// runtime report the error.
+func resolveIDPrefix[T interface{ GetId() string }](ref string, items []T, typeName string) (string, error) {
+	matches := []T{}
+
+	for _, item := range items {
+		if item.GetId() == ref {
+			return ref, nil
+		}
+
+		if strings.HasPrefix(item.GetId(), ref) {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		logrus.Debugf("No %s found for reference %q, using it as ID", typeName, ref)
+
+		return ref, nil
+	case 1:
+		return matches[0].GetId(), nil
+	default:
+		return "", ambiguousRefError(ref, typeName, matches)
+	}
+}
+
+func ambiguousRefError[T interface{ GetId() string }](ref, typeName string, matches []T) error {
+	ids := make([]string, 0, maxAmbiguousCandidates)
+
+	for i, m := range matches {
+		if i == maxAmbiguousCandidates {
+			ids = append(ids, "...")
+
+			break
+		}
+
+		ids = append(ids, getTruncatedID(m.GetId(), ""))
+	}
+
+	return fmt.Errorf("reference %q is ambiguous: matches %d %ss (%s)", ref, len(matches), typeName, strings.Join(ids, ", "))
+}
+
+// isFullID returns true if id looks like a full hex encoded ID.
+func isFullID(id string) bool {
+	if len(id) != fullIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// k8sContainerRef returns the "namespace/pod/container" reference of a
+// container, preferring the sandbox metadata over the Kubernetes labels.
+func k8sContainerRef(c *pb.Container, sandbox *pb.PodSandbox) (namespace, pod, container string) {
+	namespace = getPodNamespaceFromLabels(c.GetLabels())
+	pod = getPodNameFromLabels(c.GetLabels())
+	container = c.GetMetadata().GetName()
+
+	if sandbox != nil {
+		namespace = sandbox.GetMetadata().GetNamespace()
+		pod = sandbox.GetMetadata().GetName()
+	}
+
+	if container == "" {
+		container = getFromLabels(c.GetLabels(), types.KubernetesContainerNameLabel)
+	}
+
+	return namespace, pod, container
+}
@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalapi "k8s.io/cri-api/pkg/apis"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+type fakeListRuntimeSvc struct {
+	internalapi.RuntimeService
+
+	containers []*pb.Container
+	sandboxes  []*pb.PodSandbox
+}
+
+func (f *fakeListRuntimeSvc) ListContainers(context.Context, *pb.ContainerFilter) ([]*pb.Container, error) {
+	return f.containers, nil
+}
+
+func (f *fakeListRuntimeSvc) ListPodSandbox(context.Context, *pb.PodSandboxFilter) ([]*pb.PodSandbox, error) {
+	return f.sandboxes, nil
+}
+
+func newFakeListRuntimeSvc() *fakeListRuntimeSvc {
+	return &fakeListRuntimeSvc{
+		sandboxes: []*pb.PodSandbox{
+			{
+				Id:        "aaa111",
+				Metadata:  &pb.PodSandboxMetadata{Name: "web", Namespace: "default"},
+				State:     pb.PodSandboxState_SANDBOX_NOTREADY,
+				CreatedAt: 1,
+			},
+			{
+				Id:        "aaa222",
+				Metadata:  &pb.PodSandboxMetadata{Name: "web", Namespace: "default"},
+				State:     pb.PodSandboxState_SANDBOX_READY,
+				CreatedAt: 2,
+			},
+			{
+				Id:        "bbb111",
+				Metadata:  &pb.PodSandboxMetadata{Name: "db", Namespace: "kube-system"},
+				State:     pb.PodSandboxState_SANDBOX_READY,
+				CreatedAt: 3,
+			},
+		},
+		containers: []*pb.Container{
+			{
+				Id:           "ccc111",
+				PodSandboxId: "aaa111",
+				Metadata:     &pb.ContainerMetadata{Name: "nginx"},
+				State:        pb.ContainerState_CONTAINER_EXITED,
+				CreatedAt:    1,
+			},
+			{
+				Id:           "ccc222",
+				PodSandboxId: "aaa222",
+				Metadata:     &pb.ContainerMetadata{Name: "nginx", Attempt: 1},
+				State:        pb.ContainerState_CONTAINER_RUNNING,
+				CreatedAt:    2,
+			},
+			{
+				Id:           "ddd111",
+				PodSandboxId: "bbb111",
+				Metadata:     &pb.ContainerMetadata{Name: "postgres"},
+				State:        pb.ContainerState_CONTAINER_RUNNING,
+				CreatedAt:    3,
+			},
+			{
+				Id:           "ddd222",
+				PodSandboxId: "bbb111",
+				Metadata:     &pb.ContainerMetadata{Name: "exporter"},
+				State:        pb.ContainerState_CONTAINER_EXITED,
+				CreatedAt:    4,
+			},
+		},
+	}
+}
+
+func TestResolveContainerID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc        string
+		ref         string
+		expected    string
+		expectedErr string
+	}{
+		{desc: "full ID", ref: "ccc111", expected: "ccc111"},
+		{desc: "unique prefix", ref: "ccc2", expected: "ccc222"},
+		{desc: "ambiguous prefix", ref: "ccc", expectedErr: "is ambiguous"},
+		{desc: "unknown ID is passed through", ref: "zzz", expected: "zzz"},
+		{desc: "namespace/pod/container prefers running", ref: "default/web/nginx", expected: "ccc222"},
+		{desc: "namespace/pod with single container", ref: "default/web", expected: "ccc222"},
+		{desc: "namespace/pod with multiple containers", ref: "kube-system/db", expectedErr: "multiple containers (exporter, postgres)"},
+		{desc: "exited container by name", ref: "kube-system/db/exporter", expected: "ddd222"},
+		{desc: "unknown pod", ref: "default/missing/nginx", expectedErr: `no pod "missing" found in namespace "default"`},
+		{desc: "unknown container", ref: "default/web/missing", expectedErr: `no container "missing" found in pod default/web`},
+		{desc: "invalid reference", ref: "a/b/c/d", expectedErr: "invalid reference"},
+		{desc: "empty", ref: "", expectedErr: errIDEmpty.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			id, err := resolveContainerID(context.Background(), newFakeListRuntimeSvc(), tc.ref)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if id != tc.expected {
+				t.Errorf("resolveContainerID(%q) = %q, want %q", tc.ref, id, tc.expected)
+			}
+		})
+	}
+}
+
+func TestResolvePodSandboxID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc        string
+		ref         string
+		expected    string
+		expectedErr string
+	}{
+		{desc: "unique prefix", ref: "bb", expected: "bbb111"},
+		{desc: "ambiguous prefix", ref: "aaa", expectedErr: "matches 2 pods"},
+		{desc: "namespace/pod prefers ready", ref: "default/web", expected: "aaa222"},
+		{desc: "namespace/pod/container is rejected", ref: "default/web/nginx", expectedErr: "expected NAMESPACE/POD"},
+		{desc: "empty namespace", ref: "/web", expectedErr: "must not be empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			id, err := resolvePodSandboxID(context.Background(), newFakeListRuntimeSvc(), tc.ref)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if id != tc.expected {
+				t.Errorf("resolvePodSandboxID(%q) = %q, want %q", tc.ref, id, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIsFullID(t *testing.T) {
+	t.Parallel()
+
+	if !isFullID(strings.Repeat("a1", 32)) {
+		t.Error("expected 64 hex characters to be a full ID")
+	}
+
+	if isFullID(strings.Repeat("a1", 31)) {
+		t.Error("expected 62 hex characters not to be a full ID")
+	}
+
+	if isFullID(strings.Repeat("zz", 32)) {
+		t.Error("expected non hex characters not to be a full ID")
+	}
+}
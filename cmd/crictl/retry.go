@@ -0,0 +1,234 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// retryPolicy retries RPCs failing with a transient error, like a runtime
+// being restarted, with exponential backoff.
+type retryPolicy struct {
+	maxRetries int
+	delay      time.Duration
+	maxDelay   time.Duration
+}
+
+func newRetryPolicy(maxRetries int) *retryPolicy {
+	return &retryPolicy{maxRetries: maxRetries, delay: 500 * time.Millisecond, maxDelay: 5 * time.Second}
+}
+
+// isTransientError returns true if the RPC may succeed if sent again.
+func isTransientError(err error) bool {
+	code := status.Code(err)
+
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
+
+// retryRPC calls the RPC until it does not fail with a transient error or the
+// retries are exhausted.
+func retryRPC[T any](ctx context.Context, p *retryPolicy, rpc string, call func(attempt int) (T, error)) (T, error) {
+	result, err := call(0)
+
+	delay := p.delay
+
+	for attempt := 1; attempt <= p.maxRetries && isTransientError(err); attempt++ {
+		logrus.Debugf("%s failed: %v, retry %d/%d in %v", rpc, err, attempt, p.maxRetries, delay)
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(delay):
+		}
+
+		result, err = call(attempt)
+		if err == nil {
+			logrus.Debugf("%s succeeded after %d retries", rpc, attempt)
+		}
+
+		delay = min(delay*2, p.maxDelay)
+	}
+
+	return result, err
+}
+
+// retryRemoval retries an RPC stopping or removing an object. If a retry
+// fails because the object is not found, the previous attempt may have
+// succeeded before the error, so it is treated as success.
+func retryRemoval(ctx context.Context, p *retryPolicy, rpc string, call func() error) error {
+	_, err := retryRPC(ctx, p, rpc, func(attempt int) (struct{}, error) {
+		err := call()
+		if attempt > 0 && status.Code(err) == codes.NotFound {
+			logrus.Debugf("%s retry %d: %v, assuming that a previous attempt succeeded", rpc, attempt, err)
+
+			return struct{}{}, nil
+		}
+
+		return struct{}{}, err
+	})
+
+	return err
+}
+
+// retry is a shorthand for retryRPC of RPCs which do not depend on the
+// attempt.
+func retry[T any](ctx context.Context, p *retryPolicy, rpc string, call func() (T, error)) (T, error) {
+	return retryRPC(ctx, p, rpc, func(int) (T, error) { return call() })
+}
+
+// retryRuntimeService retries the idempotent RPCs of the runtime service, as
+// well as stopping and removing pods and containers.
+type retryRuntimeService struct {
+	internalapi.RuntimeService
+
+	policy *retryPolicy
+}
+
+func (r *retryRuntimeService) Version(ctx context.Context, apiVersion string) (*runtimeapi.VersionResponse, error) {
+	return retry(ctx, r.policy, "Version", func() (*runtimeapi.VersionResponse, error) {
+		return r.RuntimeService.Version(ctx, apiVersion)
+	})
+}
+
+func (r *retryRuntimeService) Status(ctx context.Context, verbose bool) (*runtimeapi.StatusResponse, error) {
+	return retry(ctx, r.policy, "Status", func() (*runtimeapi.StatusResponse, error) {
+		return r.RuntimeService.Status(ctx, verbose)
+	})
+}
+
+func (r *retryRuntimeService) ListPodSandbox(ctx context.Context, filter *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	return retry(ctx, r.policy, "ListPodSandbox", func() ([]*runtimeapi.PodSandbox, error) {
+		return r.RuntimeService.ListPodSandbox(ctx, filter)
+	})
+}
+
+func (r *retryRuntimeService) PodSandboxStatus(ctx context.Context, podSandboxID string, verbose bool) (*runtimeapi.PodSandboxStatusResponse, error) {
+	return retry(ctx, r.policy, "PodSandboxStatus", func() (*runtimeapi.PodSandboxStatusResponse, error) {
+		return r.RuntimeService.PodSandboxStatus(ctx, podSandboxID, verbose)
+	})
+}
+
+func (r *retryRuntimeService) PodSandboxStats(ctx context.Context, podSandboxID string) (*runtimeapi.PodSandboxStats, error) {
+	return retry(ctx, r.policy, "PodSandboxStats", func() (*runtimeapi.PodSandboxStats, error) {
+		return r.RuntimeService.PodSandboxStats(ctx, podSandboxID)
+	})
+}
+
+func (r *retryRuntimeService) ListPodSandboxStats(ctx context.Context, filter *runtimeapi.PodSandboxStatsFilter) ([]*runtimeapi.PodSandboxStats, error) {
+	return retry(ctx, r.policy, "ListPodSandboxStats", func() ([]*runtimeapi.PodSandboxStats, error) {
+		return r.RuntimeService.ListPodSandboxStats(ctx, filter)
+	})
+}
+
+func (r *retryRuntimeService) ListPodSandboxMetrics(ctx context.Context) ([]*runtimeapi.PodSandboxMetrics, error) {
+	return retry(ctx, r.policy, "ListPodSandboxMetrics", func() ([]*runtimeapi.PodSandboxMetrics, error) {
+		return r.RuntimeService.ListPodSandboxMetrics(ctx)
+	})
+}
+
+func (r *retryRuntimeService) ListMetricDescriptors(ctx context.Context) ([]*runtimeapi.MetricDescriptor, error) {
+	return retry(ctx, r.policy, "ListMetricDescriptors", func() ([]*runtimeapi.MetricDescriptor, error) {
+		return r.RuntimeService.ListMetricDescriptors(ctx)
+	})
+}
+
+func (r *retryRuntimeService) ListContainers(ctx context.Context, filter *runtimeapi.ContainerFilter) ([]*runtimeapi.Container, error) {
+	return retry(ctx, r.policy, "ListContainers", func() ([]*runtimeapi.Container, error) {
+		return r.RuntimeService.ListContainers(ctx, filter)
+	})
+}
+
+func (r *retryRuntimeService) ContainerStatus(ctx context.Context, containerID string, verbose bool) (*runtimeapi.ContainerStatusResponse, error) {
+	return retry(ctx, r.policy, "ContainerStatus", func() (*runtimeapi.ContainerStatusResponse, error) {
+		return r.RuntimeService.ContainerStatus(ctx, containerID, verbose)
+	})
+}
+
+func (r *retryRuntimeService) ContainerStats(ctx context.Context, containerID string) (*runtimeapi.ContainerStats, error) {
+	return retry(ctx, r.policy, "ContainerStats", func() (*runtimeapi.ContainerStats, error) {
+		return r.RuntimeService.ContainerStats(ctx, containerID)
+	})
+}
+
+func (r *retryRuntimeService) ListContainerStats(ctx context.Context, filter *runtimeapi.ContainerStatsFilter) ([]*runtimeapi.ContainerStats, error) {
+	return retry(ctx, r.policy, "ListContainerStats", func() ([]*runtimeapi.ContainerStats, error) {
+		return r.RuntimeService.ListContainerStats(ctx, filter)
+	})
+}
+
+func (r *retryRuntimeService) StopPodSandbox(ctx context.Context, podSandboxID string) error {
+	return retryRemoval(ctx, r.policy, "StopPodSandbox", func() error {
+		return r.RuntimeService.StopPodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (r *retryRuntimeService) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	return retryRemoval(ctx, r.policy, "RemovePodSandbox", func() error {
+		return r.RuntimeService.RemovePodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (r *retryRuntimeService) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	return retryRemoval(ctx, r.policy, "StopContainer", func() error {
+		return r.RuntimeService.StopContainer(ctx, containerID, timeout)
+	})
+}
+
+func (r *retryRuntimeService) RemoveContainer(ctx context.Context, containerID string) error {
+	return retryRemoval(ctx, r.policy, "RemoveContainer", func() error {
+		return r.RuntimeService.RemoveContainer(ctx, containerID)
+	})
+}
+
+// retryImageService retries the idempotent RPCs of the image service, as well
+// as removing images.
+type retryImageService struct {
+	internalapi.ImageManagerService
+
+	policy *retryPolicy
+}
+
+func (r *retryImageService) ListImages(ctx context.Context, filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	return retry(ctx, r.policy, "ListImages", func() ([]*runtimeapi.Image, error) {
+		return r.ImageManagerService.ListImages(ctx, filter)
+	})
+}
+
+func (r *retryImageService) ImageStatus(ctx context.Context, image *runtimeapi.ImageSpec, verbose bool) (*runtimeapi.ImageStatusResponse, error) {
+	return retry(ctx, r.policy, "ImageStatus", func() (*runtimeapi.ImageStatusResponse, error) {
+		return r.ImageManagerService.ImageStatus(ctx, image, verbose)
+	})
+}
+
+func (r *retryImageService) ImageFsInfo(ctx context.Context) (*runtimeapi.ImageFsInfoResponse, error) {
+	return retry(ctx, r.policy, "ImageFsInfo", func() (*runtimeapi.ImageFsInfoResponse, error) {
+		return r.ImageManagerService.ImageFsInfo(ctx)
+	})
+}
+
+func (r *retryImageService) RemoveImage(ctx context.Context, image *runtimeapi.ImageSpec) error {
+	return retryRemoval(ctx, r.policy, "RemoveImage", func() error {
+		return r.ImageManagerService.RemoveImage(ctx, image)
+	})
+}
@@ -0,0 +1,161 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeFlakyRuntimeSvc fails every RPC with the next of its errors, until they
+// are exhausted.
+type fakeFlakyRuntimeSvc struct {
+	internalapi.RuntimeService
+
+	errs  []error
+	calls int
+}
+
+func (f *fakeFlakyRuntimeSvc) next() error {
+	f.calls++
+
+	if len(f.errs) == 0 {
+		return nil
+	}
+
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+
+	return err
+}
+
+func (f *fakeFlakyRuntimeSvc) ListContainers(context.Context, *runtimeapi.ContainerFilter) ([]*runtimeapi.Container, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+
+	return []*runtimeapi.Container{{Id: "container"}}, nil
+}
+
+func (f *fakeFlakyRuntimeSvc) RemoveContainer(context.Context, string) error {
+	return f.next()
+}
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	notFound := status.Error(codes.NotFound, "container not found")
+
+	testCases := []struct {
+		desc          string
+		errs          []error
+		call          func(internalapi.RuntimeService) error
+		expectedCalls int
+		expectedCode  codes.Code
+	}{
+		{
+			desc:          "list succeeds after transient errors",
+			errs:          []error{unavailable, status.Error(codes.DeadlineExceeded, "timeout")},
+			call:          listContainers(ctx),
+			expectedCalls: 3,
+		},
+		{
+			desc:          "list fails after the retries are exhausted",
+			errs:          []error{unavailable, unavailable, unavailable, unavailable},
+			call:          listContainers(ctx),
+			expectedCalls: 4,
+			expectedCode:  codes.Unavailable,
+		},
+		{
+			desc:          "list is not retried on other errors",
+			errs:          []error{status.Error(codes.InvalidArgument, "invalid filter")},
+			call:          listContainers(ctx),
+			expectedCalls: 1,
+			expectedCode:  codes.InvalidArgument,
+		},
+		{
+			desc:          "remove succeeds if not found after a retry",
+			errs:          []error{unavailable, notFound},
+			call:          removeContainer(ctx),
+			expectedCalls: 2,
+		},
+		{
+			desc:          "remove fails if not found without a retry",
+			errs:          []error{notFound},
+			call:          removeContainer(ctx),
+			expectedCalls: 1,
+			expectedCode:  codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewWithT(t)
+
+			fake := &fakeFlakyRuntimeSvc{errs: tc.errs}
+			service := &retryRuntimeService{
+				RuntimeService: fake,
+				policy:         &retryPolicy{maxRetries: 3, delay: time.Millisecond, maxDelay: time.Millisecond},
+			}
+
+			err := tc.call(service)
+			g.Expect(status.Code(err)).To(Equal(tc.expectedCode))
+			g.Expect(fake.calls).To(Equal(tc.expectedCalls))
+		})
+	}
+}
+
+func listContainers(ctx context.Context) func(internalapi.RuntimeService) error {
+	return func(rs internalapi.RuntimeService) error {
+		_, err := rs.ListContainers(ctx, nil)
+
+		return err
+	}
+}
+
+func removeContainer(ctx context.Context) func(internalapi.RuntimeService) error {
+	return func(rs internalapi.RuntimeService) error {
+		return rs.RemoveContainer(ctx, "container")
+	}
+}
+
+func TestRetryDisabled(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+
+	fake := &fakeFlakyRuntimeSvc{errs: []error{status.Error(codes.Unavailable, "connection refused")}}
+	cfg := &CrictlConfig{runtimeServiceOverride: fake}
+
+	rs, err := cfg.GetRuntimeService(context.Background(), 0)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = rs.ListContainers(context.Background(), nil)
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	g.Expect(fake.calls).To(Equal(1))
+}
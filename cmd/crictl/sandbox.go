@@ -49,7 +49,7 @@ var runPodCommand = &cli.Command{
 			return printJSONSchema(&pb.PodSandboxConfig{})
 		},
 	}},
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "runtime",
 			Aliases: []string{"r"},
@@ -60,7 +60,9 @@ var runPodCommand = &cli.Command{
 			Aliases: []string{"T"},
 			Usage:   "Seconds to wait for a run pod sandbox request to complete before cancelling the request",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 
 	Action: func(c *cli.Context) error {
 		sandboxSpec := c.Args().First()
@@ -79,12 +81,14 @@ var runPodCommand = &cli.Command{
 		}
 
 		// Test RuntimeServiceClient.RunPodSandbox
-		podID, err := RunPodSandbox(c.Context, runtimeClient, podSandboxConfig, c.String("runtime"))
+		podID, err := RunPodSandbox(c.Context, runtimeClient, podSandboxConfig, runtimeHandler(c))
 		if err != nil {
 			return fmt.Errorf("run pod sandbox: %w", err)
 		}
 
-		fmt.Println(podID)
+		if !dryRunning(runtimeClient) {
+			fmt.Println(podID)
+		}
 
 		return nil
 	},
@@ -104,9 +108,12 @@ var stopPodCommand = &cli.Command{
 			return err
 		}
 
-		for i := range c.NArg() {
-			id := c.Args().Get(i)
+		ids, err := resolvePodSandboxIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
 
+		for _, id := range ids {
 			err := StopPodSandbox(c.Context, runtimeClient, id)
 			if err != nil {
 				return fmt.Errorf("stopping the pod sandbox %q: %w", id, err)
@@ -122,7 +129,7 @@ var removePodCommand = &cli.Command{
 	Usage:                  "Remove one or more pods",
 	ArgsUsage:              "POD-ID [POD-ID...]",
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:    "force",
 			Aliases: []string{"f"},
@@ -133,7 +140,9 @@ var removePodCommand = &cli.Command{
 			Aliases: []string{"a"},
 			Usage:   "Remove all pods",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(ctx *cli.Context) error {
 		runtimeClient, err := configFromContext(ctx).GetRuntimeService(ctx.Context, 0)
 		if err != nil {
@@ -147,6 +156,13 @@ var removePodCommand = &cli.Command{
 			return err
 		}
 
+		if !ctx.Bool("all") {
+			ids, err = resolvePodSandboxIDs(ctx.Context, runtimeClient, ids)
+			if err != nil {
+				return err
+			}
+		}
+
 		funcs := []func() error{}
 		for _, id := range ids {
 			funcs = append(funcs, func() error {
@@ -234,7 +250,10 @@ var podStatusCommand = &cli.Command{
 			return err
 		}
 
-		ids := c.Args().Slice()
+		ids, err := resolvePodSandboxIDs(c.Context, runtimeClient, c.Args().Slice())
+		if err != nil {
+			return err
+		}
 
 		if len(ids) == 0 {
 			opts := &listOptions{
@@ -270,7 +289,7 @@ var podStatusCommand = &cli.Command{
 			c.Context,
 			runtimeClient,
 			ids,
-			c.String("output"),
+			outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			c.Bool("quiet"),
 			c.String("template"),
 		); err != nil {
@@ -337,6 +356,10 @@ var listPodCommand = &cli.Command{
 			Name:  "no-trunc",
 			Usage: "Show output without truncating the ID",
 		},
+		&cli.BoolFlag{
+			Name:  "k8s",
+			Usage: "Show Kubernetes namespace and pod names instead of IDs",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		var err error
@@ -351,12 +374,13 @@ var listPodCommand = &cli.Command{
 			state:              c.String("state"),
 			verbose:            c.Bool("verbose"),
 			quiet:              c.Bool("quiet"),
-			output:             c.String("output"),
+			output:             outputFormat(c, outputTypeJSON, outputTypeYAML, outputTypeTable),
 			latest:             c.Bool("latest"),
 			last:               c.Int("last"),
 			noTrunc:            c.Bool("no-trunc"),
 			nameRegexp:         c.String("name"),
 			podNamespaceRegexp: c.String("namespace"),
+			k8s:                c.Bool("k8s"),
 		}
 
 		opts.labels, err = parseLabelStringSlice(c.StringSlice("label"))
@@ -408,7 +432,9 @@ func StopPodSandbox(ctx context.Context, client internalapi.RuntimeService, id s
 		return err
 	}
 
-	fmt.Printf("Stopped sandbox %s\n", id)
+	if !dryRunning(client) {
+		fmt.Printf("Stopped sandbox %s\n", id)
+	}
 
 	return nil
 }
@@ -428,7 +454,9 @@ func RemovePodSandbox(ctx context.Context, client internalapi.RuntimeService, id
 		return err
 	}
 
-	fmt.Printf("Removed sandbox %s\n", id)
+	if !dryRunning(client) {
+		fmt.Printf("Removed sandbox %s\n", id)
+	}
 
 	return nil
 }
@@ -598,6 +626,10 @@ func OutputPodSandboxes(ctx context.Context, client internalapi.RuntimeService,
 		return fmt.Errorf("unsupported output format %q", opts.output)
 	}
 
+	if opts.k8s && !opts.verbose {
+		return outputK8sPodSandboxes(r, opts)
+	}
+
 	display := newDefaultTableDisplay()
 	if !opts.verbose && !opts.quiet {
 		display.AddRow([]string{
@@ -705,6 +737,40 @@ func OutputPodSandboxes(ctx context.Context, client internalapi.RuntimeService,
 	return nil
 }
 
+// outputK8sPodSandboxes prints the pod sandboxes using their Kubernetes
+// namespace and pod names instead of the IDs.
+func outputK8sPodSandboxes(sandboxes []*pb.PodSandbox, opts *listOptions) error {
+	display := newDefaultTableDisplay()
+	if !opts.quiet {
+		display.AddRow([]string{columnNamespace, columnPodName, columnCreated, columnState, columnAttempt, columnPodRuntime})
+	}
+
+	for _, pod := range sandboxes {
+		if opts.quiet {
+			fmt.Printf("%s/%s\n", pod.GetMetadata().GetNamespace(), pod.GetMetadata().GetName())
+
+			continue
+		}
+
+		podState, err := convertPodState(pod.GetState())
+		if err != nil {
+			return err
+		}
+
+		createdAt := time.Unix(0, pod.GetCreatedAt())
+		display.AddRow([]string{
+			pod.GetMetadata().GetNamespace(),
+			pod.GetMetadata().GetName(),
+			units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago",
+			podState,
+			strconv.FormatUint(uint64(pod.GetMetadata().GetAttempt()), 10),
+			getSandboxesRuntimeHandler(pod),
+		})
+	}
+
+	return display.Flush()
+}
+
 func convertPodState(state pb.PodSandboxState) (string, error) {
 	switch state {
 	case pb.PodSandboxState_SANDBOX_READY:
@@ -0,0 +1,420 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	units "github.com/docker/go-units"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/runtime/protoiface"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+const (
+	timingConnectRuntime = "(connect runtime)"
+	timingConnectImage   = "(connect image)"
+)
+
+// timingEntry is a single RPC or connection setup.
+type timingEntry struct {
+	rpc      string
+	duration time.Duration
+	size     int
+	code     codes.Code
+}
+
+// timingRecorder records the duration, response size and status code of all
+// RPCs for the report printed by --timing.
+type timingRecorder struct {
+	out     io.Writer
+	started time.Time
+
+	mu      sync.Mutex
+	entries []timingEntry
+}
+
+func newTimingRecorder(out io.Writer) *timingRecorder {
+	return &timingRecorder{out: out, started: time.Now()}
+}
+
+func (t *timingRecorder) record(rpc string, duration time.Duration, size int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entries = append(t.entries, timingEntry{rpc: rpc, duration: duration, size: size, code: status.Code(err)})
+}
+
+// timed calls the RPC and records its timing.
+func timed[T any](t *timingRecorder, rpc string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	resp, err := call()
+	t.record(rpc, time.Since(start), responseSize(resp), err)
+
+	return resp, err
+}
+
+// timedErr calls an RPC without response and records its timing.
+func timedErr(t *timingRecorder, rpc string, call func() error) error {
+	_, err := timed(t, rpc, func() (struct{}, error) { return struct{}{}, call() })
+
+	return err
+}
+
+// responseSize returns the size of a response in the wire format, which is
+// the sum of the sizes of the items for lists.
+func responseSize(resp any) int {
+	if message, ok := resp.(protoiface.MessageV1); ok {
+		return proto.Size(protoadapt.MessageV2Of(message))
+	}
+
+	if id, ok := resp.(string); ok {
+		return len(id)
+	}
+
+	value := reflect.ValueOf(resp)
+	if value.Kind() != reflect.Slice {
+		return 0
+	}
+
+	size := 0
+	for i := range value.Len() {
+		size += responseSize(value.Index(i).Interface())
+	}
+
+	return size
+}
+
+// timingSummary aggregates all calls of a single RPC.
+type timingSummary struct {
+	rpc      string
+	calls    int
+	errors   int
+	duration time.Duration
+	size     int
+}
+
+func (s *timingSummary) add(entry *timingEntry) {
+	s.calls++
+	s.duration += entry.duration
+	s.size += entry.size
+
+	if entry.code != codes.OK {
+		s.errors++
+	}
+}
+
+// print writes the report of all recorded RPCs in order, followed by a
+// summary of the calls of each RPC and the totals.
+func (t *timingRecorder) print() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w := tabwriter.NewWriter(t.out, 10, 1, 3, ' ', 0)
+
+	fmt.Fprintln(w, "RPC\tDURATION\tSIZE\tSTATUS")
+
+	summaries := []*timingSummary{}
+	byRPC := map[string]*timingSummary{}
+	total := &timingSummary{rpc: "TOTAL"}
+
+	for i := range t.entries {
+		entry := &t.entries[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.rpc, formatTimingDuration(entry.duration), units.HumanSize(float64(entry.size)), entry.code)
+
+		summary, ok := byRPC[entry.rpc]
+		if !ok {
+			summary = &timingSummary{rpc: entry.rpc}
+			byRPC[entry.rpc] = summary
+			summaries = append(summaries, summary)
+		}
+
+		summary.add(entry)
+		total.add(entry)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "RPC\tCALLS\tERRORS\tTOTAL\tAVERAGE\tSIZE")
+
+	for _, summary := range append(summaries, total) {
+		average := time.Duration(0)
+		if summary.calls > 0 {
+			average = summary.duration / time.Duration(summary.calls)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", summary.rpc, summary.calls, summary.errors,
+			formatTimingDuration(summary.duration), formatTimingDuration(average), units.HumanSize(float64(summary.size)))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Elapsed: %s\n", formatTimingDuration(time.Since(t.started)))
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write timing report: %w", err)
+	}
+
+	return nil
+}
+
+func formatTimingDuration(d time.Duration) string {
+	return d.Round(time.Microsecond).String()
+}
+
+// timingRuntimeService records the timing of all RPCs of the runtime service.
+type timingRuntimeService struct {
+	internalapi.RuntimeService
+
+	recorder *timingRecorder
+}
+
+func (t *timingRuntimeService) Version(ctx context.Context, apiVersion string) (*runtimeapi.VersionResponse, error) {
+	return timed(t.recorder, "Version", func() (*runtimeapi.VersionResponse, error) {
+		return t.RuntimeService.Version(ctx, apiVersion)
+	})
+}
+
+func (t *timingRuntimeService) CreateContainer(ctx context.Context, podSandboxID string, config *runtimeapi.ContainerConfig, sandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	return timed(t.recorder, "CreateContainer", func() (string, error) {
+		return t.RuntimeService.CreateContainer(ctx, podSandboxID, config, sandboxConfig)
+	})
+}
+
+func (t *timingRuntimeService) StartContainer(ctx context.Context, containerID string) error {
+	return timedErr(t.recorder, "StartContainer", func() error {
+		return t.RuntimeService.StartContainer(ctx, containerID)
+	})
+}
+
+func (t *timingRuntimeService) StopContainer(ctx context.Context, containerID string, timeout int64) error {
+	return timedErr(t.recorder, "StopContainer", func() error {
+		return t.RuntimeService.StopContainer(ctx, containerID, timeout)
+	})
+}
+
+func (t *timingRuntimeService) RemoveContainer(ctx context.Context, containerID string) error {
+	return timedErr(t.recorder, "RemoveContainer", func() error {
+		return t.RuntimeService.RemoveContainer(ctx, containerID)
+	})
+}
+
+func (t *timingRuntimeService) ListContainers(ctx context.Context, filter *runtimeapi.ContainerFilter) ([]*runtimeapi.Container, error) {
+	return timed(t.recorder, "ListContainers", func() ([]*runtimeapi.Container, error) {
+		return t.RuntimeService.ListContainers(ctx, filter)
+	})
+}
+
+func (t *timingRuntimeService) ContainerStatus(ctx context.Context, containerID string, verbose bool) (*runtimeapi.ContainerStatusResponse, error) {
+	return timed(t.recorder, "ContainerStatus", func() (*runtimeapi.ContainerStatusResponse, error) {
+		return t.RuntimeService.ContainerStatus(ctx, containerID, verbose)
+	})
+}
+
+func (t *timingRuntimeService) UpdateContainerResources(ctx context.Context, containerID string, resources *runtimeapi.ContainerResources) error {
+	return timedErr(t.recorder, "UpdateContainerResources", func() error {
+		return t.RuntimeService.UpdateContainerResources(ctx, containerID, resources)
+	})
+}
+
+func (t *timingRuntimeService) ExecSync(ctx context.Context, containerID string, cmd []string, timeout time.Duration) (stdout, stderr []byte, err error) {
+	start := time.Now()
+	stdout, stderr, err = t.RuntimeService.ExecSync(ctx, containerID, cmd, timeout)
+	t.recorder.record("ExecSync", time.Since(start), len(stdout)+len(stderr), err)
+
+	return stdout, stderr, err
+}
+
+func (t *timingRuntimeService) Exec(ctx context.Context, req *runtimeapi.ExecRequest) (*runtimeapi.ExecResponse, error) {
+	return timed(t.recorder, "Exec", func() (*runtimeapi.ExecResponse, error) {
+		return t.RuntimeService.Exec(ctx, req)
+	})
+}
+
+func (t *timingRuntimeService) Attach(ctx context.Context, req *runtimeapi.AttachRequest) (*runtimeapi.AttachResponse, error) {
+	return timed(t.recorder, "Attach", func() (*runtimeapi.AttachResponse, error) {
+		return t.RuntimeService.Attach(ctx, req)
+	})
+}
+
+func (t *timingRuntimeService) ReopenContainerLog(ctx context.Context, containerID string) error {
+	return timedErr(t.recorder, "ReopenContainerLog", func() error {
+		return t.RuntimeService.ReopenContainerLog(ctx, containerID)
+	})
+}
+
+func (t *timingRuntimeService) CheckpointContainer(ctx context.Context, req *runtimeapi.CheckpointContainerRequest) error {
+	return timedErr(t.recorder, "CheckpointContainer", func() error {
+		return t.RuntimeService.CheckpointContainer(ctx, req)
+	})
+}
+
+func (t *timingRuntimeService) GetContainerEvents(ctx context.Context, containerEventsCh chan *runtimeapi.ContainerEventResponse, connectionEstablishedCallback func(runtimeapi.RuntimeService_GetContainerEventsClient)) error {
+	return timedErr(t.recorder, "GetContainerEvents", func() error {
+		return t.RuntimeService.GetContainerEvents(ctx, containerEventsCh, connectionEstablishedCallback)
+	})
+}
+
+func (t *timingRuntimeService) RunPodSandbox(ctx context.Context, config *runtimeapi.PodSandboxConfig, runtimeHandler string) (string, error) {
+	return timed(t.recorder, "RunPodSandbox", func() (string, error) {
+		return t.RuntimeService.RunPodSandbox(ctx, config, runtimeHandler)
+	})
+}
+
+func (t *timingRuntimeService) StopPodSandbox(ctx context.Context, podSandboxID string) error {
+	return timedErr(t.recorder, "StopPodSandbox", func() error {
+		return t.RuntimeService.StopPodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (t *timingRuntimeService) RemovePodSandbox(ctx context.Context, podSandboxID string) error {
+	return timedErr(t.recorder, "RemovePodSandbox", func() error {
+		return t.RuntimeService.RemovePodSandbox(ctx, podSandboxID)
+	})
+}
+
+func (t *timingRuntimeService) PodSandboxStatus(ctx context.Context, podSandboxID string, verbose bool) (*runtimeapi.PodSandboxStatusResponse, error) {
+	return timed(t.recorder, "PodSandboxStatus", func() (*runtimeapi.PodSandboxStatusResponse, error) {
+		return t.RuntimeService.PodSandboxStatus(ctx, podSandboxID, verbose)
+	})
+}
+
+func (t *timingRuntimeService) ListPodSandbox(ctx context.Context, filter *runtimeapi.PodSandboxFilter) ([]*runtimeapi.PodSandbox, error) {
+	return timed(t.recorder, "ListPodSandbox", func() ([]*runtimeapi.PodSandbox, error) {
+		return t.RuntimeService.ListPodSandbox(ctx, filter)
+	})
+}
+
+func (t *timingRuntimeService) PortForward(ctx context.Context, req *runtimeapi.PortForwardRequest) (*runtimeapi.PortForwardResponse, error) {
+	return timed(t.recorder, "PortForward", func() (*runtimeapi.PortForwardResponse, error) {
+		return t.RuntimeService.PortForward(ctx, req)
+	})
+}
+
+func (t *timingRuntimeService) UpdatePodSandboxResources(ctx context.Context, req *runtimeapi.UpdatePodSandboxResourcesRequest) (*runtimeapi.UpdatePodSandboxResourcesResponse, error) {
+	return timed(t.recorder, "UpdatePodSandboxResources", func() (*runtimeapi.UpdatePodSandboxResourcesResponse, error) {
+		return t.RuntimeService.UpdatePodSandboxResources(ctx, req)
+	})
+}
+
+func (t *timingRuntimeService) ContainerStats(ctx context.Context, containerID string) (*runtimeapi.ContainerStats, error) {
+	return timed(t.recorder, "ContainerStats", func() (*runtimeapi.ContainerStats, error) {
+		return t.RuntimeService.ContainerStats(ctx, containerID)
+	})
+}
+
+func (t *timingRuntimeService) ListContainerStats(ctx context.Context, filter *runtimeapi.ContainerStatsFilter) ([]*runtimeapi.ContainerStats, error) {
+	return timed(t.recorder, "ListContainerStats", func() ([]*runtimeapi.ContainerStats, error) {
+		return t.RuntimeService.ListContainerStats(ctx, filter)
+	})
+}
+
+func (t *timingRuntimeService) PodSandboxStats(ctx context.Context, podSandboxID string) (*runtimeapi.PodSandboxStats, error) {
+	return timed(t.recorder, "PodSandboxStats", func() (*runtimeapi.PodSandboxStats, error) {
+		return t.RuntimeService.PodSandboxStats(ctx, podSandboxID)
+	})
+}
+
+func (t *timingRuntimeService) ListPodSandboxStats(ctx context.Context, filter *runtimeapi.PodSandboxStatsFilter) ([]*runtimeapi.PodSandboxStats, error) {
+	return timed(t.recorder, "ListPodSandboxStats", func() ([]*runtimeapi.PodSandboxStats, error) {
+		return t.RuntimeService.ListPodSandboxStats(ctx, filter)
+	})
+}
+
+func (t *timingRuntimeService) ListMetricDescriptors(ctx context.Context) ([]*runtimeapi.MetricDescriptor, error) {
+	return timed(t.recorder, "ListMetricDescriptors", func() ([]*runtimeapi.MetricDescriptor, error) {
+		return t.RuntimeService.ListMetricDescriptors(ctx)
+	})
+}
+
+func (t *timingRuntimeService) ListPodSandboxMetrics(ctx context.Context) ([]*runtimeapi.PodSandboxMetrics, error) {
+	return timed(t.recorder, "ListPodSandboxMetrics", func() ([]*runtimeapi.PodSandboxMetrics, error) {
+		return t.RuntimeService.ListPodSandboxMetrics(ctx)
+	})
+}
+
+func (t *timingRuntimeService) UpdateRuntimeConfig(ctx context.Context, runtimeConfig *runtimeapi.RuntimeConfig) error {
+	return timedErr(t.recorder, "UpdateRuntimeConfig", func() error {
+		return t.RuntimeService.UpdateRuntimeConfig(ctx, runtimeConfig)
+	})
+}
+
+func (t *timingRuntimeService) Status(ctx context.Context, verbose bool) (*runtimeapi.StatusResponse, error) {
+	return timed(t.recorder, "Status", func() (*runtimeapi.StatusResponse, error) {
+		return t.RuntimeService.Status(ctx, verbose)
+	})
+}
+
+func (t *timingRuntimeService) RuntimeConfig(ctx context.Context) (*runtimeapi.RuntimeConfigResponse, error) {
+	return timed(t.recorder, "RuntimeConfig", func() (*runtimeapi.RuntimeConfigResponse, error) {
+		return t.RuntimeService.RuntimeConfig(ctx)
+	})
+}
+
+// timingImageService records the timing of all RPCs of the image service.
+type timingImageService struct {
+	internalapi.ImageManagerService
+
+	recorder *timingRecorder
+}
+
+func (t *timingImageService) ListImages(ctx context.Context, filter *runtimeapi.ImageFilter) ([]*runtimeapi.Image, error) {
+	return timed(t.recorder, "ListImages", func() ([]*runtimeapi.Image, error) {
+		return t.ImageManagerService.ListImages(ctx, filter)
+	})
+}
+
+func (t *timingImageService) ImageStatus(ctx context.Context, image *runtimeapi.ImageSpec, verbose bool) (*runtimeapi.ImageStatusResponse, error) {
+	return timed(t.recorder, "ImageStatus", func() (*runtimeapi.ImageStatusResponse, error) {
+		return t.ImageManagerService.ImageStatus(ctx, image, verbose)
+	})
+}
+
+func (t *timingImageService) PullImage(ctx context.Context, image *runtimeapi.ImageSpec, auth *runtimeapi.AuthConfig, podSandboxConfig *runtimeapi.PodSandboxConfig) (string, error) {
+	return timed(t.recorder, "PullImage", func() (string, error) {
+		return t.ImageManagerService.PullImage(ctx, image, auth, podSandboxConfig)
+	})
+}
+
+func (t *timingImageService) RemoveImage(ctx context.Context, image *runtimeapi.ImageSpec) error {
+	return timedErr(t.recorder, "RemoveImage", func() error {
+		return t.ImageManagerService.RemoveImage(ctx, image)
+	})
+}
+
+func (t *timingImageService) ImageFsInfo(ctx context.Context) (*runtimeapi.ImageFsInfoResponse, error) {
+	return timed(t.recorder, "ImageFsInfo", func() (*runtimeapi.ImageFsInfoResponse, error) {
+		return t.ImageManagerService.ImageFsInfo(ctx)
+	})
+}
+
+// timedConnect records the timing of the connection setup to an endpoint.
+func timedConnect[T any](t *timingRecorder, name string, connect func() (T, error)) (T, error) {
+	start := time.Now()
+	service, err := connect()
+	t.record(name, time.Since(start), 0, err)
+
+	return service, err
+}
@@ -0,0 +1,83 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// fakeTimingImageSvc implements the RPCs used to verify the timing report.
+type fakeTimingImageSvc struct {
+	internalapi.ImageManagerService
+}
+
+func (fakeTimingImageSvc) ImageStatus(_ context.Context, image *runtimeapi.ImageSpec, _ bool) (*runtimeapi.ImageStatusResponse, error) {
+	if image.GetImage() == "missing" {
+		return nil, status.Error(codes.NotFound, "image not found")
+	}
+
+	return &runtimeapi.ImageStatusResponse{Image: &runtimeapi.Image{Id: image.GetImage()}}, nil
+}
+
+func TestTiming(t *testing.T) {
+	t.Parallel()
+
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	out := &bytes.Buffer{}
+	cfg := &CrictlConfig{
+		timing:                 newTimingRecorder(out),
+		runtimeServiceOverride: fakeReadRuntimeSvc{},
+		imageServiceOverride:   fakeTimingImageSvc{},
+	}
+
+	rs, err := cfg.GetRuntimeService(ctx, 0)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	is, err := cfg.GetImageService(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	pods, err := rs.ListPodSandbox(ctx, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pods).To(HaveLen(1))
+
+	for _, image := range []string{"busybox", "nginx", "missing"} {
+		_, _ = is.ImageStatus(ctx, &runtimeapi.ImageSpec{Image: image}, false)
+	}
+
+	g.Expect(cfg.timing.print()).To(Succeed())
+
+	report := out.String()
+	g.Expect(report).To(MatchRegexp(`(?m)^RPC\s+DURATION\s+SIZE\s+STATUS$`))
+	g.Expect(report).To(MatchRegexp(`(?m)^\(connect runtime\)\s+\S+\s+0B\s+OK$`))
+	g.Expect(report).To(MatchRegexp(`(?m)^\(connect image\)\s+\S+\s+0B\s+OK$`))
+	// The size of the PodSandbox message with the ID "pod".
+	g.Expect(report).To(MatchRegexp(`(?m)^ListPodSandbox\s+\S+\s+5B\s+OK$`))
+	g.Expect(report).To(MatchRegexp(`(?m)^ImageStatus\s+\S+\s+\S+\s+NotFound$`))
+	g.Expect(report).To(MatchRegexp(`(?m)^ImageStatus\s+3\s+1\s+`))
+	g.Expect(report).To(MatchRegexp(`(?m)^TOTAL\s+6\s+1\s+`))
+	g.Expect(report).To(MatchRegexp(`(?m)^Elapsed: \S+$`))
+}
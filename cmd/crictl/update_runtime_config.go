@@ -33,15 +33,17 @@ var updateRuntimeConfigCommand = &cli.Command{
 	Name:                   "update-runtime-config",
 	Usage:                  "Update the runtime configuration",
 	UseShortOptionHandling: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    podCIDRFlag,
 			Aliases: []string{"p"},
 			Usage:   "The new Classless Inter-Domain Routing (CIDR) value to be used for pod IP addresses. If the CIDR is empty, runtimes should omit it.",
 		},
-	},
+	}, dryRunFlags()...),
+	Before: beginDryRun,
+	After:  endDryRun,
 	Action: func(c *cli.Context) error {
-		if c.NArg() != 0 || c.NumFlags() == 0 {
+		if c.NArg() != 0 || !c.IsSet(podCIDRFlag) {
 			return cli.ShowSubcommandHelp(c)
 		}
 
@@ -61,7 +63,9 @@ var updateRuntimeConfigCommand = &cli.Command{
 			return fmt.Errorf("update runtime config: %w", err)
 		}
 
-		logrus.Info("Runtime config successfully updated")
+		if !dryRunning(runtimeClient) {
+			logrus.Info("Runtime config successfully updated")
+		}
 
 		return nil
 	},
@@ -147,6 +147,8 @@ type listOptions struct {
 	image string
 	// resolve image path
 	resolveImagePath bool
+	// show Kubernetes namespace, pod and container names instead of IDs
+	k8s bool
 }
 
 type execOptions struct {
@@ -50,6 +50,8 @@ var (
 	isBenchMark = flag.Bool(benchmarkFlag, false, "Run benchmarks instead of validation tests")
 	parallel    = flag.Int(parallelFlag, 1, "The number of parallel test nodes to run (default 1)")
 	version     = flag.Bool(versionFlag, false, "Display version of critest")
+
+	runtimeHandlers = flag.String(runtimeHandlersFlag, "", `Run the suite once per runtime handler, "all" for the runtime handlers reported by the runtime or a comma separated list`)
 )
 
 func init() {
@@ -74,6 +76,20 @@ func getConfigFromFile() {
 		if !isFlagSet("image-endpoint") && configFromFile.ImageEndpoint != "" {
 			framework.TestContext.ImageServiceAddr = configFromFile.ImageEndpoint
 		}
+
+		for name, value := range map[string]struct {
+			target *string
+			config string
+		}{
+			"tls-ca":   {&framework.TestContext.TLSCA, configFromFile.TLSCA},
+			"tls-cert": {&framework.TestContext.TLSCert, configFromFile.TLSCert},
+			"tls-key":  {&framework.TestContext.TLSKey, configFromFile.TLSKey},
+			"tls-sni":  {&framework.TestContext.TLSSNI, configFromFile.TLSSNI},
+		} {
+			if !isFlagSet(name) && value.config != "" {
+				*value.target = value.config
+			}
+		}
 	}
 }
 
@@ -94,6 +110,18 @@ func runTestSuite(t *testing.T) {
 	t.Helper()
 	gomega.RegisterFailHandler(ginkgo.Fail)
 	ginkgo.RunSpecs(t, "CRI validation")
+
+	if err := framework.CloseEndpointProxies(); err != nil {
+		t.Logf("Failed to close endpoint forwarding: %v", err)
+	}
+
+	if err := framework.StopLocalRegistry(); err != nil {
+		t.Logf("Failed to stop the local registry: %v", err)
+	}
+
+	if err := framework.ShutdownTracing(); err != nil {
+		t.Logf("Failed to export the spans: %v", err)
+	}
 }
 
 func generateTempTestName() (string, error) {
@@ -209,9 +237,12 @@ func TestCRISuite(t *testing.T) {
 		}
 	}
 
-	if *parallel > 1 {
+	switch {
+	case *runtimeHandlers != "":
+		runRuntimeHandlersSuite(t)
+	case *parallel > 1:
 		runParallelTestSuite(t)
-	} else {
+	default:
 		runTestSuite(t)
 	}
 }
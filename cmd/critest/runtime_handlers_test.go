@@ -0,0 +1,364 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	ginkgotypes "github.com/onsi/ginkgo/v2/types"
+
+	"sigs.k8s.io/cri-tools/pkg/framework"
+)
+
+const (
+	runtimeHandlersFlag = "runtime-handlers"
+
+	// allRuntimeHandlers runs the suite for every runtime handler reported
+	// by the runtime.
+	allRuntimeHandlers = "all"
+
+	// defaultRuntimeHandlerName is shown for the default runtime handler,
+	// which has an empty name.
+	defaultRuntimeHandlerName = "<default>"
+)
+
+// handlerMatrix are the results of the specs for every runtime handler.
+type handlerMatrix struct {
+	// Handlers are the runtime handlers in the order they ran.
+	Handlers []string `json:"handlers"`
+	// Specs are the results of the specs which ran on any handler.
+	Specs []handlerMatrixSpec `json:"specs"`
+}
+
+// handlerMatrixSpec are the results of a spec by runtime handler.
+type handlerMatrixSpec struct {
+	Text    string            `json:"text"`
+	Results map[string]string `json:"results"`
+}
+
+// runRuntimeHandlersSuite runs the suite once per runtime handler of the
+// runtime-handlers flag, each in its own critest process, and prints the
+// combined results.
+func runRuntimeHandlersSuite(t *testing.T) {
+	t.Helper()
+
+	if isFlagSet("runtime-handler") {
+		t.Fatalf("The runtime-handler and %s flags cannot be used together", runtimeHandlersFlag)
+	}
+
+	handlers, err := resolveRuntimeHandlers(t.Context(), *runtimeHandlers)
+	if err != nil {
+		t.Fatalf("Failed to resolve the runtime handlers: %v", err)
+	}
+
+	criPath, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to lookup path of critest: %v", err)
+	}
+
+	reportDir := framework.TestContext.ReportDir
+	if reportDir == "" {
+		reportDir = t.TempDir()
+	} else if err := os.MkdirAll(reportDir, 0o755); err != nil {
+		t.Fatalf("Failed to create the report directory: %v", err)
+	}
+
+	matrix := &handlerMatrix{}
+
+	var failed []string
+
+	for _, handler := range handlers {
+		name := runtimeHandlerName(handler)
+		jsonReport := filepath.Join(reportDir, fmt.Sprintf("%sruntime-handler-%s.json",
+			framework.TestContext.ReportPrefix, runtimeHandlerFileName(handler)))
+
+		args, err := runtimeHandlerArgs(handler, jsonReport)
+		if err != nil {
+			t.Fatalf("Failed to generate the arguments for runtime handler %s: %v", name, err)
+		}
+
+		fmt.Printf("\nRunning the suite with runtime handler %s\n\n", name)
+
+		cmd := exec.CommandContext(t.Context(), criPath, args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			failed = append(failed, name)
+		}
+
+		if err := matrix.add(name, jsonReport); err != nil {
+			t.Errorf("Failed to read the report of runtime handler %s: %v", name, err)
+		}
+	}
+
+	matrix.print(os.Stdout)
+
+	if framework.TestContext.ReportDir != "" {
+		if err := matrix.write(filepath.Join(reportDir, framework.TestContext.ReportPrefix+"runtime-handlers.json")); err != nil {
+			t.Errorf("Failed to write the runtime handler matrix: %v", err)
+		}
+	}
+
+	if len(failed) > 0 {
+		t.Fatalf("The suite failed with runtime handlers %s", strings.Join(failed, ", "))
+	}
+}
+
+// resolveRuntimeHandlers returns the runtime handlers of the flag value, which
+// is either "all" or a comma separated list of handlers.
+func resolveRuntimeHandlers(ctx context.Context, value string) ([]string, error) {
+	if value != allRuntimeHandlers {
+		var handlers []string
+
+		for handler := range strings.SplitSeq(value, ",") {
+			if handler = strings.TrimSpace(handler); handler != "" && !slices.Contains(handlers, handler) {
+				handlers = append(handlers, handler)
+			}
+		}
+
+		if len(handlers) == 0 {
+			return nil, fmt.Errorf("no runtime handlers in %q", value)
+		}
+
+		return handlers, nil
+	}
+
+	c, err := framework.LoadCRIClient()
+	if err != nil {
+		return nil, fmt.Errorf("create the CRI client: %w", err)
+	}
+
+	defer func() {
+		if err := framework.CloseEndpointProxies(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close endpoint forwarding: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	status, err := c.CRIRuntimeClient.Status(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("get the runtime status: %w", err)
+	}
+
+	handlers := make([]string, 0, len(status.GetRuntimeHandlers()))
+	for _, handler := range status.GetRuntimeHandlers() {
+		handlers = append(handlers, handler.GetName())
+	}
+
+	if len(handlers) == 0 {
+		return nil, errors.New("the runtime does not report its runtime handlers")
+	}
+
+	return handlers, nil
+}
+
+// runtimeHandlerArgs returns the arguments of the critest process of the
+// runtime handler, which are the flags of this process with the runtime
+// handler and a JSON report.
+func runtimeHandlerArgs(handler, jsonReport string) ([]string, error) {
+	suiteConfig, reporterConfig := ginkgo.GinkgoConfiguration()
+	reporterConfig.JSONReport = jsonReport
+
+	args, err := ginkgotypes.GenerateGinkgoTestRunArgs(suiteConfig, reporterConfig, ginkgotypes.GoFlagsConfig{})
+	if err != nil {
+		return nil, err
+	}
+
+	// The ginkgo flags are generated from the configuration, see
+	// runParallelTestSuite.
+	flag.Visit(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Name, "ginkgo.") || f.Name == runtimeHandlersFlag {
+			return
+		}
+
+		value := f.Value.String()
+		if f.Name == "conformance-report" {
+			// Every runtime handler writes its own conformance report.
+			ext := filepath.Ext(value)
+			value = strings.TrimSuffix(value, ext) + "-" + runtimeHandlerFileName(handler) + ext
+		}
+
+		args = append(args, fmt.Sprintf("-%s=%s", f.Name, value))
+	})
+
+	return append(args, "-runtime-handler="+handler), nil
+}
+
+// runtimeHandlerName returns the name of the runtime handler to be shown.
+func runtimeHandlerName(handler string) string {
+	if handler == "" {
+		return defaultRuntimeHandlerName
+	}
+
+	return handler
+}
+
+// runtimeHandlerFileName returns the name of the runtime handler to be used in
+// file names.
+func runtimeHandlerFileName(handler string) string {
+	if handler == "" {
+		return "default"
+	}
+
+	return strings.ReplaceAll(handler, "/", "_")
+}
+
+// add adds the results of the specs in the JSON report of the runtime
+// handler.
+func (m *handlerMatrix) add(handler, jsonReport string) error {
+	m.Handlers = append(m.Handlers, handler)
+
+	data, err := os.ReadFile(jsonReport)
+	if err != nil {
+		return err
+	}
+
+	var reports []ginkgotypes.Report
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return fmt.Errorf("decode %s: %w", jsonReport, err)
+	}
+
+	for _, report := range reports {
+		for _, spec := range report.SpecReports {
+			if spec.LeafNodeType != ginkgotypes.NodeTypeIt {
+				continue
+			}
+
+			text := spec.FullText()
+
+			i := slices.IndexFunc(m.Specs, func(s handlerMatrixSpec) bool { return s.Text == text })
+			if i < 0 {
+				m.Specs = append(m.Specs, handlerMatrixSpec{Text: text, Results: map[string]string{}})
+				i = len(m.Specs) - 1
+			}
+
+			m.Specs[i].Results[handler] = spec.State.String()
+		}
+	}
+
+	return nil
+}
+
+// print prints the number of specs by result for every runtime handler and
+// the results of the specs which did not pass or were not skipped on every
+// handler.
+func (m *handlerMatrix) print(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "\nRuntime handler matrix\n\nRUNTIME HANDLER\tPASSED\tFAILED\tSKIPPED\tOTHER\n")
+
+	for _, handler := range m.Handlers {
+		counts := map[string]int{}
+
+		for _, spec := range m.Specs {
+			result, ok := spec.Results[handler]
+			if !ok {
+				continue
+			}
+
+			switch result {
+			case "passed", "failed", "skipped":
+				counts[result]++
+			default:
+				counts["other"]++
+			}
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", handler, counts["passed"], counts["failed"], counts["skipped"], counts["other"])
+	}
+
+	var rows []handlerMatrixSpec
+
+	for _, spec := range m.Specs {
+		if !spec.same("passed", m.Handlers) && !spec.same("skipped", m.Handlers) {
+			rows = append(rows, spec)
+		}
+	}
+
+	if len(rows) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nSPEC\t%s\n", strings.Join(m.Handlers, "\t"))
+
+	for _, spec := range rows {
+		results := make([]string, 0, len(m.Handlers))
+		for _, handler := range m.Handlers {
+			results = append(results, spec.result(handler))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\n", spec.Text, strings.Join(results, "\t"))
+	}
+}
+
+// write writes the matrix as JSON to the file.
+func (m *handlerMatrix) write(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// same returns whether the spec has the result on all runtime handlers.
+func (s *handlerMatrixSpec) same(result string, handlers []string) bool {
+	for _, handler := range handlers {
+		if s.result(handler) != result {
+			return false
+		}
+	}
+
+	return true
+}
+
+// result returns the result of the spec on the runtime handler, or "-" if it
+// didn't run.
+func (s *handlerMatrixSpec) result(handler string) string {
+	if result, ok := s.Results[handler]; ok {
+		return result
+	}
+
+	return "-"
+}
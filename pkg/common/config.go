@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -42,15 +43,74 @@ type ServerConfiguration struct {
 	DisablePullOnRun bool
 	// MaxRetries is the number of retries for connecting to the server
 	MaxRetries int
+	// RPCRetries is the number of retries for idempotent RPCs failing with a transient error
+	RPCRetries int
+	// Context is the name of the selected context, if any
+	Context string
+	// TLSCA is the path to the TLS CA certificate of tls:// endpoints and streaming
+	TLSCA string
+	// TLSCert is the path to the TLS client certificate of tls:// endpoints and streaming
+	TLSCert string
+	// TLSKey is the path to the TLS client key of tls:// endpoints and streaming
+	TLSKey string
+	// TLSSNI is the server name used to verify the TLS certificates of tls:// endpoints and streaming
+	TLSSNI string
+	// ReadOnly rejects all mutating RPCs
+	ReadOnly bool
+	// ReadOnlyAllowStreaming allows exec, attach and port-forward in read-only mode
+	ReadOnlyAllowStreaming bool
+	// AuditLog is the path of the log the mutating invocations are appended to
+	AuditLog string
+	// RuntimeHandler is the default runtime handler for new pods
+	RuntimeHandler string
+	// Output is the default output format
+	Output string
+	// Sources maps the keys of all set options to where their value comes
+	// from, which is a file path, a context or an environment variable
+	Sources map[string]string
+}
+
+// UserConfigPath returns the path of the user level config file, which is
+// crictl/crictl.yaml in $XDG_CONFIG_HOME or ~/.config on Linux.
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("get user config dir: %w", err)
+	}
+
+	return filepath.Join(dir, "crictl", "crictl.yaml"), nil
 }
 
 // GetServerConfigFromFile returns the CRI server configuration from file.
+// Only the top level options of the file are used, the contexts are ignored.
 func GetServerConfigFromFile(configFileName, currentDir string) (*ServerConfiguration, error) {
-	serverConfig := ServerConfiguration{}
+	configFileName, err := resolveConfigFile(configFileName, currentDir)
+	if err != nil {
+		return nil, err
+	}
 
+	return loadServerConfig([]string{configFileName}, false, "", nil)
+}
+
+// GetServerConfigFromFileWithContext returns the CRI server configuration
+// from file like GetServerConfigFromFile. The options of the context named
+// contextName, or of the current-context if contextName is empty, override
+// the top level options of the file.
+func GetServerConfigFromFileWithContext(configFileName, currentDir, contextName string) (*ServerConfiguration, error) {
+	configFileName, err := resolveConfigFile(configFileName, currentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadServerConfig([]string{configFileName}, true, contextName, nil)
+}
+
+// resolveConfigFile returns the config file, or crictl.yaml in the parent
+// directory of currentDir if it does not exist.
+func resolveConfigFile(configFileName, currentDir string) (string, error) {
 	if _, err := os.Stat(configFileName); err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
-			return nil, fmt.Errorf("load config file: %w", err)
+			return "", fmt.Errorf("load config file: %w", err)
 		}
 		// If the config file was not found, try looking in the program's
 		// directory as a fallback. This is to accommodate where the config file
@@ -59,26 +119,186 @@ func GetServerConfigFromFile(configFileName, currentDir string) (*ServerConfigur
 		logrus.Warnf("Config %q does not exist, trying next: %q", configFileName, nextConfigFileName)
 
 		if _, err := os.Stat(nextConfigFileName); err != nil {
-			return nil, fmt.Errorf("load config file: %w", err)
+			return "", fmt.Errorf("load config file: %w", err)
 		}
 
 		configFileName = nextConfigFileName
 	}
 
-	// Get config from file.
-	config, err := ReadConfig(configFileName)
-	if err != nil {
-		return nil, fmt.Errorf("load config file: %w", err)
+	return configFileName, nil
+}
+
+// LoadServerConfig returns the CRI server configuration from the layered
+// config files, where the options of later files override the ones of earlier
+// files. Files which do not exist are skipped. The options of the selected
+// context override the files, and the CRICTL_* environment variables override
+// both.
+func LoadServerConfig(configFileNames []string, contextName string) (*ServerConfiguration, error) {
+	return loadServerConfig(configFileNames, true, contextName, os.LookupEnv)
+}
+
+// ValidateConfigFiles validates the layered config files, the selected
+// context and the CRICTL_* environment variables. All found problems are
+// reported in the returned error.
+func ValidateConfigFiles(configFileNames []string, contextName string) error {
+	_, err := loadServerConfig(configFileNames, true, contextName, os.LookupEnv)
+
+	return err
+}
+
+// loadServerConfig merges the config files, applies the selected context if
+// useContexts is set and the environment variables if lookupEnv is set.
+// Invalid files, contexts and environment variables are skipped and all of
+// their errors are returned together.
+func loadServerConfig(configFileNames []string, useContexts bool, contextName string, lookupEnv func(string) (string, bool)) (*ServerConfiguration, error) {
+	config := &Config{}
+	sources := map[string]string{}
+
+	var errs []error
+
+	for _, file := range configFileNames {
+		layer, err := ReadConfig(file)
+		if errors.Is(err, os.ErrNotExist) {
+			logrus.Debugf("Skipping config file %q: %v", file, err)
+
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load config file %s: %w", file, err))
+
+			continue
+		}
+
+		mergeConfig(config, layer, file, sources)
+	}
+
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+
+	var ctx *Context
+
+	if useContexts && contextName != "" {
+		var err error
+
+		ctx, err = config.GetContext(contextName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("select context: %w", err))
+		} else {
+			applyContext(config, ctx, sources)
+		}
 	}
 
-	// Set the config from file to the server config struct for return
-	serverConfig.RuntimeEndpoint = config.RuntimeEndpoint
-	serverConfig.ImageEndpoint = config.ImageEndpoint
-	serverConfig.Timeout = time.Duration(config.Timeout) * time.Second
-	serverConfig.Debug = config.Debug
-	serverConfig.PullImageOnCreate = config.PullImageOnCreate
-	serverConfig.DisablePullOnRun = config.DisablePullOnRun
-	serverConfig.MaxRetries = config.MaxRetries
+	if lookupEnv != nil {
+		for _, option := range ConfigOptions {
+			if option.Env == "" {
+				continue
+			}
 
-	return &serverConfig, nil
+			value, ok := lookupEnv(option.Env)
+			if !ok {
+				continue
+			}
+
+			if err := option.Set(config, value); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %w", option.Env, err))
+
+				continue
+			}
+
+			sources[option.Key] = "env " + option.Env
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	serverConfig := &ServerConfiguration{
+		RuntimeEndpoint:        config.RuntimeEndpoint,
+		ImageEndpoint:          config.ImageEndpoint,
+		Timeout:                config.Timeout,
+		Debug:                  config.Debug,
+		PullImageOnCreate:      config.PullImageOnCreate,
+		DisablePullOnRun:       config.DisablePullOnRun,
+		MaxRetries:             config.MaxRetries,
+		RPCRetries:             config.RPCRetries,
+		TLSCA:                  config.TLSCA,
+		TLSCert:                config.TLSCert,
+		TLSKey:                 config.TLSKey,
+		TLSSNI:                 config.TLSSNI,
+		ReadOnly:               config.ReadOnly,
+		ReadOnlyAllowStreaming: config.ReadOnlyAllowStreaming,
+		AuditLog:               config.AuditLog,
+		Sources:                sources,
+	}
+
+	if ctx != nil {
+		serverConfig.Context = ctx.Name
+		serverConfig.RuntimeHandler = ctx.RuntimeHandler
+		serverConfig.Output = ctx.Output
+	}
+
+	return serverConfig, nil
+}
+
+// mergeConfig sets all options defined in the layer on the config, except
+// the options which cannot be overridden once set. Contexts of the layer
+// replace the contexts with the same name.
+func mergeConfig(config, layer *Config, source string, sources map[string]string) {
+	for _, option := range ConfigOptions {
+		if !layer.HasOption(option.Key) {
+			continue
+		}
+
+		if option.noOverride && option.IsSet(config) {
+			if option.Get(layer) != option.Get(config) {
+				logrus.Warnf("Ignoring option '%s' of %s, which cannot override the value of %s", option.Key, source, sources[option.Key])
+			}
+
+			continue
+		}
+
+		// The value has been validated when reading the layer.
+		_ = option.Set(config, option.Get(layer))
+
+		sources[option.Key] = source
+	}
+
+	for _, ctx := range layer.Contexts {
+		if i := slices.IndexFunc(config.Contexts, func(c *Context) bool { return c.Name == ctx.Name }); i >= 0 {
+			config.Contexts[i] = ctx
+
+			continue
+		}
+
+		config.Contexts = append(config.Contexts, ctx)
+	}
+}
+
+// applyContext overrides the config with all options set in the context.
+func applyContext(config *Config, ctx *Context, sources map[string]string) {
+	source := fmt.Sprintf("context %q", ctx.Name)
+
+	for _, option := range ContextOptions {
+		if option.Key == ContextName || !option.IsSet(ctx) {
+			continue
+		}
+
+		// Options like the runtime endpoint exist in both schemas and have
+		// been validated when reading the context.
+		if configOption, err := LookupConfigOption(option.Key); err == nil {
+			_ = configOption.Set(config, option.Get(ctx))
+		}
+
+		sources[option.Key] = source
+	}
+
+	// Do not mix the image endpoint of the top level options with the
+	// runtime endpoint of the context.
+	if ctx.RuntimeEndpoint != "" && ctx.ImageEndpoint == "" {
+		config.ImageEndpoint = ""
+		sources[ImageEndpoint] = source
+	}
 }
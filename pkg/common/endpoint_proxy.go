@@ -0,0 +1,280 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// EndpointSchemeTCP is the scheme of plain text remote CRI endpoints.
+	EndpointSchemeTCP = "tcp"
+
+	// EndpointSchemeTLS is the scheme of remote CRI endpoints secured by TLS.
+	EndpointSchemeTLS = "tls"
+
+	// endpointDialTimeout is the timeout for connecting to a remote endpoint.
+	endpointDialTimeout = 10 * time.Second
+
+	// endpointCheckTimeout is the time to wait for a rejection of the client
+	// certificate after the TLS handshake.
+	endpointCheckTimeout = 250 * time.Millisecond
+)
+
+// EndpointTLSConfig are the client TLS settings for tls:// endpoints.
+type EndpointTLSConfig struct {
+	// CAFile is the CA certificate used to verify the server. The system
+	// certificate pool is used if empty.
+	CAFile string
+	// CertFile and KeyFile are the client certificate and key for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// ServerName is used to verify the server certificate. The host of the
+	// endpoint is used if empty.
+	ServerName string
+}
+
+// IsRemoteEndpoint returns true if the endpoint uses the tcp:// or tls://
+// scheme.
+func IsRemoteEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, EndpointSchemeTCP+"://") ||
+		strings.HasPrefix(endpoint, EndpointSchemeTLS+"://")
+}
+
+// EndpointProxy forwards the connections of a local unix socket to a tcp://
+// or tls:// endpoint. It is required because the CRI client is only able to
+// dial local sockets.
+type EndpointProxy struct {
+	// Endpoint is the local unix:// endpoint to be used by the CRI client.
+	Endpoint string
+
+	remote   string
+	dir      string
+	listener net.Listener
+	dial     func(ctx context.Context) (net.Conn, error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewEndpointProxy starts forwarding a new local socket to the remote
+// endpoint. The remote endpoint is dialed once to report connection and TLS
+// handshake errors early, which would otherwise only be visible as closed
+// connections to the CRI client.
+func NewEndpointProxy(ctx context.Context, endpoint string, tlsConfig *EndpointTLSConfig) (*EndpointProxy, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint: %w", err)
+	}
+
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		return nil, fmt.Errorf("endpoint %q must be of the form %s://host:port: %w", endpoint, u.Scheme, err)
+	}
+
+	dialer := &net.Dialer{Timeout: endpointDialTimeout}
+	p := &EndpointProxy{remote: endpoint}
+
+	switch u.Scheme {
+	case EndpointSchemeTCP:
+		p.dial = func(ctx context.Context) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", u.Host)
+		}
+	case EndpointSchemeTLS:
+		config, err := ClientTLSConfig(u.Hostname(), tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: config}
+		p.dial = func(ctx context.Context) (net.Conn, error) {
+			return tlsDialer.DialContext(ctx, "tcp", u.Host)
+		}
+	default:
+		return nil, fmt.Errorf("endpoint %q is not a %s:// or %s:// endpoint", endpoint, EndpointSchemeTCP, EndpointSchemeTLS)
+	}
+
+	if err := p.check(ctx); err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", endpoint, err)
+	}
+
+	p.dir, err = os.MkdirTemp("", "crictl-endpoint-")
+	if err != nil {
+		return nil, fmt.Errorf("create socket directory: %w", err)
+	}
+
+	socket := filepath.Join(p.dir, "cri.sock")
+
+	p.listener, err = (&net.ListenConfig{}).Listen(ctx, "unix", socket)
+	if err != nil {
+		_ = os.RemoveAll(p.dir)
+
+		return nil, fmt.Errorf("listen on %s: %w", socket, err)
+	}
+
+	p.Endpoint = "unix://" + socket
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	p.wg.Go(p.serve)
+
+	logrus.Debugf("Forwarding %s to %s", p.Endpoint, endpoint)
+
+	return p, nil
+}
+
+// Close stops forwarding and removes the local socket.
+func (p *EndpointProxy) Close() error {
+	p.cancel()
+	err := p.listener.Close()
+
+	p.wg.Wait()
+
+	return errors.Join(err, os.RemoveAll(p.dir))
+}
+
+// check dials the remote endpoint once. With TLS 1.3 the server verifies the
+// client certificate after the client completed the handshake, which is why
+// the first read is awaited shortly to see a possible rejection.
+func (p *EndpointProxy) check(ctx context.Context) error {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, ok := conn.(*tls.Conn); !ok {
+		return nil
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(endpointCheckTimeout)); err != nil {
+		return fmt.Errorf("set read deadline: %w", err)
+	}
+
+	var netErr net.Error
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) &&
+		(!errors.As(err, &netErr) || !netErr.Timeout()) {
+		return err
+	}
+
+	return nil
+}
+
+func (p *EndpointProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			if p.ctx.Err() == nil {
+				logrus.Errorf("Unable to accept connection for %s: %v", p.remote, err)
+			}
+
+			return
+		}
+
+		p.wg.Go(func() { p.forward(conn) })
+	}
+}
+
+// forward copies the data between the local and the remote connection until
+// one of them is closed or the proxy gets closed.
+func (p *EndpointProxy) forward(local net.Conn) {
+	defer local.Close()
+
+	remote, err := p.dial(p.ctx)
+	if err != nil {
+		logrus.Errorf("Unable to connect to %s: %v", p.remote, err)
+
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+
+	copyConn := func(dst, src net.Conn) {
+		if _, err := io.Copy(dst, src); err != nil && p.ctx.Err() == nil {
+			logrus.Debugf("Forwarding %s: %v", p.remote, err)
+		}
+
+		done <- struct{}{}
+	}
+
+	go copyConn(remote, local)
+	go copyConn(local, remote)
+
+	select {
+	case <-done:
+	case <-p.ctx.Done():
+	}
+}
+
+// ClientTLSConfig returns the client TLS configuration for connecting to the
+// host. gRPC requires HTTP/2 to be negotiated via ALPN.
+func ClientTLSConfig(host string, config *EndpointTLSConfig) (*tls.Config, error) {
+	if config == nil {
+		config = &EndpointTLSConfig{}
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2"},
+		ServerName: config.ServerName,
+	}
+
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	if config.CAFile != "" {
+		ca, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read TLS CA certificate: %w", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in TLS CA certificate %s", config.CAFile)
+		}
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("both TLS certificate and key are required for client authentication")
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,269 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	remote "k8s.io/cri-client/pkg"
+
+	"sigs.k8s.io/cri-tools/pkg/common"
+)
+
+type fakeRuntimeServer struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+}
+
+func (*fakeRuntimeServer) Version(context.Context, *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       "fake",
+		RuntimeVersion:    "0.1.0",
+		RuntimeApiVersion: "v1",
+	}, nil
+}
+
+// testCertificates are the PEM files of a CA, a server and a client
+// certificate signed by the CA.
+type testCertificates struct {
+	dir                   string
+	ca                    *x509.Certificate
+	caKey                 *ecdsa.PrivateKey
+	caFile                string
+	serverCert, serverKey string
+	clientCert, clientKey string
+}
+
+func newTestCertificates() *testCertificates {
+	certs := &testCertificates{dir: GinkgoT().TempDir()}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "crictl test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	certs.ca, err = x509.ParseCertificate(caDER)
+	Expect(err).NotTo(HaveOccurred())
+
+	certs.caKey = caKey
+	certs.caFile = certs.writePEM("ca.crt", "CERTIFICATE", caDER)
+	certs.serverCert, certs.serverKey = certs.issue("server", x509.ExtKeyUsageServerAuth)
+	certs.clientCert, certs.clientKey = certs.issue("client", x509.ExtKeyUsageClientAuth)
+
+	return certs
+}
+
+func (c *testCertificates) issue(name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, c.ca, &key.PublicKey, c.caKey)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return c.writePEM(name+".crt", "CERTIFICATE", der), c.writePEM(name+".key", "PRIVATE KEY", keyDER)
+}
+
+func (c *testCertificates) writePEM(name, typ string, der []byte) string {
+	path := filepath.Join(c.dir, name)
+	Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600)).To(Succeed())
+
+	return path
+}
+
+// startRuntimeServer serves the fake runtime on a local TCP port and returns
+// its address.
+func startRuntimeServer(opts ...grpc.ServerOption) string {
+	listener, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	server := grpc.NewServer(opts...)
+	runtimeapi.RegisterRuntimeServiceServer(server, &fakeRuntimeServer{})
+
+	go func() { _ = server.Serve(listener) }()
+
+	DeferCleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+// runtimeVersion queries the runtime version through a proxy for the endpoint.
+func runtimeVersion(endpoint string, tlsConfig *common.EndpointTLSConfig) (string, error) {
+	ctx := context.Background()
+
+	proxy, err := common.NewEndpointProxy(ctx, endpoint, tlsConfig)
+	if err != nil {
+		return "", err
+	}
+
+	DeferCleanup(proxy.Close)
+
+	service, err := remote.NewRemoteRuntimeService(ctx, proxy.Endpoint, 5*time.Second, nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := service.Version(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	return version.GetRuntimeName(), nil
+}
+
+var _ = Describe("EndpointProxy", func() {
+	It("should forward tcp:// endpoints", func() {
+		addr := startRuntimeServer()
+
+		name, err := runtimeVersion("tcp://"+addr, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("fake"))
+	})
+
+	It("should fail to connect to a closed port", func() {
+		listener, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		addr := listener.Addr().String()
+		Expect(listener.Close()).To(Succeed())
+
+		_, err = common.NewEndpointProxy(context.Background(), "tcp://"+addr, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unsupported endpoints", func() {
+		_, err := common.NewEndpointProxy(context.Background(), "unix:///run/containerd/containerd.sock", nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("with mutual TLS", func() {
+		var (
+			certs *testCertificates
+			addr  string
+		)
+
+		BeforeEach(func() {
+			certs = newTestCertificates()
+
+			serverCert, err := tls.LoadX509KeyPair(certs.serverCert, certs.serverKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			clientCAs := x509.NewCertPool()
+			clientCAs.AddCert(certs.ca)
+
+			addr = startRuntimeServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{serverCert},
+				ClientCAs:    clientCAs,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+			})))
+		})
+
+		It("should connect with a client certificate", func() {
+			name, err := runtimeVersion("tls://"+addr, &common.EndpointTLSConfig{
+				CAFile:   certs.caFile,
+				CertFile: certs.clientCert,
+				KeyFile:  certs.clientKey,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("fake"))
+		})
+
+		It("should verify the server name", func() {
+			_, port, err := net.SplitHostPort(addr)
+			Expect(err).NotTo(HaveOccurred())
+
+			tlsConfig := &common.EndpointTLSConfig{
+				CAFile:   certs.caFile,
+				CertFile: certs.clientCert,
+				KeyFile:  certs.clientKey,
+			}
+
+			tlsConfig.ServerName = "localhost"
+			name, err := runtimeVersion("tls://127.0.0.1:"+port, tlsConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("fake"))
+
+			tlsConfig.ServerName = "other.example.com"
+			_, err = runtimeVersion("tls://127.0.0.1:"+port, tlsConfig)
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		It("should fail without a client certificate", func() {
+			_, err := runtimeVersion("tls://"+addr, &common.EndpointTLSConfig{CAFile: certs.caFile})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail with an unknown CA", func() {
+			_, err := runtimeVersion("tls://"+addr, &common.EndpointTLSConfig{
+				CAFile:   newTestCertificates().caFile,
+				CertFile: certs.clientCert,
+				KeyFile:  certs.clientKey,
+			})
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		It("should fail with a certificate but no key", func() {
+			_, err := runtimeVersion("tls://"+addr, &common.EndpointTLSConfig{
+				CAFile:   certs.caFile,
+				CertFile: certs.clientCert,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -17,10 +17,11 @@ limitations under the License.
 package common
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	gofilepath "path/filepath"
-	"strconv"
+	"time"
 
 	yaml "sigs.k8s.io/yaml/goyaml.v3"
 )
@@ -28,14 +29,61 @@ import (
 // Config is the internal representation of the yaml that defines
 // server configuration.
 type Config struct {
-	RuntimeEndpoint   string
-	ImageEndpoint     string
-	Timeout           int
-	Debug             bool
-	PullImageOnCreate bool
-	DisablePullOnRun  bool
-	MaxRetries        int
-	yamlData          *yaml.Node // YAML representation of config
+	RuntimeEndpoint        string
+	ImageEndpoint          string
+	Timeout                time.Duration
+	Debug                  bool
+	PullImageOnCreate      bool
+	DisablePullOnRun       bool
+	MaxRetries             int
+	RPCRetries             int
+	TLSCA                  string
+	TLSCert                string
+	TLSKey                 string
+	TLSSNI                 string
+	ReadOnly               bool
+	ReadOnlyAllowStreaming bool
+	AuditLog               string
+	CurrentContext         string
+	Contexts               []*Context
+	yamlData               *yaml.Node // YAML representation of config
+}
+
+// Context is a named set of connection and default options, which override
+// the top level options of the config if selected.
+type Context struct {
+	Name            string
+	RuntimeEndpoint string
+	ImageEndpoint   string
+	Timeout         time.Duration
+	TLSCA           string
+	TLSCert         string
+	TLSKey          string
+	TLSSNI          string
+	RuntimeHandler  string
+	Output          string
+	// ReadOnly is nil if not set, so that a context can disable the
+	// read-only mode of the top level options.
+	ReadOnly *bool
+}
+
+// ErrContextNotFound is returned if a context does not exist in the config.
+var ErrContextNotFound = errors.New("context not found")
+
+// GetContext returns the context for the provided name.
+func (c *Config) GetContext(name string) (*Context, error) {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrContextNotFound, name)
+}
+
+// HasOption returns true if the option is defined in the config file.
+func (c *Config) HasOption(key string) bool {
+	return c.yamlData != nil && hasConfigOption(key, c.yamlData)
 }
 
 const (
@@ -59,6 +107,46 @@ const (
 
 	// MaxRetries is the YAML key for the max retries config option.
 	MaxRetries = "max-retries"
+
+	// RPCRetries is the YAML key for the retries of failed idempotent RPCs.
+	RPCRetries = "rpc-retries"
+
+	// ReadOnly is the YAML key for rejecting all mutating RPCs.
+	ReadOnly = "read-only"
+
+	// ReadOnlyAllowStreaming is the YAML key for allowing exec, attach and
+	// port-forward in read-only mode.
+	ReadOnlyAllowStreaming = "read-only-allow-streaming"
+
+	// AuditLog is the YAML key for the path of the audit log.
+	AuditLog = "audit-log"
+
+	// CurrentContext is the YAML key for the selected context.
+	CurrentContext = "current-context"
+
+	// Contexts is the YAML key for the list of named contexts.
+	Contexts = "contexts"
+
+	// ContextName is the YAML key for the name of a context.
+	ContextName = "name"
+
+	// TLSCA is the YAML key for the TLS CA certificate of tls:// endpoints and streaming.
+	TLSCA = "tls-ca"
+
+	// TLSCert is the YAML key for the TLS certificate of tls:// endpoints and streaming.
+	TLSCert = "tls-cert"
+
+	// TLSKey is the YAML key for the TLS key of tls:// endpoints and streaming.
+	TLSKey = "tls-key"
+
+	// TLSSNI is the YAML key for the TLS server name of tls:// endpoints and streaming.
+	TLSSNI = "tls-sni"
+
+	// RuntimeHandler is the YAML key for the default runtime handler of a context.
+	RuntimeHandler = "runtime-handler"
+
+	// Output is the YAML key for the default output format of a context.
+	Output = "output"
 )
 
 // ReadConfig reads from a file with the given name and returns a config or
@@ -109,7 +197,8 @@ func WriteConfig(c *Config, filepath string) error {
 	return os.WriteFile(filepath, data, 0o600)
 }
 
-// Extracts config options from the yaml data which is loaded from file.
+// Extracts config options from the yaml data which is loaded from file. All
+// invalid options are reported in the returned error.
 func getConfigOptions(yamlData *yaml.Node) (*Config, error) {
 	config := &Config{yamlData: yamlData}
 
@@ -120,70 +209,174 @@ func getConfigOptions(yamlData *yaml.Node) (*Config, error) {
 
 	contentLen := len(yamlData.Content[0].Content)
 
+	var errs []error
+
 	// YAML representation contains 2 yaml ScalarNodes per config option.
 	// One is config option name and other is the value of the option
 	// These ScalarNodes help preserve comments associated with
 	// the YAML entry
-	for index := 0; index < contentLen-1; {
-		configOption := yamlData.Content[0].Content[index]
-		name := configOption.Value
-		value := yamlData.Content[0].Content[index+1].Value
-
-		var err error
-
-		switch name {
-		case RuntimeEndpoint:
-			config.RuntimeEndpoint = value
-		case ImageEndpoint:
-			config.ImageEndpoint = value
-		case Timeout:
-			config.Timeout, err = strconv.Atoi(value)
-			if err != nil {
-				return nil, fmt.Errorf("parsing config option '%s': %w", name, err)
-			}
-		case Debug:
-			config.Debug, err = strconv.ParseBool(value)
-			if err != nil {
-				return nil, fmt.Errorf("parsing config option '%s': %w", name, err)
-			}
-		case PullImageOnCreate:
-			config.PullImageOnCreate, err = strconv.ParseBool(value)
+	for index := 0; index < contentLen-1; index += 2 {
+		name := yamlData.Content[0].Content[index].Value
+		valueNode := yamlData.Content[0].Content[index+1]
+
+		if name == Contexts {
+			contexts, err := getContexts(valueNode)
 			if err != nil {
-				return nil, fmt.Errorf("parsing config option '%s': %w", name, err)
+				errs = append(errs, fmt.Errorf("parsing config option '%s': %w", name, err))
 			}
-		case DisablePullOnRun:
-			config.DisablePullOnRun, err = strconv.ParseBool(value)
+
+			config.Contexts = contexts
+
+			continue
+		}
+
+		option, err := LookupConfigOption(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Config option '%s' is not valid", name))
+
+			continue
+		}
+
+		if err := option.Set(config, valueNode.Value); err != nil {
+			errs = append(errs, fmt.Errorf("parsing config option '%s': %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return config, nil
+}
+
+// Extracts the contexts from the yaml sequence node.
+func getContexts(node *yaml.Node) ([]*Context, error) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil, nil
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.New("expected a list of contexts")
+	}
+
+	contexts := make([]*Context, 0, len(node.Content))
+	names := map[string]bool{}
+
+	var errs []error
+
+	for i, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("context %d: expected a map", i))
+
+			continue
+		}
+
+		ctx := &Context{}
+
+		for index := 0; index < len(item.Content)-1; index += 2 {
+			key := item.Content[index].Value
+
+			option, err := lookupOption(ContextOptions, key)
 			if err != nil {
-				return nil, fmt.Errorf("parsing config option '%s': %w", name, err)
+				errs = append(errs, fmt.Errorf("context %d: context option '%s' is not valid", i, key))
+
+				continue
 			}
-		case MaxRetries:
-			config.MaxRetries, err = strconv.Atoi(value)
-			if err != nil {
-				return nil, fmt.Errorf("parsing config option '%s': %w", name, err)
+
+			if err := option.Set(ctx, item.Content[index+1].Value); err != nil {
+				errs = append(errs, fmt.Errorf("context %d: %w", i, err))
 			}
-		default:
-			return nil, fmt.Errorf("Config option '%s' is not valid", name)
 		}
 
-		index += 2
+		switch {
+		case ctx.Name == "":
+			errs = append(errs, fmt.Errorf("context %d: context name must not be empty", i))
+		case names[ctx.Name]:
+			errs = append(errs, fmt.Errorf("context %q is defined multiple times", ctx.Name))
+		}
+
+		names[ctx.Name] = true
+		contexts = append(contexts, ctx)
 	}
 
-	return config, nil
+	return contexts, errors.Join(errs...)
 }
 
 // Set config options on yaml data for persistece to file.
 func setConfigOptions(config *Config) {
-	setConfigOption(RuntimeEndpoint, config.RuntimeEndpoint, config.yamlData)
-	setConfigOption(ImageEndpoint, config.ImageEndpoint, config.yamlData)
-	setConfigOption(Timeout, strconv.Itoa(config.Timeout), config.yamlData)
-	setConfigOption(Debug, strconv.FormatBool(config.Debug), config.yamlData)
-	setConfigOption(PullImageOnCreate, strconv.FormatBool(config.PullImageOnCreate), config.yamlData)
-	setConfigOption(DisablePullOnRun, strconv.FormatBool(config.DisablePullOnRun), config.yamlData)
-	setConfigOption(MaxRetries, strconv.Itoa(config.MaxRetries), config.yamlData)
+	for _, option := range ConfigOptions {
+		// Keep flat configs unchanged if no contexts are used.
+		if option.omitEmpty && !option.IsSet(config) && !hasConfigOption(option.Key, config.yamlData) {
+			continue
+		}
+
+		value := option.Get(config)
+		setConfigOption(option.Key, value, option.yamlTag(value), config.yamlData)
+	}
+
+	if len(config.Contexts) > 0 || hasConfigOption(Contexts, config.yamlData) {
+		setContextsOption(config.Contexts, config.yamlData)
+	}
+}
+
+// Returns true if the config option is already set on the yaml.
+func hasConfigOption(configName string, yamlData *yaml.Node) bool {
+	if len(yamlData.Content) == 0 || yamlData.Content[0].Content == nil {
+		return false
+	}
+
+	content := yamlData.Content[0].Content
+	for index := 0; index < len(content)-1; index += 2 {
+		if content[index].Value == configName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Set the contexts on yaml, replacing all existing context entries.
+func setContextsOption(contexts []*Context, yamlData *yaml.Node) {
+	value := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+	for _, ctx := range contexts {
+		item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+		for _, option := range ContextOptions {
+			if option.Key != ContextName && !option.IsSet(ctx) {
+				continue
+			}
+
+			v := option.Get(ctx)
+			item.Content = append(item.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: option.Key},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: option.yamlTag(v), Value: v},
+			)
+		}
+
+		value.Content = append(value.Content, item)
+	}
+
+	if !hasConfigOption(Contexts, yamlData) {
+		setConfigOption(Contexts, "", "!!str", yamlData)
+	}
+
+	content := yamlData.Content[0].Content
+	for index := 0; index < len(content)-1; index += 2 {
+		if content[index].Value != Contexts {
+			continue
+		}
+
+		existing := content[index+1]
+		value.HeadComment = existing.HeadComment
+		value.LineComment = existing.LineComment
+		value.FootComment = existing.FootComment
+		*existing = *value
+	}
 }
 
 // Set config option on yaml.
-func setConfigOption(configName, configValue string, yamlData *yaml.Node) {
+func setConfigOption(configName, configValue, tag string, yamlData *yaml.Node) {
 	if len(yamlData.Content) == 0 {
 		yamlData.Kind = yaml.DocumentNode
 		yamlData.Content = make([]*yaml.Node, 1)
@@ -205,7 +398,13 @@ func setConfigOption(configName, configValue string, yamlData *yaml.Node) {
 		name := yamlData.Content[0].Content[index].Value
 		if name == configName {
 			// Set the value, even if we have the option defined multiple times.
-			yamlData.Content[0].Content[index+1].Value = configValue
+			node := yamlData.Content[0].Content[index+1]
+			node.Value = configValue
+
+			if node.Tag != tag && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 {
+				node.Tag = tag
+			}
+
 			foundOption = true
 		}
 
@@ -218,38 +417,16 @@ func setConfigOption(configName, configValue string, yamlData *yaml.Node) {
 	// These ScalarNodes help preserve comments associated with
 	// the YAML entry
 	if !foundOption {
-		const (
-			tagPrefix = "!!"
-			tagStr    = tagPrefix + "str"
-			tagBool   = tagPrefix + "bool"
-			tagInt    = tagPrefix + "int"
-		)
-
 		name := &yaml.Node{
 			Kind:  yaml.ScalarNode,
 			Value: configName,
-			Tag:   tagStr,
-		}
-
-		var tagType string
-
-		switch configName {
-		case Timeout, MaxRetries:
-			tagType = tagInt
-		case Debug:
-			tagType = tagBool
-		case PullImageOnCreate:
-			tagType = tagBool
-		case DisablePullOnRun:
-			tagType = tagBool
-		default:
-			tagType = tagStr
+			Tag:   "!!str",
 		}
 
 		value := &yaml.Node{
 			Kind:  yaml.ScalarNode,
 			Value: configValue,
-			Tag:   tagType,
+			Tag:   tag,
 		}
 		yamlData.Content[0].Content = append(yamlData.Content[0].Content, name, value)
 	}
@@ -18,6 +18,7 @@ package common_test
 
 import (
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -51,6 +52,12 @@ var _ = DescribeTable("ReadConfig",
 		Expect(readConfig.PullImageOnCreate).To(Equal(expectedConfig.PullImageOnCreate))
 		Expect(readConfig.DisablePullOnRun).To(Equal(expectedConfig.DisablePullOnRun))
 		Expect(readConfig.MaxRetries).To(Equal(expectedConfig.MaxRetries))
+		Expect(readConfig.TLSCA).To(Equal(expectedConfig.TLSCA))
+		Expect(readConfig.TLSCert).To(Equal(expectedConfig.TLSCert))
+		Expect(readConfig.TLSKey).To(Equal(expectedConfig.TLSKey))
+		Expect(readConfig.TLSSNI).To(Equal(expectedConfig.TLSSNI))
+		Expect(readConfig.CurrentContext).To(Equal(expectedConfig.CurrentContext))
+		Expect(readConfig.Contexts).To(Equal(expectedConfig.Contexts))
 	},
 
 	Entry("should succeed with valid config", `
@@ -64,7 +71,7 @@ max-retries: 3
 `, &common.Config{
 		RuntimeEndpoint:   "foo",
 		ImageEndpoint:     "bar",
-		Timeout:           10,
+		Timeout:           10 * time.Second,
 		Debug:             true,
 		PullImageOnCreate: true,
 		DisablePullOnRun:  true,
@@ -109,7 +116,7 @@ timeout: 20
 `, &common.Config{
 		RuntimeEndpoint:   "bar",
 		ImageEndpoint:     "bar",
-		Timeout:           20,
+		Timeout:           20 * time.Second,
 		Debug:             false,
 		PullImageOnCreate: false,
 		DisablePullOnRun:  false,
@@ -124,7 +131,66 @@ timeout: 20
 		DisablePullOnRun:  false,
 	}, false),
 
+	Entry("should succeed with contexts", `
+runtime-endpoint: "foo"
+current-context: remote
+contexts:
+  - name: local
+    runtime-endpoint: unix:///run/containerd/containerd.sock
+  - name: remote # Comment
+    runtime-endpoint: unix:///run/crio/crio.sock
+    image-endpoint: unix:///run/crio/image.sock
+    timeout: 5
+    tls-sni: node
+    runtime-handler: kata
+    output: json
+`, &common.Config{
+		RuntimeEndpoint: "foo",
+		CurrentContext:  "remote",
+		Contexts: []*common.Context{
+			{Name: "local", RuntimeEndpoint: "unix:///run/containerd/containerd.sock"},
+			{
+				Name:            "remote",
+				RuntimeEndpoint: "unix:///run/crio/crio.sock",
+				ImageEndpoint:   "unix:///run/crio/image.sock",
+				Timeout:         5 * time.Second,
+				TLSSNI:          "node",
+				RuntimeHandler:  "kata",
+				Output:          "json",
+			},
+		},
+	}, false),
+
+	Entry("should succeed with duration timeout", `timeout: 1m30s`, &common.Config{
+		Timeout: 90 * time.Second,
+	}, false),
+
+	Entry("should succeed with remote endpoints and TLS", `
+runtime-endpoint: tls://node.example.com:10010
+image-endpoint: tcp://127.0.0.1:10011
+tls-ca: /etc/crictl/ca.crt
+tls-cert: /etc/crictl/client.crt
+tls-key: /etc/crictl/client.key
+tls-sni: node
+`, &common.Config{
+		RuntimeEndpoint: "tls://node.example.com:10010",
+		ImageEndpoint:   "tcp://127.0.0.1:10011",
+		TLSCA:           "/etc/crictl/ca.crt",
+		TLSCert:         "/etc/crictl/client.crt",
+		TLSKey:          "/etc/crictl/client.key",
+		TLSSNI:          "node",
+	}, false),
+
 	Entry("should fail with invalid config option", `runtime-endpoint-wrong: "foo"`, nil, true),
+	Entry("should fail with remote endpoint without port", `runtime-endpoint: "tls://node.example.com"`, nil, true),
+	Entry("should fail with remote endpoint with path", `runtime-endpoint: "tcp://node:10010/foo"`, nil, true),
+	Entry("should fail with invalid endpoint scheme", `runtime-endpoint: "http://foo"`, nil, true),
+	Entry("should fail with empty endpoint path", `image-endpoint: "unix://"`, nil, true),
+	Entry("should fail with invalid context output", "contexts:\n  - name: foo\n    output: xml", nil, true),
+	Entry("should fail with invalid context option", "contexts:\n  - name: foo\n    debug: true", nil, true),
+	Entry("should fail with unnamed context", "contexts:\n  - runtime-endpoint: foo", nil, true),
+	Entry("should fail with duplicate context", "contexts:\n  - name: foo\n  - name: foo", nil, true),
+	Entry("should fail with invalid 'contexts' value", `contexts: "foo"`, nil, true),
 	Entry("should fail with invalid 'timeout' value", `timeout: "foo"`, nil, true),
 	Entry("should fail with invalid 'debug' value", `debug: "foo"`, nil, true),
 	Entry("should fail with invalid 'pull-image-on-create' value", `pull-image-on-create: "foo"`, nil, true),
@@ -156,17 +222,253 @@ var _ = DescribeTable("WriteConfig",
 		Expect(readConfig.PullImageOnCreate).To(Equal(config.PullImageOnCreate))
 		Expect(readConfig.DisablePullOnRun).To(Equal(config.DisablePullOnRun))
 		Expect(readConfig.MaxRetries).To(Equal(config.MaxRetries))
+		Expect(readConfig.CurrentContext).To(Equal(config.CurrentContext))
+		Expect(readConfig.Contexts).To(Equal(config.Contexts))
 	},
 
 	Entry("should succeed with config", &common.Config{
 		RuntimeEndpoint:   "foo",
 		ImageEndpoint:     "bar",
-		Timeout:           10,
+		Timeout:           10 * time.Second,
 		Debug:             true,
 		PullImageOnCreate: true,
 		DisablePullOnRun:  true,
 		MaxRetries:        5,
 	}),
 
+	Entry("should succeed with contexts", &common.Config{
+		RuntimeEndpoint: "foo",
+		CurrentContext:  "remote",
+		Contexts: []*common.Context{
+			{Name: "local", RuntimeEndpoint: "bar"},
+			{Name: "remote", RuntimeEndpoint: "baz", Timeout: 5 * time.Second, Output: "yaml"},
+		},
+	}),
+
+	Entry("should succeed with sub second timeout", &common.Config{
+		Timeout: 1500 * time.Millisecond,
+	}),
+
 	Entry("should succeed with nil config", nil),
 )
+
+var _ = Describe("LoadServerConfig", func() {
+	writeConfig := func(content string) string {
+		f, err := os.CreateTemp("", "crictl-layered-config-")
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(os.RemoveAll, f.Name())
+
+		_, err = f.WriteString(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+
+		return f.Name()
+	}
+
+	It("should layer the files, context and environment", func() {
+		system := writeConfig(`
+runtime-endpoint: unix:///run/system.sock
+timeout: 10
+debug: true
+contexts:
+  - name: remote
+    runtime-endpoint: unix:///run/remote.sock
+`)
+		user := writeConfig(`
+timeout: 20s
+current-context: remote
+`)
+		GinkgoT().Setenv("CRICTL_DEBUG", "false")
+
+		config, err := common.LoadServerConfig([]string{system, "/does/not/exist", user}, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.Context).To(Equal("remote"))
+		Expect(config.RuntimeEndpoint).To(Equal("unix:///run/remote.sock"))
+		Expect(config.Timeout).To(Equal(20 * time.Second))
+		Expect(config.Debug).To(BeFalse())
+		Expect(config.Sources).To(Equal(map[string]string{
+			common.RuntimeEndpoint: `context "remote"`,
+			common.ImageEndpoint:   `context "remote"`,
+			common.Timeout:         user,
+			common.Debug:           "env CRICTL_DEBUG",
+			common.CurrentContext:  user,
+		}))
+	})
+
+	It("should enable read-only mode by context or environment", func() {
+		file := writeConfig(`
+read-only-allow-streaming: true
+current-context: production
+contexts:
+  - name: production
+    read-only: true
+  - name: staging
+`)
+
+		config, err := common.LoadServerConfig([]string{file}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ReadOnly).To(BeTrue())
+		Expect(config.ReadOnlyAllowStreaming).To(BeTrue())
+		Expect(config.Sources).To(HaveKeyWithValue(common.ReadOnly, `context "production"`))
+
+		config, err = common.LoadServerConfig([]string{file}, "staging")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ReadOnly).To(BeFalse())
+
+		GinkgoT().Setenv("CRICTL_READ_ONLY", "true")
+
+		config, err = common.LoadServerConfig([]string{file}, "staging")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ReadOnly).To(BeTrue())
+		Expect(config.Sources).To(HaveKeyWithValue(common.ReadOnly, "env CRICTL_READ_ONLY"))
+	})
+
+	It("should disable read-only mode by context", func() {
+		file := writeConfig(`
+read-only: true
+contexts:
+  - name: maintenance
+    read-only: false
+`)
+
+		config, err := common.LoadServerConfig([]string{file}, "maintenance")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.ReadOnly).To(BeFalse())
+		Expect(config.Sources).To(HaveKeyWithValue(common.ReadOnly, `context "maintenance"`))
+
+		// The option is kept when writing the config.
+		c, err := common.ReadConfig(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(common.WriteConfig(c, file)).To(Succeed())
+
+		data, err := os.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring("read-only: false"))
+	})
+
+	It("should read the audit log path", func() {
+		file := writeConfig(`
+audit-log: /var/log/crictl-audit.log
+`)
+
+		config, err := common.LoadServerConfig([]string{file}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.AuditLog).To(Equal("/var/log/crictl-audit.log"))
+		Expect(config.Sources).To(HaveKeyWithValue(common.AuditLog, file))
+	})
+
+	It("should not disable the audit log of the system config", func() {
+		system := writeConfig(`
+audit-log: /var/log/crictl-audit.log
+`)
+		user := writeConfig(`
+audit-log: ""
+`)
+		GinkgoT().Setenv("CRICTL_AUDIT_LOG", "")
+
+		config, err := common.LoadServerConfig([]string{system, user}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.AuditLog).To(Equal("/var/log/crictl-audit.log"))
+		Expect(config.Sources).To(HaveKeyWithValue(common.AuditLog, system))
+
+		config, err = common.LoadServerConfig([]string{user}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.AuditLog).To(BeEmpty())
+	})
+
+	It("should fail with invalid environment variable", func() {
+		GinkgoT().Setenv("CRICTL_TIMEOUT", "foo")
+
+		_, err := common.LoadServerConfig(nil, "")
+		Expect(err).To(MatchError(ContainSubstring("CRICTL_TIMEOUT")))
+	})
+
+	It("should report all problems on validation", func() {
+		file := writeConfig(`
+timeout: foo
+debug: bar
+current-context: missing
+`)
+		GinkgoT().Setenv("CRICTL_MAX_RETRIES", "baz")
+
+		err := common.ValidateConfigFiles([]string{file}, "")
+		Expect(err).To(MatchError(ContainSubstring("'timeout'")))
+		Expect(err).To(MatchError(ContainSubstring("'debug'")))
+		Expect(err).To(MatchError(ContainSubstring("CRICTL_MAX_RETRIES")))
+	})
+})
+
+var _ = DescribeTable("GetServerConfigFromFileWithContext",
+	func(contextName string, expectedConfig *common.ServerConfiguration, shouldFail bool) {
+		f, err := os.CreateTemp("", "crictl-server-config-")
+		defer os.RemoveAll(f.Name())
+
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = f.WriteString(`
+runtime-endpoint: "foo"
+image-endpoint: "bar"
+timeout: 10
+current-context: local
+contexts:
+  - name: local
+  - name: remote
+    runtime-endpoint: "baz"
+    timeout: 5
+    runtime-handler: kata
+`)
+		Expect(err).NotTo(HaveOccurred())
+
+		config, err := common.GetServerConfigFromFileWithContext(f.Name(), "", contextName)
+		if shouldFail {
+			Expect(err).To(MatchError(common.ErrContextNotFound))
+
+			return
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Context).To(Equal(expectedConfig.Context))
+		Expect(config.RuntimeEndpoint).To(Equal(expectedConfig.RuntimeEndpoint))
+		Expect(config.ImageEndpoint).To(Equal(expectedConfig.ImageEndpoint))
+		Expect(config.Timeout).To(Equal(expectedConfig.Timeout))
+		Expect(config.RuntimeHandler).To(Equal(expectedConfig.RuntimeHandler))
+	},
+
+	Entry("should use the current-context", "", &common.ServerConfiguration{
+		Context:         "local",
+		RuntimeEndpoint: "foo",
+		ImageEndpoint:   "bar",
+		Timeout:         10 * time.Second,
+	}, false),
+
+	Entry("should override the top level options", "remote", &common.ServerConfiguration{
+		Context:         "remote",
+		RuntimeEndpoint: "baz",
+		Timeout:         5 * time.Second,
+		RuntimeHandler:  "kata",
+	}, false),
+
+	Entry("should fail with unknown context", "missing", nil, true),
+)
+
+var _ = It("GetServerConfigFromFile should ignore the contexts", func() {
+	f, err := os.CreateTemp("", "crictl-server-config-")
+	defer os.RemoveAll(f.Name())
+
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = f.WriteString(`
+runtime-endpoint: "foo"
+current-context: remote
+contexts:
+  - name: remote
+    runtime-endpoint: "baz"
+`)
+	Expect(err).NotTo(HaveOccurred())
+
+	config, err := common.GetServerConfigFromFile(f.Name(), "")
+	Expect(err).NotTo(HaveOccurred())
+	Expect(config.Context).To(BeEmpty())
+	Expect(config.RuntimeEndpoint).To(Equal("foo"))
+})
@@ -0,0 +1,407 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptionType is the type of a config option value.
+type OptionType string
+
+const (
+	// OptionTypeString is a plain string option.
+	OptionTypeString OptionType = "string"
+
+	// OptionTypeEndpoint is a CRI endpoint, like unix:///run/containerd/containerd.sock
+	// or tls://node:10010.
+	OptionTypeEndpoint OptionType = "endpoint"
+
+	// OptionTypeOutput is an output format, like json or yaml.
+	OptionTypeOutput OptionType = "output"
+
+	// OptionTypeBool is a boolean option.
+	OptionTypeBool OptionType = "bool"
+
+	// OptionTypeInt is an integer option.
+	OptionTypeInt OptionType = "int"
+
+	// OptionTypeDuration is a duration option, either in seconds like 10 or
+	// with a unit like 10s or 500ms.
+	OptionTypeDuration OptionType = "duration"
+)
+
+const (
+	// envPrefix is the prefix of the environment variables overriding options.
+	envPrefix = "CRICTL_"
+
+	// EndpointAuto is the endpoint value to discover the endpoint
+	// automatically.
+	EndpointAuto = "auto"
+)
+
+// outputFormats are the valid values of output options.
+var outputFormats = []string{"json", "yaml", "table"}
+
+// Option describes a single config option of T, which is either a Config or a
+// Context.
+type Option[T any] struct {
+	// Key is the YAML key of the option.
+	Key string
+	// Type is the type of the option value.
+	Type OptionType
+	// Usage is the description of the option.
+	Usage string
+	// Default is the default value used by crictl if the option is not set.
+	Default string
+	// Env is the name of the environment variable overriding the option, if any.
+	Env string
+
+	// omitEmpty skips writing the option if it is not set.
+	omitEmpty bool
+	// noOverride keeps the value of the first config file setting the
+	// option, which later config files cannot override.
+	noOverride bool
+
+	get   func(*T) string
+	set   func(*T, string) error
+	isSet func(*T) bool
+}
+
+// Get returns the canonical string representation of the option value.
+func (o *Option[T]) Get(c *T) string {
+	return o.get(c)
+}
+
+// Set parses and validates the value and sets it on c.
+func (o *Option[T]) Set(c *T, value string) error {
+	if err := o.set(c, value); err != nil {
+		return fmt.Errorf("invalid value %q for option '%s': %w", value, o.Key, err)
+	}
+
+	return nil
+}
+
+// IsSet returns true if the option value differs from the zero value, or if
+// an optional option is defined at all.
+func (o *Option[T]) IsSet(c *T) bool {
+	return o.isSet(c)
+}
+
+// yamlTag returns the YAML tag to be used for the value.
+func (o *Option[T]) yamlTag(value string) string {
+	switch o.Type {
+	case OptionTypeBool:
+		return "!!bool"
+	case OptionTypeInt:
+		return "!!int"
+	case OptionTypeDuration:
+		if _, err := strconv.Atoi(value); err == nil {
+			return "!!int"
+		}
+	case OptionTypeString, OptionTypeEndpoint, OptionTypeOutput:
+	}
+
+	return "!!str"
+}
+
+// ConfigOptions are all top level options of the config file.
+var ConfigOptions = []*Option[Config]{
+	stringOption(RuntimeEndpoint, OptionTypeEndpoint, "Container Runtime Interface (CRI) runtime endpoint, or \"auto\" to discover it",
+		func(c *Config) *string { return &c.RuntimeEndpoint }),
+	stringOption(ImageEndpoint, OptionTypeEndpoint, "Container Runtime Interface (CRI) image endpoint, or \"auto\" to discover it",
+		func(c *Config) *string { return &c.ImageEndpoint }),
+	withDefault(durationOption(Timeout, "Timeout of connecting to server, in seconds or with unit like 10s",
+		func(c *Config) *time.Duration { return &c.Timeout }), "2s"),
+	withDefault(boolOption(Debug, "Enable debug output",
+		func(c *Config) *bool { return &c.Debug }), "false"),
+	withDefault(boolOption(PullImageOnCreate, "Enable pulling image on create requests",
+		func(c *Config) *bool { return &c.PullImageOnCreate }), "false"),
+	withDefault(boolOption(DisablePullOnRun, "Disable pulling image on run requests",
+		func(c *Config) *bool { return &c.DisablePullOnRun }), "false"),
+	withDefault(intOption(MaxRetries, "Max retries for connecting to an explicitly set endpoint (0 to disable, negative for infinite)",
+		func(c *Config) *int { return &c.MaxRetries }), "3"),
+	omitEmpty(withDefault(intOption(RPCRetries, "Max retries for idempotent RPCs failing with a transient error (0 to disable)",
+		func(c *Config) *int { return &c.RPCRetries }), "0")),
+	omitEmpty(stringOption(TLSCA, OptionTypeString, "Path to the TLS CA certificate of tls:// endpoints and streaming",
+		func(c *Config) *string { return &c.TLSCA })),
+	omitEmpty(stringOption(TLSCert, OptionTypeString, "Path to the TLS client certificate of tls:// endpoints and streaming",
+		func(c *Config) *string { return &c.TLSCert })),
+	omitEmpty(stringOption(TLSKey, OptionTypeString, "Path to the TLS client key of tls:// endpoints and streaming",
+		func(c *Config) *string { return &c.TLSKey })),
+	omitEmpty(stringOption(TLSSNI, OptionTypeString, "Server name used to verify the TLS certificates of tls:// endpoints and streaming",
+		func(c *Config) *string { return &c.TLSSNI })),
+	omitEmpty(withDefault(boolOption(ReadOnly, "Reject all RPCs which modify the runtime, like creating, removing or pulling",
+		func(c *Config) *bool { return &c.ReadOnly }), "false")),
+	omitEmpty(withDefault(boolOption(ReadOnlyAllowStreaming, "Allow exec, attach and port-forward in read-only mode",
+		func(c *Config) *bool { return &c.ReadOnlyAllowStreaming }), "false")),
+	withoutOverride(withoutEnv(omitEmpty(stringOption(AuditLog, OptionTypeString, "Path of the log every invocation modifying the runtime is appended to",
+		func(c *Config) *string { return &c.AuditLog })))),
+	withoutEnv(omitEmpty(stringOption(CurrentContext, OptionTypeString, "Name of the context used by default",
+		func(c *Config) *string { return &c.CurrentContext }))),
+}
+
+// ContextOptions are all options of a context.
+var ContextOptions = []*Option[Context]{
+	stringOption(ContextName, OptionTypeString, "Name of the context",
+		func(c *Context) *string { return &c.Name }),
+	stringOption(RuntimeEndpoint, OptionTypeEndpoint, "Container Runtime Interface (CRI) runtime endpoint",
+		func(c *Context) *string { return &c.RuntimeEndpoint }),
+	stringOption(ImageEndpoint, OptionTypeEndpoint, "Container Runtime Interface (CRI) image endpoint",
+		func(c *Context) *string { return &c.ImageEndpoint }),
+	durationOption(Timeout, "Timeout of connecting to server, in seconds or with unit like 10s",
+		func(c *Context) *time.Duration { return &c.Timeout }),
+	stringOption(TLSCA, OptionTypeString, "Path to the TLS CA certificate of tls:// endpoints and streaming",
+		func(c *Context) *string { return &c.TLSCA }),
+	stringOption(TLSCert, OptionTypeString, "Path to the TLS client certificate of tls:// endpoints and streaming",
+		func(c *Context) *string { return &c.TLSCert }),
+	stringOption(TLSKey, OptionTypeString, "Path to the TLS client key of tls:// endpoints and streaming",
+		func(c *Context) *string { return &c.TLSKey }),
+	stringOption(TLSSNI, OptionTypeString, "Server name used to verify the TLS certificates of tls:// endpoints and streaming",
+		func(c *Context) *string { return &c.TLSSNI }),
+	stringOption(RuntimeHandler, OptionTypeString, "Default runtime handler of new pods",
+		func(c *Context) *string { return &c.RuntimeHandler }),
+	stringOption(Output, OptionTypeOutput, "Default output format ("+strings.Join(outputFormats, ", ")+")",
+		func(c *Context) *string { return &c.Output }),
+	optionalBoolOption(ReadOnly, "Reject all RPCs which modify the runtime, like creating, removing or pulling",
+		func(c *Context) **bool { return &c.ReadOnly }),
+}
+
+// LookupConfigOption returns the top level option for the key.
+func LookupConfigOption(key string) (*Option[Config], error) {
+	return lookupOption(ConfigOptions, key)
+}
+
+func lookupOption[T any](options []*Option[T], key string) (*Option[T], error) {
+	for _, o := range options {
+		if o.Key == key {
+			return o, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no configuration option named %s", key)
+}
+
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func withDefault[T any](o *Option[T], def string) *Option[T] {
+	o.Default = def
+
+	return o
+}
+
+func withoutEnv[T any](o *Option[T]) *Option[T] {
+	o.Env = ""
+
+	return o
+}
+
+func withoutOverride[T any](o *Option[T]) *Option[T] {
+	o.noOverride = true
+
+	return o
+}
+
+func omitEmpty[T any](o *Option[T]) *Option[T] {
+	o.omitEmpty = true
+
+	return o
+}
+
+func stringOption[T any](key string, typ OptionType, usage string, field func(*T) *string) *Option[T] {
+	return &Option[T]{
+		Key:   key,
+		Type:  typ,
+		Usage: usage,
+		Env:   envName(key),
+		get:   func(c *T) string { return *field(c) },
+		set: func(c *T, value string) error {
+			switch typ {
+			case OptionTypeEndpoint:
+				if err := ValidateEndpoint(value); err != nil {
+					return err
+				}
+			case OptionTypeOutput:
+				if value != "" && !slices.Contains(outputFormats, value) {
+					return fmt.Errorf("expected one of %s", strings.Join(outputFormats, ", "))
+				}
+			case OptionTypeString, OptionTypeBool, OptionTypeInt, OptionTypeDuration:
+			}
+
+			*field(c) = value
+
+			return nil
+		},
+		isSet: func(c *T) bool { return *field(c) != "" },
+	}
+}
+
+func boolOption[T any](key, usage string, field func(*T) *bool) *Option[T] {
+	return &Option[T]{
+		Key:   key,
+		Type:  OptionTypeBool,
+		Usage: usage,
+		Env:   envName(key),
+		get:   func(c *T) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *T, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected a boolean: %w", err)
+			}
+
+			*field(c) = b
+
+			return nil
+		},
+		isSet: func(c *T) bool { return *field(c) },
+	}
+}
+
+// optionalBoolOption is a boolean option which is set if defined, even if
+// false.
+func optionalBoolOption[T any](key, usage string, field func(*T) **bool) *Option[T] {
+	return &Option[T]{
+		Key:   key,
+		Type:  OptionTypeBool,
+		Usage: usage,
+		Env:   envName(key),
+		get: func(c *T) string {
+			if *field(c) == nil {
+				return ""
+			}
+
+			return strconv.FormatBool(**field(c))
+		},
+		set: func(c *T, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected a boolean: %w", err)
+			}
+
+			*field(c) = &b
+
+			return nil
+		},
+		isSet: func(c *T) bool { return *field(c) != nil },
+	}
+}
+
+func intOption[T any](key, usage string, field func(*T) *int) *Option[T] {
+	return &Option[T]{
+		Key:   key,
+		Type:  OptionTypeInt,
+		Usage: usage,
+		Env:   envName(key),
+		get:   func(c *T) string { return strconv.Itoa(*field(c)) },
+		set: func(c *T, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("expected an integer: %w", err)
+			}
+
+			*field(c) = n
+
+			return nil
+		},
+		isSet: func(c *T) bool { return *field(c) != 0 },
+	}
+}
+
+func durationOption[T any](key, usage string, field func(*T) *time.Duration) *Option[T] {
+	return &Option[T]{
+		Key:   key,
+		Type:  OptionTypeDuration,
+		Usage: usage,
+		Env:   envName(key),
+		get:   func(c *T) string { return formatDuration(*field(c)) },
+		set: func(c *T, value string) error {
+			d, err := parseDuration(value)
+			if err != nil {
+				return err
+			}
+
+			*field(c) = d
+
+			return nil
+		},
+		isSet: func(c *T) bool { return *field(c) != 0 },
+	}
+}
+
+// parseDuration parses plain integers as seconds for backwards compatibility
+// and everything else as Go duration.
+func parseDuration(value string) (time.Duration, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("expected seconds or a duration like 10s: %w", err)
+	}
+
+	return d, nil
+}
+
+// formatDuration formats whole seconds as plain integers, which keeps the
+// config file readable by older crictl versions.
+func formatDuration(d time.Duration) string {
+	if d%time.Second == 0 {
+		return strconv.FormatInt(int64(d/time.Second), 10)
+	}
+
+	return d.String()
+}
+
+// ValidateEndpoint verifies the format of a CRI endpoint. Values without a
+// scheme are accepted, because they are used as unix socket paths.
+func ValidateEndpoint(endpoint string) error {
+	if endpoint == "" || endpoint == EndpointAuto || !strings.Contains(endpoint, "://") {
+		return nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("parse endpoint: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix", "npipe":
+	case EndpointSchemeTCP, EndpointSchemeTLS:
+		if _, _, err := net.SplitHostPort(u.Host); err != nil || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("endpoint %q must be of the form %s://host:port", endpoint, u.Scheme)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported endpoint scheme %q, expected unix://, npipe://, tcp:// or tls://", u.Scheme)
+	}
+
+	if u.Host == "" && u.Path == "" && u.Opaque == "" {
+		return fmt.Errorf("endpoint %q has no path", endpoint)
+	}
+
+	return nil
+}
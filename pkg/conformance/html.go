@@ -0,0 +1,150 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"html/template"
+	"os"
+	"slices"
+	"time"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sortedKeys": func(m map[string]bool) []string {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		return keys
+	},
+	"summary": func(r *Report) []Group {
+		return []Group{{Name: "all", Counts: r.Summary}}
+	},
+	"round": func(d time.Duration) time.Duration {
+		return d.Round(time.Millisecond)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>CRI conformance report{{with .Runtime.Name}} of {{.}}{{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f0f0f0; }
+td.num { text-align: right; }
+.passed { color: #1a7f37; }
+.failed { color: #cf222e; font-weight: bold; }
+.skipped, .pending, .missing { color: #777; }
+.regressions { background: #ffebe9; }
+.lost { background: #fff8c5; }
+pre { white-space: pre-wrap; margin: 0; }
+</style>
+</head>
+<body>
+<h1>CRI conformance report</h1>
+<table>
+<tr><th>Runtime</th><td>{{.Runtime.Name}} {{.Runtime.Version}}</td></tr>
+<tr><th>CRI version</th><td>{{.Runtime.APIVersion}}</td></tr>
+<tr><th>Runtime handler</th><td>{{if .Runtime.Handler}}{{.Runtime.Handler}}{{else}}&lt;default&gt;{{end}}</td></tr>
+<tr><th>Started</th><td>{{.StartTime.UTC.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Duration</th><td>{{round (.EndTime.Sub .StartTime)}}</td></tr>
+<tr><th>Suite</th><td class="{{if .SuiteSucceeded}}passed{{else}}failed{{end}}">{{if .SuiteSucceeded}}succeeded{{else}}failed{{end}}</td></tr>
+{{- with .Baseline}}
+<tr><th>Compared to</th><td>{{.Name}} {{.Version}}</td></tr>
+{{- end}}
+</table>
+{{- if .Regressions}}
+<h2>Regressions</h2>
+<table class="regressions">
+<tr><th>Spec</th><th>Previous</th><th>Result</th><th>Failure</th></tr>
+{{- range .Regressions}}
+<tr><td>{{.Text}}</td><td class="{{.Previous}}">{{.Previous}}</td><td class="{{.Result}}">{{.Result}}</td><td><pre>{{.Failure}}</pre></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Lost}}
+<h2>Lost specs</h2>
+<table class="lost">
+<tr><th>Spec</th><th>Previous</th><th>Result</th></tr>
+{{- range .Lost}}
+<tr><td>{{.Text}}</td><td class="{{.Previous}}">{{.Previous}}</td><td class="{{.Result}}">{{.Result}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Runtime.Capabilities}}
+<h2>Capabilities</h2>
+<table>
+{{- range $name := sortedKeys .Runtime.Capabilities}}
+<tr><th>{{$name}}</th><td>{{index $.Runtime.Capabilities $name}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Runtime.Handlers}}
+<h2>Runtime handlers</h2>
+<table>
+<tr><th>Name</th><th>Features</th></tr>
+{{- range $h := .Runtime.Handlers}}
+<tr><td>{{if $h.Name}}{{$h.Name}}{{else}}&lt;default&gt;{{end}}</td><td>{{range $i, $name := sortedKeys $h.Features}}{{if $i}}, {{end}}{{$name}}: {{index $h.Features $name}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- define "groups"}}
+<table>
+<tr><th>Name</th><th>Total</th><th>Passed</th><th>Failed</th><th>Skipped</th><th>Pending</th></tr>
+{{- range .}}
+<tr><td>{{.Name}}</td><td class="num">{{.Total}}</td><td class="num passed">{{.Passed}}</td><td class="num failed">{{.Failed}}</td><td class="num skipped">{{.Skipped}}</td><td class="num pending">{{.Pending}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>Summary</h2>
+{{template "groups" (summary .)}}
+<h2>Areas</h2>
+{{template "groups" .Areas}}
+{{- if .Tags}}
+<h2>Tags</h2>
+{{template "groups" .Tags}}
+{{- end}}
+<h2>Specs</h2>
+<table>
+<tr><th>Spec</th><th>Area</th><th>Result</th><th>Duration</th></tr>
+{{- range .Specs}}
+<tr><td>{{.Text}}{{with .Failure}}<pre class="failed">{{.}}</pre>{{end}}</td><td>{{.Area}}</td><td class="{{.Result}}">{{.Result}}</td><td class="num">{{round .Duration}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as static HTML page to the file.
+func (r *Report) WriteHTML(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := htmlTemplate.Execute(f, r); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,354 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance builds conformance reports of the CRI validation suite,
+// which group the results of the specs by CRI area and tag, and compare them
+// with a previous report.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	ginkgotypes "github.com/onsi/ginkgo/v2/types"
+)
+
+// The results of the specs.
+const (
+	ResultPassed  = "passed"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+	ResultPending = "pending"
+)
+
+// ResultMissing is the result of a spec of the previous report which is not
+// in this one.
+const ResultMissing = "missing"
+
+// areaOther is the area of the specs which don't belong to a known area.
+const areaOther = "other"
+
+// areas maps the prefixes of the top level containers of the specs to their
+// CRI areas. Longer prefixes come first.
+var areas = []struct {
+	prefix, area string
+}{
+	{"Image Volume", "image"},
+	{"Image", "image"},
+	{"PodSandbox", "pod"},
+	{"Networking", "network"},
+	{"Multiple Containers", "container"},
+	{"Container", "container"},
+	{"Idempotence", "container"},
+	{"Streaming", "streaming"},
+	{"Security Context", "security"},
+	{"AppArmor", "security"},
+	{"SELinux", "security"},
+	{"NRI", "nri"},
+	{"Checkpoint", "checkpoint"},
+	{"Runtime info", "runtime"},
+}
+
+// tagPattern matches the tags in the texts of the specs, like [Conformance]
+// and [Feature:Checkpoint].
+var tagPattern = regexp.MustCompile(`\[(Conformance|Serial|Slow|Feature:[^\]]+)\]`)
+
+// Report is the conformance report of a suite run.
+type Report struct {
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	SuiteSucceeded bool      `json:"suiteSucceeded"`
+	Runtime        Runtime   `json:"runtime"`
+	Summary        Counts    `json:"summary"`
+	// Areas are the results by CRI area, like pod, container and image.
+	Areas []Group `json:"areas"`
+	// Tags are the results by tag, like Conformance and Feature:Checkpoint.
+	Tags  []Group `json:"tags"`
+	Specs []Spec  `json:"specs"`
+	// Baseline is the runtime of the previous report, if compared.
+	Baseline *Runtime `json:"baseline,omitempty"`
+	// Regressions are the specs which passed in the previous report and
+	// failed in this one.
+	Regressions []Regression `json:"regressions,omitempty"`
+	// Lost are the specs which passed in the previous report and were
+	// skipped, pending or missing in this one.
+	Lost []Regression `json:"lost,omitempty"`
+}
+
+// Runtime describes the runtime under test.
+type Runtime struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	APIVersion string `json:"apiVersion"`
+	// Handler is the runtime handler under test.
+	Handler string `json:"handler"`
+	// Capabilities are the capabilities of the runtime handler under test.
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+	// Handlers are the runtime handlers reported by the runtime.
+	Handlers []Handler `json:"handlers,omitempty"`
+}
+
+// Handler is a runtime handler and its features.
+type Handler struct {
+	Name     string          `json:"name"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// Counts are the number of specs by result.
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Pending int `json:"pending"`
+}
+
+// Group are the results of the specs of an area or tag.
+type Group struct {
+	Name string `json:"name"`
+	Counts
+}
+
+// Spec is the result of a spec.
+type Spec struct {
+	Text     string        `json:"text"`
+	Area     string        `json:"area"`
+	Tags     []string      `json:"tags,omitempty"`
+	Result   string        `json:"result"`
+	Duration time.Duration `json:"duration"`
+	Failure  string        `json:"failure,omitempty"`
+	Location string        `json:"location,omitempty"`
+}
+
+// Regression is a spec which passed before and fails, or no longer runs, now.
+type Regression struct {
+	Text     string `json:"text"`
+	Previous string `json:"previous"`
+	Result   string `json:"result"`
+	Failure  string `json:"failure,omitempty"`
+}
+
+// NewReport builds the conformance report of the Ginkgo report.
+func NewReport(report *ginkgotypes.Report, runtime *Runtime) *Report {
+	r := &Report{
+		StartTime:      report.StartTime,
+		EndTime:        report.EndTime,
+		SuiteSucceeded: report.SuiteSucceeded,
+		Runtime:        *runtime,
+	}
+
+	for _, spec := range report.SpecReports {
+		if spec.LeafNodeType != ginkgotypes.NodeTypeIt {
+			continue
+		}
+
+		s := Spec{
+			Text:     spec.FullText(),
+			Area:     specArea(spec.ContainerHierarchyTexts),
+			Tags:     specTags(spec.FullText(), spec.Labels()),
+			Result:   specResult(spec.State),
+			Duration: spec.RunTime,
+			Location: spec.LeafNodeLocation.String(),
+		}
+
+		if s.Result == ResultFailed {
+			s.Failure = spec.Failure.Message
+		}
+
+		r.Specs = append(r.Specs, s)
+	}
+
+	slices.SortFunc(r.Specs, func(a, b Spec) int { return strings.Compare(a.Text, b.Text) })
+
+	areaGroups := map[string]*Counts{}
+	tagGroups := map[string]*Counts{}
+
+	for _, s := range r.Specs {
+		r.Summary.add(s.Result)
+		group(areaGroups, s.Area).add(s.Result)
+
+		for _, tag := range s.Tags {
+			group(tagGroups, tag).add(s.Result)
+		}
+	}
+
+	r.Areas = groups(areaGroups)
+	r.Tags = groups(tagGroups)
+
+	return r
+}
+
+// Compare records the specs which passed in the previous report and failed
+// in this one as regressions, and the ones which were skipped, pending or
+// missing in this one as lost.
+func (r *Report) Compare(previous *Report) {
+	r.Baseline = &previous.Runtime
+	r.Regressions = nil
+	r.Lost = nil
+
+	specs := make(map[string]Spec, len(r.Specs))
+	for _, s := range r.Specs {
+		specs[s.Text] = s
+	}
+
+	for _, previousSpec := range previous.Specs {
+		if previousSpec.Result != ResultPassed {
+			continue
+		}
+
+		s, ok := specs[previousSpec.Text]
+		if !ok {
+			s = Spec{Text: previousSpec.Text, Result: ResultMissing}
+		}
+
+		regression := Regression{
+			Text:     s.Text,
+			Previous: ResultPassed,
+			Result:   s.Result,
+			Failure:  s.Failure,
+		}
+
+		switch s.Result {
+		case ResultPassed:
+			continue
+		case ResultFailed:
+			r.Regressions = append(r.Regressions, regression)
+		default:
+			r.Lost = append(r.Lost, regression)
+		}
+	}
+
+	byText := func(a, b Regression) int { return strings.Compare(a.Text, b.Text) }
+	slices.SortFunc(r.Regressions, byText)
+	slices.SortFunc(r.Lost, byText)
+}
+
+// Load reads a JSON conformance report.
+func Load(file string) (*Report, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("decode conformance report %s: %w", file, err)
+	}
+
+	return r, nil
+}
+
+// WriteJSON writes the report as JSON to the file.
+func (r *Report) WriteJSON(file string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+func (c *Counts) add(result string) {
+	c.Total++
+
+	switch result {
+	case ResultPassed:
+		c.Passed++
+	case ResultFailed:
+		c.Failed++
+	case ResultSkipped:
+		c.Skipped++
+	case ResultPending:
+		c.Pending++
+	}
+}
+
+func group(groups map[string]*Counts, name string) *Counts {
+	if groups[name] == nil {
+		groups[name] = &Counts{}
+	}
+
+	return groups[name]
+}
+
+func groups(counts map[string]*Counts) []Group {
+	result := make([]Group, 0, len(counts))
+	for name, c := range counts {
+		result = append(result, Group{Name: name, Counts: *c})
+	}
+
+	slices.SortFunc(result, func(a, b Group) int { return strings.Compare(a.Name, b.Name) })
+
+	return result
+}
+
+// specArea returns the CRI area of the spec by its top level container.
+func specArea(containers []string) string {
+	if len(containers) == 0 {
+		return areaOther
+	}
+
+	text := strings.TrimPrefix(containers[0], "[k8s.io] ")
+
+	for _, a := range areas {
+		if strings.HasPrefix(text, a.prefix) {
+			return a.area
+		}
+	}
+
+	return areaOther
+}
+
+// specTags returns the tags in the text of the spec and its labels.
+func specTags(text string, labels []string) []string {
+	var tags []string
+
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(tags, match[1]) {
+			tags = append(tags, match[1])
+		}
+	}
+
+	for _, label := range labels {
+		if !slices.Contains(tags, label) {
+			tags = append(tags, label)
+		}
+	}
+
+	slices.Sort(tags)
+
+	return tags
+}
+
+// specResult returns the result of the spec state, where all failure states
+// are failed.
+func specResult(state ginkgotypes.SpecState) string {
+	switch {
+	case state.Is(ginkgotypes.SpecStatePassed):
+		return ResultPassed
+	case state.Is(ginkgotypes.SpecStateSkipped):
+		return ResultSkipped
+	case state.Is(ginkgotypes.SpecStatePending):
+		return ResultPending
+	default:
+		return ResultFailed
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	ginkgotypes "github.com/onsi/ginkgo/v2/types"
+)
+
+func spec(state ginkgotypes.SpecState, containers []string, text string, labels ...string) ginkgotypes.SpecReport {
+	return ginkgotypes.SpecReport{
+		ContainerHierarchyTexts:  containers,
+		ContainerHierarchyLabels: make([][]string, len(containers)),
+		LeafNodeType:             ginkgotypes.NodeTypeIt,
+		LeafNodeText:             text,
+		LeafNodeLabels:           labels,
+		State:                    state,
+		Failure:                  ginkgotypes.Failure{Message: "<" + text + " failed>"},
+	}
+}
+
+func ginkgoReport(states ...ginkgotypes.SpecState) *ginkgotypes.Report {
+	return &ginkgotypes.Report{
+		SuiteSucceeded: false,
+		SpecReports: []ginkgotypes.SpecReport{
+			{LeafNodeType: ginkgotypes.NodeTypeBeforeSuite, State: ginkgotypes.SpecStatePassed},
+			spec(states[0], []string{"[k8s.io] PodSandbox", "PodSandbox runtime"}, "should run a pod [Conformance]"),
+			spec(states[1], []string{"[k8s.io] Image Volume [Feature:ImageVolume]"}, "should mount an image"),
+			spec(states[2], []string{"[k8s.io] Security Context", "UserNamespaces"}, "should map the ids", "Capability:UserNamespaces"),
+			spec(states[3], []string{"[k8s.io] Something new"}, "should be other [Conformance]"),
+		},
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	r := NewReport(ginkgoReport(
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStateFailed,
+		ginkgotypes.SpecStateSkipped,
+		ginkgotypes.SpecStateTimedout,
+	), &Runtime{Name: "containerd"})
+
+	if len(r.Specs) != 4 {
+		t.Fatalf("expected 4 specs, got %d", len(r.Specs))
+	}
+
+	expectedSummary := Counts{Total: 4, Passed: 1, Failed: 2, Skipped: 1}
+	if r.Summary != expectedSummary {
+		t.Errorf("expected summary %+v, got %+v", expectedSummary, r.Summary)
+	}
+
+	expectedAreas := []Group{
+		{Name: "image", Counts: Counts{Total: 1, Failed: 1}},
+		{Name: "other", Counts: Counts{Total: 1, Failed: 1}},
+		{Name: "pod", Counts: Counts{Total: 1, Passed: 1}},
+		{Name: "security", Counts: Counts{Total: 1, Skipped: 1}},
+	}
+	if !reflect.DeepEqual(r.Areas, expectedAreas) {
+		t.Errorf("expected areas %+v, got %+v", expectedAreas, r.Areas)
+	}
+
+	expectedTags := []Group{
+		{Name: "Capability:UserNamespaces", Counts: Counts{Total: 1, Skipped: 1}},
+		{Name: "Conformance", Counts: Counts{Total: 2, Passed: 1, Failed: 1}},
+		{Name: "Feature:ImageVolume", Counts: Counts{Total: 1, Failed: 1}},
+	}
+	if !reflect.DeepEqual(r.Tags, expectedTags) {
+		t.Errorf("expected tags %+v, got %+v", expectedTags, r.Tags)
+	}
+
+	for _, s := range r.Specs {
+		if (s.Result == ResultFailed) != (s.Failure != "") {
+			t.Errorf("spec %q with result %s has failure %q", s.Text, s.Result, s.Failure)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	previous := NewReport(ginkgoReport(
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStateFailed,
+	), &Runtime{Name: "containerd", Version: "v2.0.0"})
+	previous.Specs = append(previous.Specs, Spec{Text: "[k8s.io] Removed should be missing", Result: ResultPassed})
+
+	r := NewReport(ginkgoReport(
+		ginkgotypes.SpecStatePanicked,
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStateSkipped,
+		ginkgotypes.SpecStateFailed,
+	), &Runtime{Name: "containerd", Version: "v2.1.0"})
+
+	r.Compare(previous)
+
+	if r.Baseline == nil || r.Baseline.Version != "v2.0.0" {
+		t.Errorf("expected the baseline v2.0.0, got %+v", r.Baseline)
+	}
+
+	if len(r.Regressions) != 1 || r.Regressions[0].Text != "[k8s.io] PodSandbox PodSandbox runtime should run a pod [Conformance]" {
+		t.Fatalf("expected the pod spec as only regression, got %+v", r.Regressions)
+	}
+
+	if r.Regressions[0].Previous != ResultPassed || r.Regressions[0].Result != ResultFailed {
+		t.Errorf("unexpected regression %+v", r.Regressions[0])
+	}
+
+	expectedLost := []Regression{
+		{Text: "[k8s.io] Removed should be missing", Previous: ResultPassed, Result: ResultMissing},
+		{Text: "[k8s.io] Security Context UserNamespaces should map the ids", Previous: ResultPassed, Result: ResultSkipped},
+	}
+	if !reflect.DeepEqual(r.Lost, expectedLost) {
+		t.Errorf("expected the lost specs %+v, got %+v", expectedLost, r.Lost)
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	r := NewReport(ginkgoReport(
+		ginkgotypes.SpecStatePassed,
+		ginkgotypes.SpecStateFailed,
+		ginkgotypes.SpecStateSkipped,
+		ginkgotypes.SpecStatePending,
+	), &Runtime{
+		Name:         "containerd",
+		Capabilities: map[string]bool{"UserNamespaces": true},
+		Handlers:     []Handler{{Name: "runc", Features: map[string]bool{"UserNamespaces": true}}},
+	})
+	r.Compare(r)
+
+	jsonFile := filepath.Join(dir, "report.json")
+	if err := r.WriteJSON(jsonFile); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+
+	loaded, err := Load(jsonFile)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded.Specs, r.Specs) || !reflect.DeepEqual(loaded.Runtime, r.Runtime) {
+		t.Errorf("expected the loaded report to equal the written one, got %+v", loaded)
+	}
+
+	htmlFile := filepath.Join(dir, "report.html")
+	if err := r.WriteHTML(htmlFile); err != nil {
+		t.Fatalf("WriteHTML returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(htmlFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	html := string(data)
+	for _, expected := range []string{"containerd", "Feature:ImageVolume", "UserNamespaces: true", "&lt;should mount an image failed&gt;"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected the HTML report to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(html, "<should mount an image failed>") {
+		t.Error("expected the failure to be escaped in the HTML report")
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error loading a missing report")
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// Capability is a feature of the runtime, which specs can require by
+// RequireCapabilities.
+type Capability string
+
+const (
+	// CapabilityRuntimeHandler is set if the runtime reports the runtime
+	// handler under test.
+	CapabilityRuntimeHandler Capability = "RuntimeHandler"
+
+	// CapabilityUserNamespaces is set if the runtime handler under test
+	// supports user namespaces.
+	CapabilityUserNamespaces Capability = "UserNamespaces"
+
+	// CapabilityRecursiveReadOnlyMounts is set if the runtime handler under
+	// test supports recursive read-only mounts.
+	CapabilityRecursiveReadOnlyMounts Capability = "RecursiveReadOnlyMounts"
+
+	// CapabilitySupplementalGroupsPolicy is set if the runtime supports the
+	// SupplementalGroupsPolicy of the security context.
+	CapabilitySupplementalGroupsPolicy Capability = "SupplementalGroupsPolicy"
+
+	// CapabilityCgroupDriverSystemd is set if the runtime uses the systemd
+	// cgroup driver.
+	CapabilityCgroupDriverSystemd Capability = "CgroupDriverSystemd"
+
+	// CapabilityCgroupDriverCgroupfs is set if the runtime uses the cgroupfs
+	// cgroup driver.
+	CapabilityCgroupDriverCgroupfs Capability = "CgroupDriverCgroupfs"
+
+	// capabilityLabelPrefix is the prefix of the labels of RequireCapabilities.
+	capabilityLabelPrefix = "Capability:"
+
+	// capabilityDiscoveryTimeout is the timeout of every CRI call of the
+	// capability discovery.
+	capabilityDiscoveryTimeout = time.Minute
+)
+
+// allCapabilities are the capabilities shown in the capability report.
+var allCapabilities = []Capability{
+	CapabilityRuntimeHandler,
+	CapabilityUserNamespaces,
+	CapabilityRecursiveReadOnlyMounts,
+	CapabilitySupplementalGroupsPolicy,
+	CapabilityCgroupDriverSystemd,
+	CapabilityCgroupDriverCgroupfs,
+}
+
+var (
+	// capabilities are the capabilities discovered at the start of the suite.
+	capabilities = map[Capability]bool{}
+
+	// runtimeStatus, runtimeConfig and runtimeVersion are the responses of
+	// the capability discovery, which are nil if the call failed.
+	runtimeStatus  *runtimeapi.StatusResponse
+	runtimeConfig  *runtimeapi.RuntimeConfigResponse
+	runtimeVersion *runtimeapi.VersionResponse
+)
+
+var _ = AddBeforeSuiteCallback(discoverCapabilities)
+
+// Skip the specs which require capabilities the runtime doesn't have.
+var _ = BeforeEach(func() {
+	for _, label := range CurrentSpecReport().Labels() {
+		name, ok := strings.CutPrefix(label, capabilityLabelPrefix)
+		if ok && !HasCapability(Capability(name)) {
+			Skip(fmt.Sprintf("The runtime does not have the %s capability", name))
+		}
+	}
+})
+
+// RequireCapabilities labels a container or spec, so that it is skipped if
+// the runtime doesn't have all of the capabilities. The labels can be used in
+// label filters as well, like `-ginkgo.label-filter='!Capability:UserNamespaces'`.
+func RequireCapabilities(caps ...Capability) Labels {
+	labels := make(Labels, 0, len(caps))
+	for _, c := range caps {
+		labels = append(labels, capabilityLabelPrefix+string(c))
+	}
+
+	return labels
+}
+
+// HasCapability returns whether the runtime has the capability.
+func HasCapability(c Capability) bool {
+	return capabilities[c]
+}
+
+// Capabilities returns the capabilities discovered at the start of the suite.
+func Capabilities() map[Capability]bool {
+	return maps.Clone(capabilities)
+}
+
+// RuntimeStatus returns the verbose status of the runtime from the start of
+// the suite, or nil if it is unknown.
+func RuntimeStatus() *runtimeapi.StatusResponse {
+	return runtimeStatus
+}
+
+// discoverCapabilities queries the status, config and version of the runtime
+// once and derives the capabilities for the runtime handler under test from
+// them. Failed calls are logged, so that the specs depending on them are
+// skipped and the specs of the calls themselves fail.
+func discoverCapabilities() {
+	c, err := LoadCRIClient()
+	if err != nil {
+		Logf("Unable to discover the runtime capabilities: %v", err)
+
+		return
+	}
+
+	rc := c.CRIRuntimeClient
+
+	withTimeout := func(call func(ctx context.Context) error) error {
+		ctx, cancel := context.WithTimeout(context.Background(), capabilityDiscoveryTimeout)
+		defer cancel()
+
+		return call(ctx)
+	}
+
+	if err := withTimeout(func(ctx context.Context) (err error) {
+		runtimeVersion, err = rc.Version(ctx, "v1")
+
+		return err
+	}); err != nil {
+		Logf("Unable to get the runtime version: %v", err)
+	}
+
+	if err := withTimeout(func(ctx context.Context) (err error) {
+		runtimeStatus, err = rc.Status(ctx, true)
+
+		return err
+	}); err != nil {
+		Logf("Unable to get the runtime status: %v", err)
+	}
+
+	if err := withTimeout(func(ctx context.Context) (err error) {
+		runtimeConfig, err = rc.RuntimeConfig(ctx)
+
+		return err
+	}); err != nil && status.Code(err) != codes.Unimplemented {
+		Logf("Unable to get the runtime config: %v", err)
+	}
+
+	capabilities[CapabilitySupplementalGroupsPolicy] = runtimeStatus.GetFeatures().GetSupplementalGroupsPolicy()
+
+	for _, handler := range runtimeStatus.GetRuntimeHandlers() {
+		if handler.GetName() != TestContext.RuntimeHandler {
+			continue
+		}
+
+		capabilities[CapabilityRuntimeHandler] = true
+		capabilities[CapabilityUserNamespaces] = handler.GetFeatures().GetUserNamespaces()
+		capabilities[CapabilityRecursiveReadOnlyMounts] = handler.GetFeatures().GetRecursiveReadOnlyMounts()
+	}
+
+	driver := runtimeConfig.GetLinux().GetCgroupDriver()
+	capabilities[CapabilityCgroupDriverSystemd] = runtimeConfig.GetLinux() != nil && driver == runtimeapi.CgroupDriver_SYSTEMD
+	capabilities[CapabilityCgroupDriverCgroupfs] = runtimeConfig.GetLinux() != nil && driver == runtimeapi.CgroupDriver_CGROUPFS
+
+	// Every parallel process discovers the capabilities, but only the first
+	// one shows them in the report.
+	if GinkgoParallelProcess() == 1 {
+		AddReportEntry("Runtime capabilities", capabilityReport())
+	} else {
+		Logf("Runtime capabilities:\n%s", capabilityReport())
+	}
+}
+
+// capabilityReport formats the runtime and its capabilities.
+func capabilityReport() string {
+	var sb strings.Builder
+
+	if runtimeVersion != nil {
+		fmt.Fprintf(&sb, "Runtime: %s %s (CRI %s)\n",
+			runtimeVersion.GetRuntimeName(), runtimeVersion.GetRuntimeVersion(), runtimeVersion.GetRuntimeApiVersion())
+	}
+
+	handler := TestContext.RuntimeHandler
+	if handler == "" {
+		handler = "<default>"
+	}
+
+	fmt.Fprintf(&sb, "Runtime handler: %s\n", handler)
+
+	width := 0
+	for _, c := range allCapabilities {
+		width = max(width, len(c)+1)
+	}
+
+	for _, c := range allCapabilities {
+		fmt.Fprintf(&sb, "  %-*s %t\n", width, string(c)+":", capabilities[c])
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"sigs.k8s.io/cri-tools/pkg/conformance"
+)
+
+// Write the conformance report after all specs of all processes ran.
+var _ = ReportAfterSuite("conformance report", func(report Report) {
+	if TestContext.ConformanceReport == "" {
+		return
+	}
+
+	r := conformance.NewReport(&report, conformanceRuntime())
+
+	if TestContext.ConformanceBaseline != "" {
+		previous, err := conformance.Load(TestContext.ConformanceBaseline)
+		ExpectNoError(err, "failed to load the conformance baseline")
+
+		r.Compare(previous)
+
+		for _, regression := range r.Regressions {
+			Logf("Regression: %q %s before, %s now", regression.Text, regression.Previous, regression.Result)
+		}
+
+		for _, lost := range r.Lost {
+			Logf("Lost: %q %s before, %s now", lost.Text, lost.Previous, lost.Result)
+		}
+	}
+
+	err := r.WriteJSON(TestContext.ConformanceReport)
+	ExpectNoError(err, "failed to write the conformance report")
+
+	htmlReport := strings.TrimSuffix(TestContext.ConformanceReport, filepath.Ext(TestContext.ConformanceReport)) + ".html"
+	err = r.WriteHTML(htmlReport)
+	ExpectNoError(err, "failed to write the HTML conformance report")
+
+	Logf("Wrote the conformance report to %s and %s with %d regressions and %d lost specs",
+		TestContext.ConformanceReport, htmlReport, len(r.Regressions), len(r.Lost))
+})
+
+// conformanceRuntime returns the runtime under test of the capability
+// discovery.
+func conformanceRuntime() *conformance.Runtime {
+	runtime := &conformance.Runtime{
+		Name:         runtimeVersion.GetRuntimeName(),
+		Version:      runtimeVersion.GetRuntimeVersion(),
+		APIVersion:   runtimeVersion.GetRuntimeApiVersion(),
+		Handler:      TestContext.RuntimeHandler,
+		Capabilities: map[string]bool{},
+	}
+
+	for c, ok := range capabilities {
+		runtime.Capabilities[string(c)] = ok
+	}
+
+	for _, handler := range runtimeStatus.GetRuntimeHandlers() {
+		runtime.Handlers = append(runtime.Handlers, conformance.Handler{
+			Name: handler.GetName(),
+			Features: map[string]bool{
+				string(CapabilityUserNamespaces):          handler.GetFeatures().GetUserNamespaces(),
+				string(CapabilityRecursiveReadOnlyMounts): handler.GetFeatures().GetRecursiveReadOnlyMounts(),
+			},
+		})
+	}
+
+	return runtime
+}
@@ -0,0 +1,270 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/runtime/protoiface"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/common"
+)
+
+const (
+	// diagnosticsDirName is the directory of the failure diagnostics in the
+	// report directory.
+	diagnosticsDirName = "diagnostics"
+
+	// diagnosticsTimeout is the timeout of collecting the failure diagnostics
+	// of a spec.
+	diagnosticsTimeout = time.Minute
+)
+
+// diagnosticsNamePattern matches the characters replaced in the directory
+// names of the specs.
+var diagnosticsNamePattern = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+var (
+	// diagnosticsClient is the CRI client of the failure diagnostics, which
+	// is created by the first spec.
+	diagnosticsClient *InternalAPIClient
+
+	// specDiagnostics are the collectors of the running spec by name.
+	specDiagnostics = map[string]func() any{}
+
+	// specPodSandboxes and specContainers are the IDs of the pod sandboxes
+	// and containers created by the running spec.
+	specPodSandboxes []string
+	specContainers   []string
+
+	specDiagnosticsMu sync.Mutex
+)
+
+// Reset the diagnostics before every spec and create the CRI client of the
+// failure diagnostics.
+var _ = BeforeEach(func() {
+	specDiagnosticsMu.Lock()
+	clear(specDiagnostics)
+	specPodSandboxes, specContainers = nil, nil
+	specDiagnosticsMu.Unlock()
+
+	if TestContext.ReportDir == "" {
+		return
+	}
+
+	if diagnosticsClient == nil {
+		c, err := LoadCRIClient()
+		if err != nil {
+			Logf("Unable to create the CRI client of the failure diagnostics: %v", err)
+
+			return
+		}
+
+		diagnosticsClient = c
+	}
+})
+
+// Write the failure diagnostics of a failed spec before its cleanup.
+var _ = JustAfterEach(func() {
+	if TestContext.ReportDir == "" || diagnosticsClient == nil || !CurrentSpecReport().Failed() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagnosticsTimeout)
+	defer cancel()
+
+	dir, err := writeFailureDiagnostics(ctx)
+	if err != nil {
+		Logf("Unable to write the failure diagnostics: %v", err)
+
+		return
+	}
+
+	AddReportEntry("Failure diagnostics", dir)
+})
+
+// AddSpecDiagnostics adds a collector of diagnostics to the running spec. If
+// the spec fails, the result of the collector is written as JSON file with
+// the name to its failure diagnostics.
+func AddSpecDiagnostics(name string, collect func() any) {
+	specDiagnosticsMu.Lock()
+	defer specDiagnosticsMu.Unlock()
+
+	specDiagnostics[name] = collect
+}
+
+// AddSpecPodSandbox adds a pod sandbox created by the running spec to its
+// failure diagnostics. The pod sandboxes run by RunPodSandbox are added
+// already.
+func AddSpecPodSandbox(podID string) {
+	if podID == "" {
+		return
+	}
+
+	specDiagnosticsMu.Lock()
+	defer specDiagnosticsMu.Unlock()
+
+	specPodSandboxes = append(specPodSandboxes, podID)
+}
+
+// AddSpecContainer adds a container created by the running spec to its
+// failure diagnostics. The containers created by CreateContainer are added
+// already.
+func AddSpecContainer(containerID string) {
+	if containerID == "" {
+		return
+	}
+
+	specDiagnosticsMu.Lock()
+	defer specDiagnosticsMu.Unlock()
+
+	specContainers = append(specContainers, containerID)
+}
+
+// writeFailureDiagnostics writes the runtime status and config, the verbose
+// status of the pod sandboxes and containers created by the spec, the logs of
+// the containers and the results of the spec collectors to the diagnostics
+// directory of the spec, and returns it. Diagnostics which cannot be
+// collected are logged.
+func writeFailureDiagnostics(ctx context.Context) (string, error) {
+	report := CurrentSpecReport()
+
+	dir := filepath.Join(TestContext.ReportDir, TestContext.ReportPrefix+diagnosticsDirName)
+	if TestContext.RuntimeHandler != "" {
+		dir = filepath.Join(dir, TestContext.RuntimeHandler)
+	}
+
+	dir = filepath.Join(dir, diagnosticsSpecDirName(report.FullText(), report.NumAttempts))
+
+	for _, sub := range []string{"pods", "containers"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return "", err
+		}
+	}
+
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			Logf("Unable to write the failure diagnostics %s: %v", name, err)
+		}
+	}
+
+	writeProto := func(name string, msg protoiface.MessageV1, err error) {
+		if err != nil {
+			write(name+".err", []byte(err.Error()+"\n"))
+
+			return
+		}
+
+		data, err := protojson.MarshalOptions{EmitDefaultValues: true, Indent: "  "}.Marshal(protoadapt.MessageV2Of(msg))
+		if err != nil {
+			Logf("Unable to marshal the failure diagnostics %s: %v", name, err)
+
+			return
+		}
+
+		write(name+".json", append(data, '\n'))
+	}
+
+	write("failure.txt", fmt.Appendf(nil, "%s\n\n%s\n\n%s\n",
+		report.FullText(), report.Failure.Location, report.Failure.Message))
+
+	rc := diagnosticsClient.CRIRuntimeClient
+
+	status, err := rc.Status(ctx, true)
+	writeProto("status", status, err)
+
+	config, err := rc.RuntimeConfig(ctx)
+	writeProto("runtime-config", config, err)
+
+	specDiagnosticsMu.Lock()
+	defer specDiagnosticsMu.Unlock()
+
+	for _, id := range specPodSandboxes {
+		podStatus, err := rc.PodSandboxStatus(ctx, id, true)
+		writeProto(filepath.Join("pods", id), podStatus, err)
+	}
+
+	for _, id := range specContainers {
+		containerStatus, err := rc.ContainerStatus(ctx, id, true)
+		writeProto(filepath.Join("containers", id), containerStatus, err)
+
+		copyContainerLog(containerStatus.GetStatus(), filepath.Join(dir, "containers", id+".log"))
+	}
+
+	for name, collect := range specDiagnostics {
+		data, err := json.MarshalIndent(collect(), "", "  ")
+		if err != nil {
+			Logf("Unable to marshal the failure diagnostics %s: %v", name, err)
+
+			continue
+		}
+
+		write(name+".json", append(data, '\n'))
+	}
+
+	return dir, nil
+}
+
+// copyContainerLog copies the log file of the container, which is only
+// readable for local runtimes.
+func copyContainerLog(status *runtimeapi.ContainerStatus, file string) {
+	if status.GetLogPath() == "" || common.IsRemoteEndpoint(TestContext.RuntimeServiceAddr) {
+		return
+	}
+
+	data, err := os.ReadFile(status.GetLogPath())
+	if err != nil {
+		Logf("Unable to read the log of container %s: %v", status.GetId(), err)
+
+		return
+	}
+
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		Logf("Unable to write the log of container %s: %v", status.GetId(), err)
+	}
+}
+
+// diagnosticsSpecDirName returns the directory name of the diagnostics of a
+// spec, which is its text with a hash, so that it is unique.
+func diagnosticsSpecDirName(text string, attempt int) string {
+	name := diagnosticsNamePattern.ReplaceAllString(text, "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	hash := sha256.Sum256([]byte(text))
+	name += "-" + hex.EncodeToString(hash[:4])
+
+	if attempt > 1 {
+		name += fmt.Sprintf("-attempt%d", attempt)
+	}
+
+	return name
+}
@@ -0,0 +1,82 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"net"
+	"strings"
+
+	"sigs.k8s.io/cri-tools/pkg/registry"
+)
+
+// localRegistry serves the images of the registry-images flag, if set.
+var localRegistry *registry.Registry
+
+var _ = AddBeforeSuiteCallback(func() {
+	if TestContext.RegistryImages == "" {
+		return
+	}
+
+	if TestContext.RegistryPrefix != DefaultRegistryPrefix {
+		Failf("The registry-images and registry-prefix flags cannot be used together")
+	}
+
+	localRegistry = registry.New()
+
+	for file := range strings.SplitSeq(TestContext.RegistryImages, ",") {
+		if file = strings.TrimSpace(file); file == "" {
+			continue
+		}
+
+		images, err := localRegistry.Load(file)
+		ExpectNoError(err, "failed to load the images of %s", file)
+		Logf("Loaded images %v from %s", images, file)
+	}
+
+	err := localRegistry.Start("127.0.0.1:0")
+	ExpectNoError(err, "failed to start the local registry")
+
+	TestContext.RegistryPrefix = localRegistry.Address()
+	TestContext.TestImageList.DefaultTestContainerImage = PrepareImageName(TestContext.TestImageList.DefaultTestContainerImage)
+
+	Logf("Serving the test images from the local registry %s", TestContext.RegistryPrefix)
+})
+
+// LocalRegistryAlias returns the local registry address with localhost as
+// host, which is a second registry serving the same images, or an empty
+// string if the local registry is not used.
+func LocalRegistryAlias() string {
+	if localRegistry == nil {
+		return ""
+	}
+
+	_, port, err := net.SplitHostPort(localRegistry.Address())
+	if err != nil {
+		return ""
+	}
+
+	return net.JoinHostPort("localhost", port)
+}
+
+// StopLocalRegistry stops the local registry, if it is used.
+func StopLocalRegistry() error {
+	if localRegistry == nil {
+		return nil
+	}
+
+	return localRegistry.Close()
+}
@@ -92,6 +92,10 @@ type TestContextType struct {
 	// Report related settings.
 	ReportDir    string
 	ReportPrefix string
+	// Path of the JSON conformance report, the HTML report is written next
+	// to it, and the previous JSON conformance report to compare with.
+	ConformanceReport   string
+	ConformanceBaseline string
 
 	// CRI client configurations.
 	ConfigPath            string
@@ -101,6 +105,18 @@ type TestContextType struct {
 	RuntimeServiceTimeout time.Duration
 	RuntimeHandler        string
 
+	// Client TLS settings for tls:// endpoints.
+	TLSCA   string
+	TLSCert string
+	TLSKey  string
+	TLSSNI  string
+
+	// OpenTelemetry tracing settings.
+	EnableTracing   bool
+	TracingProtocol string
+	TracingEndpoint string
+	TracingFile     string
+
 	// Test images-related settings.
 	TestImageList TestImageList
 
@@ -112,6 +128,9 @@ type TestContextType struct {
 	IsLcow bool
 
 	RegistryPrefix string
+	// Comma separated OCI image layouts and docker save archives to serve
+	// from a local registry, which replaces the registry prefix.
+	RegistryImages string
 
 	// Use websocket connections over than SPDY for streaming tests.
 	UseWebsocketForExec        bool
@@ -153,6 +172,8 @@ func RegisterFlags() {
 
 	flag.StringVar(&TestContext.ReportPrefix, "report-prefix", "", "Optional prefix for JUnit XML reports. Default is empty, which doesn't prepend anything to the default name.")
 	flag.StringVar(&TestContext.ReportDir, "report-dir", "", "Path to the directory where the JUnit XML reports should be saved. Default is empty, which doesn't generate these reports.")
+	flag.StringVar(&TestContext.ConformanceReport, "conformance-report", "", "Path of the JSON conformance report, which groups the results by CRI area and tag. A static HTML report is written next to it with the .html extension.")
+	flag.StringVar(&TestContext.ConformanceBaseline, "conformance-baseline", "", "Path of a previous JSON conformance report, the specs which passed in it and fail now are reported as regressions.")
 	flag.StringVar(&TestContext.ImageServiceAddr, "image-endpoint", "", "Image service socket for client to connect.")
 	flag.StringVar(&testImagesFilePath, "test-images-file", "", "Optional path to a YAML file containing references to custom container images to be used in tests.")
 	flag.DurationVar(&TestContext.ImageServiceTimeout, "image-service-timeout", 300*time.Second, "Timeout when trying to connect to image service.")
@@ -169,6 +190,15 @@ func RegisterFlags() {
 	flag.StringVar(&TestContext.RuntimeServiceAddr, "runtime-endpoint", svcaddr, "Runtime service socket for client to connect.")
 	flag.DurationVar(&TestContext.RuntimeServiceTimeout, "runtime-service-timeout", 300*time.Second, "Timeout when trying to connect to a runtime service.")
 	flag.StringVar(&TestContext.RuntimeHandler, "runtime-handler", "", "Runtime handler to use in the test.")
+	flag.StringVar(&TestContext.TLSCA, "tls-ca", "", "Path to the CA certificate used to verify tls:// endpoints.")
+	flag.StringVar(&TestContext.TLSCert, "tls-cert", "", "Path to the client certificate for tls:// endpoints.")
+	flag.StringVar(&TestContext.TLSKey, "tls-key", "", "Path to the client key for tls:// endpoints.")
+	flag.StringVar(&TestContext.TLSSNI, "tls-sni", "", "Server name used to verify the certificate of tls:// endpoints (default: the endpoint host).")
+
+	flag.BoolVar(&TestContext.EnableTracing, "enable-tracing", false, "Record an OpenTelemetry span for every spec, with the spans of the CRI clients as children.")
+	flag.StringVar(&TestContext.TracingProtocol, "tracing-protocol", "", "OTLP protocol of the tracing collector, one of: grpc|http/protobuf (default: OTEL_EXPORTER_OTLP_PROTOCOL or grpc).")
+	flag.StringVar(&TestContext.TracingEndpoint, "tracing-endpoint", "", "Address of the tracing collector, as host:port or http(s):// URL (default: OTEL_EXPORTER_OTLP_ENDPOINT, or 127.0.0.1:4317 for grpc and 127.0.0.1:4318 for http/protobuf).")
+	flag.StringVar(&TestContext.TracingFile, "tracing-file", "", "Append the spans as OTLP JSON lines to the file instead of sending them to a collector, \"-\" for stdout.")
 
 	flag.StringVar(&benchmarkSettingFilePath, "benchmarking-params-file", "", "Optional path to a YAML file specifying benchmarking configuration options.")
 	flag.StringVar(&TestContext.BenchmarkingOutputDir, "benchmarking-output-dir", "", "Optional path to a directory in which benchmarking data should be placed.")
@@ -180,6 +210,7 @@ func RegisterFlags() {
 	}
 
 	flag.StringVar(&TestContext.RegistryPrefix, "registry-prefix", DefaultRegistryPrefix, "A possible registry prefix added to all images, like 'localhost:5000'")
+	flag.StringVar(&TestContext.RegistryImages, "registry-images", "", "Comma separated OCI image layout directories and docker save archives to serve from a local registry on 127.0.0.1, which is used as registry prefix.")
 
 	flag.BoolVar(&TestContext.UseWebsocketForExec, "websocket-exec", false, "Use websocket connections over SPDY for exec streaming tests.")
 	flag.BoolVar(&TestContext.UseWebsocketForAttach, "websocket-attach", false, "Use websocket connections over SPDY for attach streaming tests.")
@@ -0,0 +1,169 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/cri-tools/pkg/tracing"
+)
+
+const tracerName = "sigs.k8s.io/cri-tools/pkg/framework"
+
+var (
+	// tracerProvider is set if tracing is enabled.
+	tracerProvider *sdktrace.TracerProvider
+
+	// suiteCtx contains the span of the suite, which is the parent of the
+	// spans of all specs.
+	suiteCtx  = context.Background()
+	suiteSpan trace.Span
+
+	// specSpan is the span of the running spec. The specs of a process run
+	// one after another, so there is at most one.
+	specSpan   trace.Span
+	specSpanMu sync.Mutex
+)
+
+var _ = AddBeforeSuiteCallback(func() {
+	if !TestContext.EnableTracing {
+		return
+	}
+
+	var err error
+
+	tracerProvider, err = tracing.InitWithOptions(context.Background(), &tracing.Options{
+		Protocol:     TestContext.TracingProtocol,
+		Endpoint:     TestContext.TracingEndpoint,
+		File:         TestContext.TracingFile,
+		SamplingRate: -1,
+	})
+	ExpectNoError(err, "failed to initialize tracing")
+
+	suiteCtx, suiteSpan = tracerProvider.Tracer(tracerName).Start(
+		tracing.ContextWithEnvParent(context.Background()),
+		"CRI validation",
+		trace.WithAttributes(attribute.Int("ginkgo.parallel.process", GinkgoParallelProcess())),
+	)
+})
+
+// Start the span of every spec before its own setup.
+var _ = BeforeEach(func() {
+	if tracerProvider == nil {
+		return
+	}
+
+	report := CurrentSpecReport()
+
+	_, span := tracerProvider.Tracer(tracerName).Start(suiteCtx, report.FullText(), trace.WithAttributes(
+		attribute.String("ginkgo.spec.text", report.FullText()),
+		attribute.StringSlice("ginkgo.spec.labels", report.Labels()),
+		attribute.String("ginkgo.spec.location", report.LeafNodeLocation.String()),
+	))
+
+	specSpanMu.Lock()
+	specSpan = span
+	specSpanMu.Unlock()
+})
+
+// End the span of every spec after its cleanup with its result.
+var _ = ReportAfterEach(func(report SpecReport) {
+	specSpanMu.Lock()
+	span := specSpan
+	specSpan = nil
+	specSpanMu.Unlock()
+
+	if span == nil {
+		return
+	}
+
+	span.SetAttributes(attribute.String("ginkgo.spec.result", report.State.String()))
+
+	if report.State.Is(types.SpecStateFailureStates) {
+		span.SetStatus(codes.Error, report.Failure.Message)
+	}
+
+	span.End()
+})
+
+// ShutdownTracing ends the span of the suite and exports all spans, if
+// tracing is enabled.
+func ShutdownTracing() error {
+	if tracerProvider == nil {
+		return nil
+	}
+
+	suiteSpan.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("shutdown tracer provider: %w", err)
+	}
+
+	return nil
+}
+
+// criTracerProvider returns the tracer provider of the CRI clients, or nil
+// if tracing is disabled.
+func criTracerProvider() trace.TracerProvider {
+	if tracerProvider == nil {
+		return nil
+	}
+
+	return &specTracerProvider{TracerProvider: tracerProvider}
+}
+
+// specTracerProvider makes the spans of the CRI clients children of the span
+// of the running spec. The specs pass their own contexts to the clients,
+// which do not contain the span of the spec.
+type specTracerProvider struct {
+	trace.TracerProvider
+}
+
+func (p *specTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &specTracer{Tracer: p.TracerProvider.Tracer(name, options...)}
+}
+
+type specTracer struct {
+	trace.Tracer
+}
+
+func (t *specTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		specSpanMu.Lock()
+		span := specSpan
+		specSpanMu.Unlock()
+
+		if span != nil {
+			ctx = trace.ContextWithSpan(ctx, span)
+		}
+	}
+
+	return t.Tracer.Start(ctx, spanName, opts...)
+}
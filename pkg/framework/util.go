@@ -23,6 +23,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/distribution/reference"
@@ -56,6 +57,10 @@ var (
 	// DefaultLinuxContainerCommand default container command for Linux.
 	DefaultLinuxContainerCommand = []string{"top"}
 
+	// endpointProxies forward the tcp:// and tls:// endpoints by their URL.
+	endpointProxies   = map[string]*common.EndpointProxy{}
+	endpointProxiesMu sync.Mutex
+
 	// DefaultLinuxPauseCommand default container command for Linux pause.
 	DefaultLinuxPauseCommand = []string{"sh", "-c", "top"}
 
@@ -132,11 +137,16 @@ func AddBeforeSuiteCallback(callback func()) bool {
 
 // LoadCRIClient creates a InternalAPIClient.
 func LoadCRIClient() (*InternalAPIClient, error) {
+	runtimeServiceAddr, err := localEndpoint(TestContext.RuntimeServiceAddr)
+	if err != nil {
+		return nil, err
+	}
+
 	rService, err := remote.NewRemoteRuntimeService(
 		context.Background(),
-		TestContext.RuntimeServiceAddr,
+		runtimeServiceAddr,
 		TestContext.RuntimeServiceTimeout,
-		nil,
+		criTracerProvider(),
 		false,
 	)
 	if err != nil {
@@ -149,7 +159,12 @@ func LoadCRIClient() (*InternalAPIClient, error) {
 		imageServiceAddr = TestContext.RuntimeServiceAddr
 	}
 
-	iService, err := remote.NewRemoteImageService(context.Background(), imageServiceAddr, TestContext.ImageServiceTimeout, nil, false)
+	imageServiceAddr, err = localEndpoint(imageServiceAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	iService, err := remote.NewRemoteImageService(context.Background(), imageServiceAddr, TestContext.ImageServiceTimeout, criTracerProvider(), false)
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +175,54 @@ func LoadCRIClient() (*InternalAPIClient, error) {
 	}, nil
 }
 
+// localEndpoint returns the endpoint to be used by the CRI client. The
+// connections to tcp:// and tls:// endpoints are forwarded by a local socket,
+// which is shared by all clients of the endpoint.
+func localEndpoint(endpoint string) (string, error) {
+	if !common.IsRemoteEndpoint(endpoint) {
+		return endpoint, nil
+	}
+
+	endpointProxiesMu.Lock()
+	defer endpointProxiesMu.Unlock()
+
+	if proxy, ok := endpointProxies[endpoint]; ok {
+		return proxy.Endpoint, nil
+	}
+
+	proxy, err := common.NewEndpointProxy(context.Background(), endpoint, &common.EndpointTLSConfig{
+		CAFile:     TestContext.TLSCA,
+		CertFile:   TestContext.TLSCert,
+		KeyFile:    TestContext.TLSKey,
+		ServerName: TestContext.TLSSNI,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	endpointProxies[endpoint] = proxy
+
+	return proxy.Endpoint, nil
+}
+
+// CloseEndpointProxies stops forwarding all tcp:// and tls:// endpoints.
+func CloseEndpointProxies() error {
+	endpointProxiesMu.Lock()
+	defer endpointProxiesMu.Unlock()
+
+	errs := []error{}
+
+	for endpoint, proxy := range endpointProxies {
+		if err := proxy.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close forwarding of %s: %w", endpoint, err))
+		}
+
+		delete(endpointProxies, endpoint)
+	}
+
+	return errors.Join(errs...)
+}
+
 func nowStamp() string {
 	return time.Now().Format(time.StampMilli)
 }
@@ -239,6 +302,7 @@ func BuildPodSandboxMetadata(podSandboxName, uid, namespace string, attempt uint
 func RunPodSandbox(ctx context.Context, c internalapi.RuntimeService, config *runtimeapi.PodSandboxConfig) string {
 	podID, err := c.RunPodSandbox(ctx, config, TestContext.RuntimeHandler)
 	ExpectNoError(err, "failed to create PodSandbox")
+	AddSpecPodSandbox(podID)
 
 	return podID
 }
@@ -247,6 +311,7 @@ func RunPodSandbox(ctx context.Context, c internalapi.RuntimeService, config *ru
 func RunPodSandboxError(ctx context.Context, c internalapi.RuntimeService, config *runtimeapi.PodSandboxConfig) string {
 	podID, err := c.RunPodSandbox(ctx, config, TestContext.RuntimeHandler)
 	Expect(err).To(HaveOccurred())
+	AddSpecPodSandbox(podID)
 
 	return podID
 }
@@ -331,6 +396,7 @@ func CreateContainerWithError(ctx context.Context, rc internalapi.RuntimeService
 	By("Create container.")
 
 	containerID, err := rc.CreateContainer(ctx, podID, config, podConfig)
+	AddSpecContainer(containerID)
 
 	return containerID, err
 }
@@ -378,14 +444,13 @@ func PrepareImageName(imageName string) string {
 
 	// Modify the image if it's a fully qualified image name
 	if TestContext.RegistryPrefix != DefaultRegistryPrefix {
-		r := fmt.Sprintf("%s/%s", TestContext.RegistryPrefix, reference.Path(ref))
-		ref, err = reference.ParseNamed(r)
+		ref, err = reference.ParseNamed(WithRegistryPrefix(imageName))
 		ExpectNoError(err, "failed to parse new image name")
 	}
 
 	imageName = ref.String()
 
-	if !strings.Contains(imageName, ":") {
+	if reference.IsNameOnly(ref) {
 		imageName += ":latest"
 
 		Logf("Use latest as default image tag.")
@@ -394,6 +459,19 @@ func PrepareImageName(imageName string) string {
 	return imageName
 }
 
+// WithRegistryPrefix replaces the registry of a fully qualified image name by
+// the registry prefix, keeping its tag and digest.
+func WithRegistryPrefix(imageName string) string {
+	if TestContext.RegistryPrefix == DefaultRegistryPrefix {
+		return imageName
+	}
+
+	ref, err := reference.ParseNamed(imageName)
+	ExpectNoError(err, "failed to parse image name %q", imageName)
+
+	return fmt.Sprintf("%s/%s", TestContext.RegistryPrefix, strings.TrimPrefix(ref.String(), reference.Domain(ref)+"/"))
+}
+
 // PullPublicImage pulls the public image named imageName.
 func PullPublicImage(ctx context.Context, c internalapi.ImageManagerService, imageName string, podConfig *runtimeapi.PodSandboxConfig) string {
 	imageName = PrepareImageName(imageName)
@@ -0,0 +1,546 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	mediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeImageConfig   = "application/vnd.oci.image.config.v1+json"
+	mediaTypeLayer         = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeLayerGzip     = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	// annotationImageName is the full image name set by containerd and docker.
+	annotationImageName = "io.containerd.image.name"
+	// annotationRefName is the OCI reference name, which is either a full image
+	// name or only a tag.
+	annotationRefName = "org.opencontainers.image.ref.name"
+)
+
+// descriptor is an OCI content descriptor.
+type descriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      digest.Digest     `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// manifest is either an OCI image manifest or an OCI image index, or their
+// docker equivalents.
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        *descriptor  `json:"config,omitempty"`
+	Layers        []descriptor `json:"layers,omitempty"`
+	Manifests     []descriptor `json:"manifests,omitempty"`
+}
+
+// dockerManifest is an entry of the manifest.json of a docker save archive.
+type dockerManifest struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// archive provides the files of an OCI image layout or docker save archive.
+type archive interface {
+	open(name string) (io.ReadSeekCloser, error)
+}
+
+// dirArchive is an OCI image layout directory.
+type dirArchive string
+
+func (d dirArchive) open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// tarArchive is an uncompressed tar archive. Its files are read in place.
+type tarArchive struct {
+	path    string
+	entries map[string]tarEntry
+}
+
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+type readSeekCloser struct {
+	io.ReadSeeker
+	io.Closer
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// newTarArchive indexes the regular files of the tar archive at the path.
+func newTarArchive(file string) (*tarArchive, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(f, magic); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return nil, fmt.Errorf("%s is compressed, only uncompressed tar archives are supported", file)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	a := &tarArchive{path: file, entries: map[string]tarEntry{}}
+	reader := tar.NewReader(f)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("read tar archive %s: %w", file, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// The tar reader consumes exactly the headers, so the file is positioned
+		// at the content of the entry.
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+
+		a.entries[path.Clean(header.Name)] = tarEntry{offset: offset, size: header.Size}
+	}
+
+	return a, nil
+}
+
+func (a *tarArchive) open(name string) (io.ReadSeekCloser, error) {
+	entry, ok := a.entries[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s: %w", name, a.path, os.ErrNotExist)
+	}
+
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return readSeekCloser{io.NewSectionReader(f, entry.offset, entry.size), f}, nil
+}
+
+// blob is a blob of an archive, or generated content.
+type blob struct {
+	archive archive
+	name    string
+	data    []byte
+}
+
+func (b *blob) open() (io.ReadSeekCloser, error) {
+	if b.archive == nil {
+		return readSeekCloser{bytes.NewReader(b.data), nopCloser{}}, nil
+	}
+
+	return b.archive.open(b.name)
+}
+
+// Load loads the images of an OCI image layout directory, or of an
+// uncompressed tar archive created by docker save or containing an OCI image
+// layout, and returns their names as repository:tag.
+func (r *Registry) Load(file string) ([]string, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("load images: %w", err)
+	}
+
+	var a archive = dirArchive(file)
+
+	if !info.IsDir() {
+		if a, err = newTarArchive(file); err != nil {
+			return nil, fmt.Errorf("load images: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var names []string
+
+	switch {
+	case exists(a, "index.json"):
+		names, err = r.loadLayout(a)
+	case exists(a, "manifest.json"):
+		names, err = r.loadDockerArchive(a)
+	default:
+		err = errors.New("neither an OCI image layout nor a docker save archive")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("load images of %s: %w", file, err)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no named images found in %s", file)
+	}
+
+	return names, nil
+}
+
+// loadLayout loads the images of an OCI image layout. The images are named
+// by the annotations of the descriptors of index.json.
+func (r *Registry) loadLayout(a archive) ([]string, error) {
+	data, err := readAll(a, "index.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var index manifest
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parse index.json: %w", err)
+	}
+
+	var names []string
+
+	for _, desc := range index.Manifests {
+		if err := r.addManifest(a, desc); err != nil {
+			return nil, err
+		}
+
+		if name, ok := r.tag(imageName(desc), desc.Digest); ok {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// addManifest adds the manifest of the descriptor with its blobs. The
+// manifests and blobs which are not part of the archive, like the images of
+// other platforms, are skipped.
+func (r *Registry) addManifest(a archive, desc descriptor) error {
+	name, err := blobPath(desc.Digest)
+	if err != nil {
+		return err
+	}
+
+	data, err := readAll(a, name)
+	if err != nil {
+		return err
+	}
+
+	if dgst := digest.FromBytes(data); dgst != desc.Digest {
+		return fmt.Errorf("manifest %s has digest %s", desc.Digest, dgst)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse manifest %s: %w", desc.Digest, err)
+	}
+
+	r.blobs[desc.Digest] = &blob{archive: a, name: name}
+	r.manifests[desc.Digest] = cmp.Or(desc.MediaType, m.MediaType, mediaTypeImageManifest)
+
+	for _, child := range m.Manifests {
+		if childName, err := blobPath(child.Digest); err != nil || !exists(a, childName) {
+			continue
+		}
+
+		if err := r.addManifest(a, child); err != nil {
+			return err
+		}
+	}
+
+	blobs := m.Layers
+	if m.Config != nil {
+		blobs = slices.Concat([]descriptor{*m.Config}, m.Layers)
+	}
+
+	for _, b := range blobs {
+		if blobName, err := blobPath(b.Digest); err == nil && exists(a, blobName) {
+			r.blobs[b.Digest] = &blob{archive: a, name: blobName}
+		}
+	}
+
+	return nil
+}
+
+// loadDockerArchive loads the images of a docker save archive without an OCI
+// image layout. An OCI image manifest is generated for every image.
+func (r *Registry) loadDockerArchive(a archive) ([]string, error) {
+	data, err := readAll(a, "manifest.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var dockerManifests []dockerManifest
+	if err := json.Unmarshal(data, &dockerManifests); err != nil {
+		return nil, fmt.Errorf("parse manifest.json: %w", err)
+	}
+
+	var names []string
+
+	for _, dm := range dockerManifests {
+		config, err := r.addBlob(a, dm.Config, mediaTypeImageConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		m := manifest{SchemaVersion: 2, MediaType: mediaTypeImageManifest, Config: &config}
+
+		for _, layer := range dm.Layers {
+			desc, err := r.addBlob(a, layer, mediaTypeLayer)
+			if err != nil {
+				return nil, err
+			}
+
+			m.Layers = append(m.Layers, desc)
+		}
+
+		content, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+		dgst := digest.FromBytes(content)
+		r.blobs[dgst] = &blob{data: content}
+		r.manifests[dgst] = mediaTypeImageManifest
+
+		for _, repoTag := range dm.RepoTags {
+			if name, ok := r.tag(repoTag, dgst); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// AddImage adds an image of the platform of the host with a single
+// uncompressed layer containing the files, and returns the digest of its
+// manifest.
+func (r *Registry) AddImage(name string, files map[string][]byte) (string, error) {
+	if _, err := reference.ParseNormalizedNamed(name); err != nil {
+		return "", fmt.Errorf("invalid image name %q: %w", name, err)
+	}
+
+	var layer bytes.Buffer
+
+	tw := tar.NewWriter(&layer)
+
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     file,
+			Mode:     0o644,
+			Size:     int64(len(files[file])),
+			Typeflag: tar.TypeReg,
+			ModTime:  time.Unix(0, 0),
+		}); err != nil {
+			return "", err
+		}
+
+		if _, err := tw.Write(files[file]); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+
+	layerDigest := digest.FromBytes(layer.Bytes())
+
+	config, err := json.Marshal(map[string]any{
+		"architecture": runtime.GOARCH,
+		"os":           runtime.GOOS,
+		"config":       map[string]any{},
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": []digest.Digest{layerDigest}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	configDigest := digest.FromBytes(config)
+
+	content, err := json.Marshal(manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageManifest,
+		Config:        &descriptor{MediaType: mediaTypeImageConfig, Digest: configDigest, Size: int64(len(config))},
+		Layers:        []descriptor{{MediaType: mediaTypeLayer, Digest: layerDigest, Size: int64(layer.Len())}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	manifestDigest := digest.FromBytes(content)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.blobs[layerDigest] = &blob{data: layer.Bytes()}
+	r.blobs[configDigest] = &blob{data: config}
+	r.blobs[manifestDigest] = &blob{data: content}
+	r.manifests[manifestDigest] = mediaTypeImageManifest
+
+	if _, ok := r.tag(name, manifestDigest); !ok {
+		return "", fmt.Errorf("invalid image name %q", name)
+	}
+
+	return manifestDigest.String(), nil
+}
+
+// addBlob adds a file of the archive as blob and returns its descriptor.
+// Gzip compressed layers get the matching media type.
+func (r *Registry) addBlob(a archive, name, mediaType string) (descriptor, error) {
+	f, err := a.open(name)
+	if err != nil {
+		return descriptor{}, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b && mediaType == mediaTypeLayer {
+		mediaType = mediaTypeLayerGzip
+	}
+
+	counter := &countingReader{reader: reader}
+
+	dgst, err := digest.Canonical.FromReader(counter)
+	if err != nil {
+		return descriptor{}, fmt.Errorf("digest %s: %w", name, err)
+	}
+
+	r.blobs[dgst] = &blob{archive: a, name: name}
+
+	return descriptor{MediaType: mediaType, Digest: dgst, Size: counter.n}, nil
+}
+
+// tag tags the manifest with the tag of the image name, and returns the
+// name as repository:tag. Names without a tag are tagged latest, names with
+// only a digest are skipped.
+func (r *Registry) tag(name string, dgst digest.Digest) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return "", false
+	}
+
+	tagged, ok := reference.TagNameOnly(named).(reference.Tagged)
+	if !ok {
+		return "", false
+	}
+
+	repository := reference.Path(named)
+	if r.tags[repository] == nil {
+		r.tags[repository] = map[string]digest.Digest{}
+	}
+
+	r.tags[repository][tagged.Tag()] = dgst
+
+	return repository + ":" + tagged.Tag(), true
+}
+
+// imageName returns the image name of a descriptor of an OCI image index.
+func imageName(desc descriptor) string {
+	if name := desc.Annotations[annotationImageName]; name != "" {
+		return name
+	}
+
+	// The reference name may only be a tag, which does not name an image.
+	if name := desc.Annotations[annotationRefName]; strings.ContainsAny(name, "/:") {
+		return name
+	}
+
+	return ""
+}
+
+// blobPath returns the path of a blob in an OCI image layout.
+func blobPath(dgst digest.Digest) (string, error) {
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("invalid digest %q: %w", dgst, err)
+	}
+
+	return path.Join("blobs", dgst.Algorithm().String(), dgst.Encoded()), nil
+}
+
+func exists(a archive, name string) bool {
+	f, err := a.open(name)
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+
+	return true
+}
+
+func readAll(a archive, name string) ([]byte, error) {
+	f, err := a.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
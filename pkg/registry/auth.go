@@ -0,0 +1,160 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// tokenPath is the path of the token endpoint of registries with bearer
+// token authentication.
+const tokenPath = "/token"
+
+// Auth is the authentication required by a registry.
+type Auth struct {
+	// Username and Password are the credentials of the basic authentication,
+	// or of the token endpoint for the bearer token authentication.
+	Username string
+	Password string
+	// Bearer requires the bearer tokens issued by the token endpoint of the
+	// registry, or the RegistryToken.
+	Bearer bool
+	// IdentityToken is the refresh token accepted by the token endpoint.
+	IdentityToken string
+	// RegistryToken is a bearer token accepted by the registry as is.
+	RegistryToken string
+}
+
+// SetAuth requires the authentication for all requests to the registry.
+func (r *Registry) SetAuth(auth *Auth) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("generate token: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.auth = auth
+	r.token = hex.EncodeToString(token)
+
+	return nil
+}
+
+// authorize returns true if the request is authorized, otherwise it writes
+// the authentication challenge.
+func (r *Registry) authorize(w http.ResponseWriter, req *http.Request) bool {
+	r.mu.RLock()
+	auth, token := r.auth, r.token
+	r.mu.RUnlock()
+
+	if auth == nil {
+		return true
+	}
+
+	if auth.Bearer {
+		if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok &&
+			(equal(bearer, token) || (auth.RegistryToken != "" && equal(bearer, auth.RegistryToken))) {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s%s",service=%q`, req.Host, tokenPath, req.Host))
+	} else {
+		if username, password, ok := req.BasicAuth(); ok && auth.validCredentials(username, password) {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="cri-tools"`)
+	}
+
+	writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+
+	return false
+}
+
+// serveToken issues the bearer tokens for the credentials by the GET requests
+// with basic authentication, and the POST requests with the password or
+// refresh token grant of OAuth2.
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	auth, token := r.auth, r.token
+	r.mu.RUnlock()
+
+	if auth == nil || !auth.Bearer {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown path")
+
+		return
+	}
+
+	var valid bool
+
+	switch req.Method {
+	case http.MethodGet:
+		username, password, ok := req.BasicAuth()
+		valid = ok && auth.validCredentials(username, password)
+	case http.MethodPost:
+		req.Body = http.MaxBytesReader(w, req.Body, 1<<20)
+		if err := req.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, "UNSUPPORTED", err.Error())
+
+			return
+		}
+
+		switch req.PostForm.Get("grant_type") {
+		case "password":
+			valid = auth.validCredentials(req.PostForm.Get("username"), req.PostForm.Get("password"))
+		case "refresh_token":
+			valid = auth.IdentityToken != "" && equal(req.PostForm.Get("refresh_token"), auth.IdentityToken)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+
+		return
+	}
+
+	if !valid {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid credentials")
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"token":        token,
+		"access_token": token,
+		"expires_in":   3600,
+		"issued_at":    time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		return
+	}
+}
+
+func (a *Auth) validCredentials(username, password string) bool {
+	return a.Username != "" && equal(username, a.Username) && equal(password, a.Password)
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
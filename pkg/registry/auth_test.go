@@ -0,0 +1,211 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newAuthServer(t *testing.T, auth *Auth) *httptest.Server {
+	t.Helper()
+
+	r := New()
+	if _, err := r.AddImage("example.com/auth/test:latest", map[string][]byte{"hello": []byte("world")}); err != nil {
+		t.Fatalf("AddImage returned error: %v", err)
+	}
+
+	if err := r.SetAuth(auth); err != nil {
+		t.Fatalf("SetAuth returned error: %v", err)
+	}
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func do(t *testing.T, req *http.Request) (int, http.Header, []byte) {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.Request.Method != http.MethodHead {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	return resp.StatusCode, resp.Header, body
+}
+
+func manifestRequest(t *testing.T, server *httptest.Server) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/v2/auth/test/manifests/latest", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return req
+}
+
+func TestBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	server := newAuthServer(t, &Auth{Username: "user", Password: "secret"})
+
+	status, header, _ := do(t, manifestRequest(t, server))
+	if status != http.StatusUnauthorized || !strings.HasPrefix(header.Get("WWW-Authenticate"), "Basic ") {
+		t.Fatalf("expected a basic challenge, got %d %q", status, header.Get("WWW-Authenticate"))
+	}
+
+	for _, tc := range []struct {
+		username, password string
+		status             int
+	}{
+		{"user", "secret", http.StatusOK},
+		{"user", "wrong", http.StatusUnauthorized},
+		{"other", "secret", http.StatusUnauthorized},
+	} {
+		req := manifestRequest(t, server)
+		req.SetBasicAuth(tc.username, tc.password)
+
+		if status, _, _ := do(t, req); status != tc.status {
+			t.Errorf("%s:%s: expected status %d, got %d", tc.username, tc.password, tc.status, status)
+		}
+	}
+
+	req := manifestRequest(t, server)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	if status, _, _ := do(t, req); status != http.StatusUnauthorized {
+		t.Errorf("expected bearer tokens to be rejected, got %d", status)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	t.Parallel()
+
+	server := newAuthServer(t, &Auth{
+		Username:      "user",
+		Password:      "secret",
+		Bearer:        true,
+		IdentityToken: "identity",
+		RegistryToken: "registry",
+	})
+
+	status, header, _ := do(t, manifestRequest(t, server))
+	if status != http.StatusUnauthorized || !strings.Contains(header.Get("WWW-Authenticate"), `realm="`+server.URL+tokenPath+`"`) {
+		t.Fatalf("expected a bearer challenge, got %d %q", status, header.Get("WWW-Authenticate"))
+	}
+
+	getToken := func(req *http.Request) (int, string) {
+		t.Helper()
+
+		status, _, body := do(t, req)
+
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(body, &token); err != nil {
+			t.Fatal(err)
+		}
+
+		return status, token.AccessToken
+	}
+
+	newTokenRequest := func(method string, form url.Values) *http.Request {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(t.Context(), method, server.URL+tokenPath, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+
+		return req
+	}
+
+	var tokens []string
+
+	for _, tc := range []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"password grant", newTokenRequest(http.MethodPost, url.Values{"grant_type": {"password"}, "username": {"user"}, "password": {"secret"}}), http.StatusOK},
+		{"refresh token grant", newTokenRequest(http.MethodPost, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"identity"}}), http.StatusOK},
+		{"wrong password grant", newTokenRequest(http.MethodPost, url.Values{"grant_type": {"password"}, "username": {"user"}, "password": {"wrong"}}), http.StatusUnauthorized},
+		{"wrong refresh token grant", newTokenRequest(http.MethodPost, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"wrong"}}), http.StatusUnauthorized},
+		{"anonymous", newTokenRequest(http.MethodGet, nil), http.StatusUnauthorized},
+	} {
+		status, token := getToken(tc.req)
+		if status != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, status)
+		}
+
+		if status == http.StatusOK {
+			tokens = append(tokens, token)
+		}
+	}
+
+	basic := newTokenRequest(http.MethodGet, nil)
+	basic.SetBasicAuth("user", "secret")
+
+	if status, token := getToken(basic); status != http.StatusOK {
+		t.Errorf("basic authentication: expected status 200, got %d", status)
+	} else {
+		tokens = append(tokens, token)
+	}
+
+	for _, token := range append(tokens, "registry") {
+		req := manifestRequest(t, server)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		if status, _, _ := do(t, req); status != http.StatusOK {
+			t.Errorf("token %q: expected status 200, got %d", token, status)
+		}
+	}
+
+	for _, token := range []string{"identity", "wrong"} {
+		req := manifestRequest(t, server)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		if status, _, _ := do(t, req); status != http.StatusUnauthorized {
+			t.Errorf("token %q: expected status 401, got %d", token, status)
+		}
+	}
+
+	req := manifestRequest(t, server)
+	req.SetBasicAuth("user", "secret")
+
+	if status, _, _ := do(t, req); status != http.StatusUnauthorized {
+		t.Errorf("expected basic authentication to be rejected, got %d", status)
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry implements a read-only OCI distribution registry, which
+// serves the images of OCI image layouts and docker save archives, so that
+// container runtimes can pull them without network access.
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+)
+
+// Registry serves the loaded images by the OCI distribution API.
+//
+// The images are served under the path of their names without the registry
+// host, for example the image registry.k8s.io/pause:3.10 as pause:3.10.
+// Manifests can be pulled by tag or digest, and blobs by digest.
+type Registry struct {
+	mu sync.RWMutex
+	// tags maps the repositories to their tags and manifest digests.
+	tags map[string]map[string]digest.Digest
+	// manifests maps the manifest digests to their media types.
+	manifests map[digest.Digest]string
+	blobs     map[digest.Digest]*blob
+
+	// auth is the required authentication, and token the bearer token issued
+	// by the token endpoint.
+	auth  *Auth
+	token string
+
+	listener net.Listener
+	server   *http.Server
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{
+		tags:      map[string]map[string]digest.Digest{},
+		manifests: map[digest.Digest]string{},
+		blobs:     map[digest.Digest]*blob{},
+	}
+}
+
+// Start serves the registry on the TCP address, like 127.0.0.1:0.
+func (r *Registry) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", address, err)
+	}
+
+	r.listener = listener
+	r.server = &http.Server{Handler: r, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := r.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("Serving the registry on %s failed: %v", listener.Addr(), err)
+		}
+	}()
+
+	return nil
+}
+
+// Address returns the host:port the registry is served on.
+func (r *Registry) Address() string {
+	if r.listener == nil {
+		return ""
+	}
+
+	return r.listener.Addr().String()
+}
+
+// Close stops serving the registry.
+func (r *Registry) Close() error {
+	if r.server == nil {
+		return nil
+	}
+
+	if err := r.server.Close(); err != nil {
+		return fmt.Errorf("close registry: %w", err)
+	}
+
+	return nil
+}
+
+// Images returns the names of the served images as repository:tag.
+func (r *Registry) Images() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []string
+
+	for repository, tags := range r.tags {
+		for tag := range tags {
+			images = append(images, repository+":"+tag)
+		}
+	}
+
+	slices.Sort(images)
+
+	return images
+}
+
+// ServeHTTP implements the pull endpoints of the OCI distribution API.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == tokenPath {
+		r.serveToken(w, req)
+
+		return
+	}
+
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+
+	if !r.authorize(w, req) {
+		return
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "the registry is read-only")
+
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown path")
+
+		return
+	}
+
+	if path == "" {
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	if i := strings.LastIndex(path, "/manifests/"); i > 0 {
+		r.serveManifest(w, req, path[:i], path[i+len("/manifests/"):])
+
+		return
+	}
+
+	if i := strings.LastIndex(path, "/blobs/"); i > 0 {
+		r.serveBlob(w, req, path[i+len("/blobs/"):])
+
+		return
+	}
+
+	if repository, ok := strings.CutSuffix(path, "/tags/list"); ok {
+		r.serveTags(w, repository)
+
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "unknown path")
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, repository, ref string) {
+	r.mu.RLock()
+
+	dgst, err := digest.Parse(ref)
+	if err != nil {
+		dgst = r.tags[repository][ref]
+	}
+
+	mediaType, ok := r.manifests[dgst]
+	b := r.blobs[dgst]
+
+	r.mu.RUnlock()
+
+	if !ok || b == nil {
+		writeError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", fmt.Sprintf("manifest %s:%s not found", repository, ref))
+
+		return
+	}
+
+	serveContent(w, req, b, dgst, mediaType)
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, ref string) {
+	dgst, err := digest.Parse(ref)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+
+		return
+	}
+
+	r.mu.RLock()
+	b := r.blobs[dgst]
+	r.mu.RUnlock()
+
+	if b == nil {
+		writeError(w, http.StatusNotFound, "BLOB_UNKNOWN", fmt.Sprintf("blob %s not found", dgst))
+
+		return
+	}
+
+	serveContent(w, req, b, dgst, "application/octet-stream")
+}
+
+func (r *Registry) serveTags(w http.ResponseWriter, repository string) {
+	r.mu.RLock()
+
+	tags := make([]string, 0, len(r.tags[repository]))
+	for tag := range r.tags[repository] {
+		tags = append(tags, tag)
+	}
+
+	r.mu.RUnlock()
+
+	if len(tags) == 0 {
+		writeError(w, http.StatusNotFound, "NAME_UNKNOWN", fmt.Sprintf("repository %s not found", repository))
+
+		return
+	}
+
+	slices.Sort(tags)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": tags}); err != nil {
+		return
+	}
+}
+
+func serveContent(w http.ResponseWriter, req *http.Request, b *blob, dgst digest.Digest, contentType string) {
+	content, err := b.open()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "UNKNOWN", err.Error())
+
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Docker-Content-Digest", dgst.String())
+	w.Header().Set("ETag", `"`+dgst.String()+`"`)
+
+	http.ServeContent(w, req, "", time.Time{}, content)
+}
+
+// writeError writes an error response of the OCI distribution API.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	body := map[string]any{"errors": []map[string]string{{"code": code, "message": message}}}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		return
+	}
+}
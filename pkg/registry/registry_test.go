@@ -0,0 +1,394 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+var (
+	testConfig = []byte(`{"architecture":"amd64","os":"linux"}`)
+	testLayer  = []byte("layer content")
+)
+
+func writeFile(t *testing.T, file string, data []byte) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// writeLayout writes an OCI image layout with one image named by the
+// annotations and returns the manifest digest.
+func writeLayout(t *testing.T, dir string, annotations map[string]string) digest.Digest {
+	t.Helper()
+
+	writeBlob := func(data []byte) descriptor {
+		dgst := digest.FromBytes(data)
+		writeFile(t, filepath.Join(dir, "blobs", "sha256", dgst.Encoded()), data)
+
+		return descriptor{Digest: dgst, Size: int64(len(data))}
+	}
+
+	config := writeBlob(testConfig)
+	config.MediaType = mediaTypeImageConfig
+	layer := writeBlob(testLayer)
+	layer.MediaType = mediaTypeLayer
+
+	m := writeBlob(mustMarshal(t, manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageManifest,
+		Config:        &config,
+		Layers:        []descriptor{layer},
+	}))
+	m.MediaType = mediaTypeImageManifest
+	m.Annotations = annotations
+
+	writeFile(t, filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`))
+	writeFile(t, filepath.Join(dir, "index.json"), mustMarshal(t, manifest{SchemaVersion: 2, Manifests: []descriptor{m}}))
+
+	return m.Digest
+}
+
+// writeDockerArchive writes a docker save archive without OCI image layout.
+func writeDockerArchive(t *testing.T, file string, repoTags ...string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"manifest.json", mustMarshal(t, []dockerManifest{{Config: "config.json", RepoTags: repoTags, Layers: []string{"layer/layer.tar"}}})},
+		{"config.json", testConfig},
+		{"layer/layer.tar", testLayer},
+	}
+
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, file, buf.Bytes())
+}
+
+func get(t *testing.T, baseURL, method, path string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), method, baseURL+path, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+func TestLoadLayout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	manifestDigest := writeLayout(t, dir, map[string]string{
+		annotationImageName: "registry.k8s.io/e2e-test-images/busybox:1.29-2",
+		annotationRefName:   "1.29-2",
+	})
+
+	r := New()
+
+	names, err := r.Load(dir)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if !slices.Equal(names, []string{"e2e-test-images/busybox:1.29-2"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, _ := get(t, server.URL, http.MethodGet, "/v2/")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Docker-Distribution-API-Version") != "registry/2.0" {
+		t.Fatalf("unexpected version check response: %v %v", resp.StatusCode, resp.Header)
+	}
+
+	for _, ref := range []string{"1.29-2", manifestDigest.String()} {
+		resp, body := get(t, server.URL, http.MethodGet, "/v2/e2e-test-images/busybox/manifests/"+ref)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status of manifest %s: %d", ref, resp.StatusCode)
+		}
+
+		if resp.Header.Get("Content-Type") != mediaTypeImageManifest {
+			t.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+		}
+
+		if resp.Header.Get("Docker-Content-Digest") != manifestDigest.String() || digest.FromBytes(body) != manifestDigest {
+			t.Errorf("unexpected manifest %s: %s", resp.Header.Get("Docker-Content-Digest"), body)
+		}
+	}
+
+	layerDigest := digest.FromBytes(testLayer)
+
+	resp, body := get(t, server.URL, http.MethodGet, "/v2/e2e-test-images/busybox/blobs/"+layerDigest.String())
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, testLayer) {
+		t.Fatalf("unexpected layer: %d %q", resp.StatusCode, body)
+	}
+
+	resp, _ = get(t, server.URL, http.MethodHead, "/v2/e2e-test-images/busybox/blobs/"+layerDigest.String())
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != int64(len(testLayer)) {
+		t.Fatalf("unexpected HEAD response: %d %d", resp.StatusCode, resp.ContentLength)
+	}
+
+	resp, body = get(t, server.URL, http.MethodGet, "/v2/e2e-test-images/busybox/tags/list")
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(body, []byte(`"tags":["1.29-2"]`)) {
+		t.Fatalf("unexpected tags: %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestLoadDockerArchive(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "image.tar")
+	writeDockerArchive(t, file, "busybox:latest", "example.com/test/busybox:v1")
+
+	r := New()
+
+	names, err := r.Load(file)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if !slices.Equal(names, []string{"library/busybox:latest", "test/busybox:v1"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, body := get(t, server.URL, http.MethodGet, "/v2/test/busybox/manifests/v1")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Config == nil || m.Config.Digest != digest.FromBytes(testConfig) || m.Config.Size != int64(len(testConfig)) {
+		t.Errorf("unexpected config: %+v", m.Config)
+	}
+
+	if len(m.Layers) != 1 || m.Layers[0].Digest != digest.FromBytes(testLayer) || m.Layers[0].MediaType != mediaTypeLayer {
+		t.Fatalf("unexpected layers: %+v", m.Layers)
+	}
+
+	for digestData, expected := range map[digest.Digest][]byte{m.Config.Digest: testConfig, m.Layers[0].Digest: testLayer} {
+		resp, body := get(t, server.URL, http.MethodGet, "/v2/test/busybox/blobs/"+digestData.String())
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(body, expected) {
+			t.Errorf("unexpected blob %s: %d %q", digestData, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestLoadTarLayout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeLayout(t, dir, map[string]string{annotationRefName: "example.com/pause:3.10"})
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	if err := tw.AddFS(os.DirFS(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "layout.tar")
+	writeFile(t, file, buf.Bytes())
+
+	names, err := New().Load(file)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if !slices.Equal(names, []string{"pause:3.10"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	unnamed := t.TempDir()
+	writeLayout(t, unnamed, map[string]string{annotationRefName: "latest"})
+
+	empty := t.TempDir()
+
+	gzipFile := filepath.Join(t.TempDir(), "image.tar.gz")
+	writeFile(t, gzipFile, []byte{0x1f, 0x8b, 0x08, 0x00})
+
+	for name, file := range map[string]string{
+		"unnamed":    unnamed,
+		"empty":      empty,
+		"gzip":       gzipFile,
+		"not exists": filepath.Join(empty, "missing"),
+	} {
+		if _, err := New().Load(file); err == nil {
+			t.Errorf("Load of %s should fail", name)
+		}
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeLayout(t, dir, map[string]string{annotationImageName: "docker.io/library/busybox:latest"})
+
+	r := New()
+	if err := r.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	defer r.Close()
+
+	if _, err := r.Load(dir); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/v2/library/busybox/manifests/missing", http.StatusNotFound},
+		{http.MethodGet, "/v2/library/other/manifests/latest", http.StatusNotFound},
+		{http.MethodGet, "/v2/library/busybox/blobs/" + digest.FromString("missing").String(), http.StatusNotFound},
+		{http.MethodGet, "/v2/library/busybox/blobs/invalid", http.StatusBadRequest},
+		{http.MethodGet, "/v2/library/other/tags/list", http.StatusNotFound},
+		{http.MethodPost, "/v2/library/busybox/blobs/uploads/", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/other", http.StatusNotFound},
+	} {
+		resp, body := get(t, "http://"+r.Address(), tc.method, tc.path)
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.status, resp.StatusCode)
+		}
+
+		if !bytes.Contains(body, []byte(`"errors"`)) {
+			t.Errorf("%s %s: expected an error body, got %s", tc.method, tc.path, body)
+		}
+	}
+
+	if images := r.Images(); !slices.Equal(images, []string{"library/busybox:latest"}) {
+		t.Errorf("unexpected images: %v", images)
+	}
+}
+
+func TestAddImage(t *testing.T) {
+	t.Parallel()
+
+	r := New()
+
+	manifestDigest, err := r.AddImage("example.com/test/generated:v1", map[string][]byte{"file": []byte("content")})
+	if err != nil {
+		t.Fatalf("AddImage returned error: %v", err)
+	}
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, body := get(t, server.URL, http.MethodGet, "/v2/test/generated/manifests/v1")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Docker-Content-Digest") != manifestDigest {
+		t.Fatalf("unexpected manifest: %d %s", resp.StatusCode, resp.Header.Get("Docker-Content-Digest"))
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, layer := get(t, server.URL, http.MethodGet, "/v2/test/generated/blobs/"+m.Layers[0].Digest.String())
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected layer status: %d", resp.StatusCode)
+	}
+
+	header, err := tar.NewReader(bytes.NewReader(layer)).Next()
+	if err != nil || header.Name != "file" {
+		t.Fatalf("unexpected layer: %v %v", header, err)
+	}
+
+	resp, config := get(t, server.URL, http.MethodGet, "/v2/test/generated/blobs/"+m.Config.Digest.String())
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(config, []byte(m.Layers[0].Digest.String())) {
+		t.Fatalf("unexpected config: %d %s", resp.StatusCode, config)
+	}
+
+	if _, err := r.AddImage("Invalid Name", nil); err == nil {
+		t.Error("AddImage with an invalid name should fail")
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient writes the spans as OTLP JSON lines, one export request per
+// line, which can be read by the file receivers of OpenTelemetry collectors.
+type fileClient struct {
+	path string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// Start opens the file.
+func (c *fileClient) Start(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == StdoutFile {
+		c.out = os.Stdout
+
+		return nil
+	}
+
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open trace file: %w", err)
+	}
+
+	c.out = file
+
+	return nil
+}
+
+// Stop closes the file.
+func (c *fileClient) Stop(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if closer, ok := c.out.(io.Closer); ok && c.path != StdoutFile {
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("close trace file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UploadTraces appends the spans to the file.
+func (c *fileClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	data, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return fmt.Errorf("marshal spans: %w", err)
+	}
+
+	data, err = hexEncodeIDs(data)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := c.out.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write spans: %w", err)
+	}
+
+	return nil
+}
+
+// hexEncodeIDs replaces the base64 encoded trace and span IDs of the
+// protobuf JSON mapping by the hex encoding required by OTLP JSON.
+func hexEncodeIDs(data []byte) ([]byte, error) {
+	var request any
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("unmarshal spans: %w", err)
+	}
+
+	if err := hexEncodeIDsOf(request); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshal spans: %w", err)
+	}
+
+	return data, nil
+}
+
+func hexEncodeIDsOf(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				id, ok := field.(string)
+				if !ok {
+					continue
+				}
+
+				raw, err := base64.StdEncoding.DecodeString(id)
+				if err != nil {
+					return fmt.Errorf("decode %s: %w", key, err)
+				}
+
+				v[key] = hex.EncodeToString(raw)
+			default:
+				if err := hexEncodeIDsOf(field); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := hexEncodeIDsOf(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
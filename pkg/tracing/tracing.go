@@ -18,19 +18,75 @@ package tracing
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"google.golang.org/grpc/credentials"
 )
 
-// Init initializes OpenTelemetry tracing.
+const (
+	// ProtocolGRPC exports the spans by OTLP over gRPC.
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP exports the spans by OTLP over HTTP with protobuf payloads.
+	ProtocolHTTP = "http/protobuf"
+
+	// DefaultGRPCEndpoint and DefaultHTTPEndpoint are the collector
+	// addresses used if neither the options nor the environment set one.
+	DefaultGRPCEndpoint = "127.0.0.1:4317"
+	DefaultHTTPEndpoint = "127.0.0.1:4318"
+
+	// StdoutFile writes the spans to stdout instead of a file.
+	StdoutFile = "-"
+)
+
+// Options configure the exporter of the spans. Unset options are taken from
+// the standard OTEL_EXPORTER_OTLP_* environment variables, if set.
+type Options struct {
+	// Protocol is ProtocolGRPC or ProtocolHTTP, defaulting to
+	// OTEL_EXPORTER_OTLP_TRACES_PROTOCOL, OTEL_EXPORTER_OTLP_PROTOCOL or gRPC.
+	Protocol string
+	// Endpoint is the address of the collector as host:port or as http://
+	// or https:// URL. A host:port endpoint uses TLS only if a CA or client
+	// certificate is set.
+	Endpoint string
+	// CAFile, CertFile and KeyFile are the paths of the CA certificate to
+	// verify the collector and of the client certificate and key.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// Headers are sent with every export request.
+	Headers map[string]string
+	// File is the path the spans are appended to as OTLP JSON lines, or
+	// StdoutFile. If set, no collector is used.
+	File string
+	// SamplingRate is the number of samples per million spans, or negative
+	// to always sample.
+	SamplingRate int
+}
+
+// Init initializes OpenTelemetry tracing with an insecure OTLP gRPC exporter.
 func Init(ctx context.Context, collectorAddress string, samplingRate int) (*trace.TracerProvider, error) {
+	return InitWithOptions(ctx, &Options{
+		Protocol:     ProtocolGRPC,
+		Endpoint:     collectorAddress,
+		SamplingRate: samplingRate,
+	})
+}
+
+// InitWithOptions initializes OpenTelemetry tracing.
+func InitWithOptions(ctx context.Context, opts *Options) (*trace.TracerProvider, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
 		return nil, fmt.Errorf("get hostname: %w", err)
@@ -45,10 +101,7 @@ func Init(ctx context.Context, collectorAddress string, samplingRate int) (*trac
 		semconv.ProcessPIDKey.Int64(int64(os.Getpid())),
 	)
 
-	exporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithEndpoint(collectorAddress),
-		otlptracegrpc.WithInsecure(),
-	)
+	exporter, err := newExporter(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("create tracing exporter: %w", err)
 	}
@@ -57,9 +110,9 @@ func Init(ctx context.Context, collectorAddress string, samplingRate int) (*trac
 	sampler := trace.NeverSample()
 
 	// Or, emit spans for a fraction of transactions.
-	if samplingRate > 0 {
-		sampler = trace.TraceIDRatioBased(float64(samplingRate) / float64(1000000))
-	} else if samplingRate < 0 {
+	if opts.SamplingRate > 0 {
+		sampler = trace.TraceIDRatioBased(float64(opts.SamplingRate) / float64(1000000))
+	} else if opts.SamplingRate < 0 {
 		sampler = trace.AlwaysSample()
 	}
 
@@ -79,3 +132,148 @@ func Init(ctx context.Context, collectorAddress string, samplingRate int) (*trac
 
 	return tp, nil
 }
+
+// ContextWithEnvParent returns a context with the remote parent span of the
+// W3C trace context in the TRACEPARENT and TRACESTATE environment variables,
+// so that the spans of an invocation from a traced script are nested under
+// the span of the script.
+func ContextWithEnvParent(ctx context.Context) context.Context {
+	traceParent, ok := os.LookupEnv("TRACEPARENT")
+	if !ok {
+		return ctx
+	}
+
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{
+		"traceparent": traceParent,
+		"tracestate":  os.Getenv("TRACESTATE"),
+	})
+}
+
+func newExporter(ctx context.Context, opts *Options) (*otlptrace.Exporter, error) {
+	if opts.File != "" {
+		return otlptrace.New(ctx, &fileClient{path: opts.File})
+	}
+
+	protocol := opts.Protocol
+	if protocol == "" {
+		protocol = envProtocol()
+	}
+
+	tlsConfig, err := clientTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" && !envHasEndpoint() {
+		endpoint = DefaultGRPCEndpoint
+		if protocol == ProtocolHTTP {
+			endpoint = DefaultHTTPEndpoint
+		}
+	}
+
+	isURL := strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://")
+
+	switch protocol {
+	case ProtocolGRPC:
+		grpcOpts := []otlptracegrpc.Option{}
+
+		if isURL {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithEndpointURL(endpoint))
+		} else if endpoint != "" {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithEndpoint(endpoint))
+			if tlsConfig == nil {
+				grpcOpts = append(grpcOpts, otlptracegrpc.WithInsecure())
+			}
+		}
+
+		if tlsConfig != nil {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+
+		if len(opts.Headers) > 0 {
+			grpcOpts = append(grpcOpts, otlptracegrpc.WithHeaders(opts.Headers))
+		}
+
+		return otlptracegrpc.New(ctx, grpcOpts...)
+
+	case ProtocolHTTP:
+		httpOpts := []otlptracehttp.Option{}
+
+		if isURL {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(endpoint))
+		} else if endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpoint(endpoint))
+			if tlsConfig == nil {
+				httpOpts = append(httpOpts, otlptracehttp.WithInsecure())
+			}
+		}
+
+		if tlsConfig != nil {
+			httpOpts = append(httpOpts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		}
+
+		if len(opts.Headers) > 0 {
+			httpOpts = append(httpOpts, otlptracehttp.WithHeaders(opts.Headers))
+		}
+
+		return otlptracehttp.New(ctx, httpOpts...)
+
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q, expected %s or %s", protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}
+
+// envProtocol returns the protocol of the OTLP environment variables.
+func envProtocol() string {
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if protocol := os.Getenv(env); protocol != "" {
+			return protocol
+		}
+	}
+
+	return ProtocolGRPC
+}
+
+// envHasEndpoint returns true if the OTLP environment variables set the
+// endpoint, which is used by the exporters if not set by the options.
+func envHasEndpoint() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != ""
+}
+
+// clientTLSConfig returns the TLS config for the collector, or nil if
+// neither a CA nor a client certificate is set.
+func clientTLSConfig(opts *Options) (*tls.Config, error) {
+	if opts.CAFile == "" && opts.CertFile == "" && opts.KeyFile == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.CAFile != "" {
+		ca, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate: %w", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, errors.New("the client certificate and key must be set together")
+		}
+
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
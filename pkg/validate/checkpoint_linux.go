@@ -0,0 +1,255 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/framework"
+)
+
+const (
+	// checkpointTimeout is the timeout in seconds of a checkpoint.
+	checkpointTimeout = 60
+	// checkpointCounterFile is written by the counter process of the
+	// checkpointed containers.
+	checkpointCounterFile = "/tmp/counter"
+)
+
+// checkpointCounterCmd increments the counter in checkpointCounterFile every
+// second, to verify that the process keeps running after the checkpoint.
+var checkpointCounterCmd = []string{
+	"sh", "-c", "i=0; while true; do echo $i > " + checkpointCounterFile + "; i=$((i+1)); sleep 1; done",
+}
+
+// checkpointMetadataFiles are the metadata files of a checkpoint archive in
+// the format of checkpointctl, besides the CRIU images in checkpoint/.
+var checkpointMetadataFiles = []string{"config.dump", "spec.dump"}
+
+var _ = framework.KubeDescribe("Checkpoint [Feature:Checkpoint]", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var (
+		rc internalapi.RuntimeService
+		ic internalapi.ImageManagerService
+	)
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support CheckpointContainer", func() {
+		var (
+			podID         string
+			podConfig     *runtimeapi.PodSandboxConfig
+			checkpointDir string
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			podID, podConfig = framework.CreatePodSandboxForContainer(ctx, rc)
+
+			var err error
+
+			checkpointDir, err = os.MkdirTemp("", "checkpoint-"+podID)
+			framework.ExpectNoError(err, "failed to create checkpoint directory")
+		})
+
+		AfterEach(func(ctx SpecContext) {
+			framework.CleanupPodSandbox(ctx, rc, podID)
+			Expect(os.RemoveAll(checkpointDir)).To(Succeed())
+		})
+
+		It("runtime should checkpoint a running container to an archive", func(ctx SpecContext) {
+			containerID := createCounterContainer(ctx, rc, ic, podID, podConfig)
+			startContainer(ctx, rc, containerID)
+
+			Eventually(func() (int, error) {
+				return readCounter(ctx, rc, containerID)
+			}, time.Minute, time.Second).Should(BeNumerically(">", 0))
+
+			location := filepath.Join(checkpointDir, "checkpoint.tar")
+			checkpointContainer(ctx, rc, containerID, location)
+
+			By("check the checkpoint archive")
+
+			entries := listCheckpointArchive(location)
+			for _, file := range checkpointMetadataFiles {
+				Expect(entries).To(ContainElement(file), "the checkpoint archive should contain %s", file)
+			}
+
+			Expect(entries).To(ContainElement(HavePrefix("checkpoint/")), "the checkpoint archive should contain the CRIU images")
+
+			By("check that the container keeps running")
+			Expect(getContainerStatus(ctx, rc, containerID).GetState()).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+
+			counter, err := readCounter(ctx, rc, containerID)
+			framework.ExpectNoError(err, "failed to read the counter")
+			Eventually(func() (int, error) {
+				return readCounter(ctx, rc, containerID)
+			}, time.Minute, time.Second).Should(BeNumerically(">", counter), "the counter should keep increasing")
+		})
+
+		It("runtime should fail to checkpoint a container which is not running", func(ctx SpecContext) {
+			containerID := createCounterContainer(ctx, rc, ic, podID, podConfig)
+
+			location := filepath.Join(checkpointDir, "checkpoint.tar")
+
+			err := tryCheckpointContainer(ctx, rc, containerID, location)
+			Expect(err).To(HaveOccurred(), "checkpointing a created container should fail")
+			expectCheckpointError(err, codes.FailedPrecondition, codes.Unknown)
+			Expect(location).NotTo(BeAnExistingFile())
+
+			By("check that the container is still created")
+			Expect(getContainerStatus(ctx, rc, containerID).GetState()).To(Equal(runtimeapi.ContainerState_CONTAINER_CREATED))
+		})
+
+		It("runtime should fail to checkpoint a container which does not exist", func(ctx SpecContext) {
+			err := tryCheckpointContainer(ctx, rc, framework.NewUUID(), filepath.Join(checkpointDir, "checkpoint.tar"))
+			Expect(err).To(HaveOccurred(), "checkpointing a missing container should fail")
+			expectCheckpointError(err, codes.NotFound)
+		})
+
+		It("runtime should fail to checkpoint a container to an invalid path", func(ctx SpecContext) {
+			containerID := createCounterContainer(ctx, rc, ic, podID, podConfig)
+			startContainer(ctx, rc, containerID)
+
+			location := filepath.Join(checkpointDir, "missing", "checkpoint.tar")
+
+			err := tryCheckpointContainer(ctx, rc, containerID, location)
+			Expect(err).To(HaveOccurred(), "checkpointing to a missing directory should fail")
+			expectCheckpointError(err, codes.InvalidArgument, codes.NotFound, codes.Unknown)
+			Expect(location).NotTo(BeAnExistingFile())
+
+			By("check that the container keeps running")
+			Expect(getContainerStatus(ctx, rc, containerID).GetState()).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+		})
+	})
+})
+
+// createCounterContainer creates a container running the counter process.
+func createCounterContainer(ctx context.Context, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig) string {
+	By("create a container running a counter")
+
+	containerName := "container-for-checkpoint-test-" + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.TestContext.TestImageList.DefaultTestContainerImage},
+		Command:  checkpointCounterCmd,
+		Linux:    &runtimeapi.LinuxContainerConfig{},
+	}
+
+	return framework.CreateContainer(ctx, rc, ic, containerConfig, podID, podConfig)
+}
+
+// readCounter returns the value of the counter of the container.
+func readCounter(ctx context.Context, rc internalapi.RuntimeService, containerID string) (int, error) {
+	stdout, _, err := rc.ExecSync(ctx, containerID, []string{"cat", checkpointCounterFile}, time.Duration(defaultExecSyncTimeout)*time.Second)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(stdout)))
+}
+
+// tryCheckpointContainer checkpoints the container to the location and
+// returns the error. It skips the spec if the runtime does not implement
+// CheckpointContainer.
+func tryCheckpointContainer(ctx context.Context, rc internalapi.RuntimeService, containerID, location string) error {
+	By("checkpoint container " + containerID + " to " + location)
+
+	err := rc.CheckpointContainer(ctx, &runtimeapi.CheckpointContainerRequest{
+		ContainerId: containerID,
+		Location:    location,
+		Timeout:     checkpointTimeout,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		Skip("The runtime does not support CheckpointContainer: " + err.Error())
+	}
+
+	return err
+}
+
+// expectCheckpointError verifies that a failed checkpoint returned one of the
+// codes, which differ between the runtimes for some failures.
+func expectCheckpointError(err error, expected ...codes.Code) {
+	framework.Logf("CheckpointContainer failed with code %s: %v", status.Code(err), err)
+	Expect(status.Code(err)).To(BeElementOf(expected), "unexpected error code of CheckpointContainer: %v", err)
+}
+
+// checkpointContainer checkpoints the container to the location.
+func checkpointContainer(ctx context.Context, rc internalapi.RuntimeService, containerID, location string) {
+	err := tryCheckpointContainer(ctx, rc, containerID, location)
+	framework.ExpectNoError(err, "failed to checkpoint container %q", containerID)
+	Expect(location).To(BeAnExistingFile(), "the checkpoint archive should exist")
+}
+
+// listCheckpointArchive returns the names of the entries of the checkpoint
+// archive, which may be compressed with gzip.
+func listCheckpointArchive(location string) []string {
+	file, err := os.Open(location)
+	framework.ExpectNoError(err, "failed to open the checkpoint archive")
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	var archive io.Reader = reader
+
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		framework.ExpectNoError(err, "failed to decompress the checkpoint archive")
+
+		defer gzipReader.Close()
+
+		archive = gzipReader
+	}
+
+	var entries []string
+
+	tarReader := tar.NewReader(archive)
+
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		framework.ExpectNoError(err, "failed to read the checkpoint archive")
+
+		entries = append(entries, filepath.Clean(header.Name))
+	}
+
+	return entries
+}
@@ -185,11 +185,41 @@ var _ = framework.AddBeforeSuiteCallback(func() {
 		"registry.k8s.io/pause:3.9",
 		"k8s.gcr.io/pause:3.9",
 	}
+
+	// Expect the images under the registry prefix, which is where they are
+	// pulled from.
+	if framework.TestContext.RegistryPrefix != framework.DefaultRegistryPrefix {
+		testImageWithoutTag = framework.WithRegistryPrefix(testImageWithoutTag)
+		testImageWithTag = framework.WithRegistryPrefix(testImageWithTag)
+		testImageWithDigest = framework.WithRegistryPrefix(testImageWithDigest)
+		testImageWithAllReferences = framework.WithRegistryPrefix(testImageWithAllReferences)
+		testDifferentTagDifferentImageList = withRegistryPrefix(testDifferentTagDifferentImageList)
+		testDifferentTagSameImageList = withRegistryPrefix(testDifferentTagSameImageList)
+
+		// The local registry is reachable as a second registry by localhost.
+		if alias := framework.LocalRegistryAlias(); alias != "" {
+			testSameImageDifferentRegistries = []string{
+				framework.WithRegistryPrefix(testSameImageDifferentRegistries[0]),
+				alias + "/pause:3.9",
+			}
+		}
+	}
+
 	testImagePodSandbox = &runtimeapi.PodSandboxConfig{
 		Labels: framework.DefaultPodLabels,
 	}
 })
 
+// withRegistryPrefix returns the image names under the registry prefix.
+func withRegistryPrefix(imageNames []string) []string {
+	prefixed := make([]string, 0, len(imageNames))
+	for _, imageName := range imageNames {
+		prefixed = append(prefixed, framework.WithRegistryPrefix(imageName))
+	}
+
+	return prefixed
+}
+
 // Networking test constants
 
 const (
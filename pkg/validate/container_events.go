@@ -0,0 +1,341 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/framework"
+)
+
+// containerEventTimeout is the time to wait for container events.
+const containerEventTimeout = time.Minute
+
+var containerLifecycleEvents = []runtimeapi.ContainerEventType{
+	runtimeapi.ContainerEventType_CONTAINER_CREATED_EVENT,
+	runtimeapi.ContainerEventType_CONTAINER_STARTED_EVENT,
+	runtimeapi.ContainerEventType_CONTAINER_STOPPED_EVENT,
+	runtimeapi.ContainerEventType_CONTAINER_DELETED_EVENT,
+}
+
+var _ = framework.KubeDescribe("Container Events", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var (
+		rc internalapi.RuntimeService
+		ic internalapi.ImageManagerService
+	)
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support GetContainerEvents", func() {
+		var (
+			podID     string
+			podConfig *runtimeapi.PodSandboxConfig
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			podID, podConfig = framework.CreatePodSandboxForContainer(ctx, rc)
+		})
+
+		AfterEach(func(ctx SpecContext) {
+			framework.CleanupPodSandbox(ctx, rc, podID)
+		})
+
+		It("runtime should emit the lifecycle events of a container in order", func(ctx SpecContext) {
+			events := subscribeContainerEvents(ctx, rc)
+			waitContainerEventSubscribers(ctx, rc, ic, podID, podConfig, events)
+
+			containerID := runContainerLifecycle(ctx, rc, ic, podID, podConfig)
+
+			By("wait for the created, started, stopped and deleted events")
+			Eventually(func() []runtimeapi.ContainerEventType {
+				return events.typesFor(containerID)
+			}, containerEventTimeout, time.Second).Should(Equal(containerLifecycleEvents))
+
+			By("check that the events are ordered by their timestamps")
+
+			var previous int64
+			for _, event := range events.eventsFor(containerID) {
+				Expect(event.GetCreatedAt()).To(BeNumerically(">=", previous),
+					"event %s is older than the previous event", event.GetContainerEventType())
+
+				previous = event.GetCreatedAt()
+			}
+		})
+
+		It("runtime should embed a pod and container status consistent with ContainerStatus", func(ctx SpecContext) {
+			events := subscribeContainerEvents(ctx, rc)
+			waitContainerEventSubscribers(ctx, rc, ic, podID, podConfig, events)
+
+			By("create and start a container")
+
+			containerID := framework.CreateDefaultContainer(ctx, rc, ic, podID, podConfig, "container-for-events-test-")
+			startContainer(ctx, rc, containerID)
+
+			By("wait for the started event")
+
+			var started *runtimeapi.ContainerEventResponse
+
+			Eventually(func() *runtimeapi.ContainerEventResponse {
+				started = events.find(containerID, runtimeapi.ContainerEventType_CONTAINER_STARTED_EVENT)
+
+				return started
+			}, containerEventTimeout, time.Second).ShouldNot(BeNil())
+
+			By("check the pod sandbox status of the event")
+
+			podStatus := started.GetPodSandboxStatus()
+			Expect(podStatus).NotTo(BeNil(), "the event should contain the pod sandbox status")
+			Expect(podStatus.GetId()).To(Equal(podID))
+			Expect(podStatus.GetMetadata().GetName()).To(Equal(podConfig.GetMetadata().GetName()))
+			Expect(podStatus.GetMetadata().GetNamespace()).To(Equal(podConfig.GetMetadata().GetNamespace()))
+			Expect(podStatus.GetMetadata().GetUid()).To(Equal(podConfig.GetMetadata().GetUid()))
+			Expect(podStatus.GetState()).To(Equal(runtimeapi.PodSandboxState_SANDBOX_READY))
+
+			By("check the container status of the event against ContainerStatus")
+
+			eventStatus := eventContainerStatus(started, containerID)
+			Expect(eventStatus).NotTo(BeNil(), "the event should contain the status of container %q", containerID)
+
+			expected := getContainerStatus(ctx, rc, containerID)
+			Expect(eventStatus.GetState()).To(Equal(runtimeapi.ContainerState_CONTAINER_RUNNING))
+			Expect(eventStatus.GetMetadata().GetName()).To(Equal(expected.GetMetadata().GetName()))
+			Expect(eventStatus.GetMetadata().GetAttempt()).To(Equal(expected.GetMetadata().GetAttempt()))
+			Expect(eventStatus.GetImage().GetImage()).To(Equal(expected.GetImage().GetImage()))
+			Expect(eventStatus.GetImageRef()).To(Equal(expected.GetImageRef()))
+			Expect(eventStatus.GetCreatedAt()).To(Equal(expected.GetCreatedAt()))
+			Expect(eventStatus.GetStartedAt()).To(Equal(expected.GetStartedAt()))
+			framework.ExpectSubset(eventStatus.GetLabels(), expected.GetLabels(), "labels")
+			framework.ExpectSubset(eventStatus.GetAnnotations(), expected.GetAnnotations(), "annotations")
+		})
+
+		It("runtime should send all events to every concurrent subscriber", func(ctx SpecContext) {
+			subscribers := []*containerEventRecorder{
+				subscribeContainerEvents(ctx, rc),
+				subscribeContainerEvents(ctx, rc),
+				subscribeContainerEvents(ctx, rc),
+			}
+			waitContainerEventSubscribers(ctx, rc, ic, podID, podConfig, subscribers...)
+
+			containerID := runContainerLifecycle(ctx, rc, ic, podID, podConfig)
+
+			for i, events := range subscribers {
+				By(fmt.Sprintf("wait for the events of subscriber %d", i+1))
+				Eventually(func() []runtimeapi.ContainerEventType {
+					return events.typesFor(containerID)
+				}, containerEventTimeout, time.Second).Should(Equal(containerLifecycleEvents))
+			}
+		})
+
+		It("runtime should emit a stopped event for a container exiting on its own", func(ctx SpecContext) {
+			events := subscribeContainerEvents(ctx, rc)
+			waitContainerEventSubscribers(ctx, rc, ic, podID, podConfig, events)
+
+			By("create a container exiting immediately")
+
+			containerName := "container-for-events-exit-test-" + framework.NewUUID()
+			containerConfig := &runtimeapi.ContainerConfig{
+				Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+				Image:    &runtimeapi.ImageSpec{Image: framework.TestContext.TestImageList.DefaultTestContainerImage},
+				Command:  echoHelloCmd,
+			}
+			if runtime.GOOS != framework.OSWindows || framework.TestContext.IsLcow {
+				containerConfig.Linux = &runtimeapi.LinuxContainerConfig{}
+			}
+
+			containerID := framework.CreateContainer(ctx, rc, ic, containerConfig, podID, podConfig)
+			startContainer(ctx, rc, containerID)
+
+			By("wait for the stopped event without stopping the container")
+			Eventually(func() []runtimeapi.ContainerEventType {
+				return events.typesFor(containerID)
+			}, containerEventTimeout, time.Second).Should(Equal(containerLifecycleEvents[:3]))
+
+			By("check the exit code in the container status of the event")
+
+			stopped := events.find(containerID, runtimeapi.ContainerEventType_CONTAINER_STOPPED_EVENT)
+
+			eventStatus := eventContainerStatus(stopped, containerID)
+			Expect(eventStatus).NotTo(BeNil(), "the event should contain the status of container %q", containerID)
+			Expect(eventStatus.GetState()).To(Equal(runtimeapi.ContainerState_CONTAINER_EXITED))
+			Expect(eventStatus.GetExitCode()).To(BeZero())
+		})
+	})
+})
+
+// runContainerLifecycle creates, starts, stops and removes a container.
+func runContainerLifecycle(ctx context.Context, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig) string {
+	By("create, start, stop and remove a container")
+
+	containerID := framework.CreateDefaultContainer(ctx, rc, ic, podID, podConfig, "container-for-events-test-")
+	startContainer(ctx, rc, containerID)
+	testStopContainer(ctx, rc, containerID)
+	removeContainer(ctx, rc, containerID)
+
+	return containerID
+}
+
+// eventContainerStatus returns the status of the container embedded in the
+// event, or nil.
+func eventContainerStatus(event *runtimeapi.ContainerEventResponse, containerID string) *runtimeapi.ContainerStatus {
+	for _, containerStatus := range event.GetContainersStatuses() {
+		if containerStatus.GetId() == containerID {
+			return containerStatus
+		}
+	}
+
+	return nil
+}
+
+// containerEventRecorder records the events of a GetContainerEvents stream.
+type containerEventRecorder struct {
+	mu     sync.Mutex
+	events []*runtimeapi.ContainerEventResponse
+}
+
+// subscribeContainerEvents records the container events until the end of the
+// spec. It skips the spec if the runtime does not implement GetContainerEvents.
+func subscribeContainerEvents(ctx context.Context, rc internalapi.RuntimeService) *containerEventRecorder {
+	By("subscribe to the container events")
+
+	ctx, cancel := context.WithCancel(ctx)
+	DeferCleanup(cancel)
+
+	recorder := &containerEventRecorder{}
+	eventsCh := make(chan *runtimeapi.ContainerEventResponse, 100)
+	established := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- rc.GetContainerEvents(ctx, eventsCh, func(runtimeapi.RuntimeService_GetContainerEventsClient) {
+			close(established)
+		})
+	}()
+
+	done := make(chan error, 1)
+
+	// Drain the events until the stream ends, so that the client never blocks.
+	go func() {
+		for {
+			select {
+			case event := <-eventsCh:
+				recorder.mu.Lock()
+				recorder.events = append(recorder.events, event)
+				recorder.mu.Unlock()
+			case err := <-errCh:
+				done <- err
+
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-established:
+	case err := <-done:
+		if status.Code(err) == codes.Unimplemented {
+			Skip("The runtime does not support GetContainerEvents")
+		}
+
+		framework.Failf("Failed to subscribe to the container events: %v", err)
+	case <-time.After(containerEventTimeout):
+		framework.Failf("Timed out subscribing to the container events")
+	}
+
+	return recorder
+}
+
+// waitContainerEventSubscribers waits until all subscribers receive the
+// events of a marker container. The runtime may register a subscriber only
+// after the stream has been established on the client, so a new marker is
+// created on every attempt until all subscribers received one of them.
+func waitContainerEventSubscribers(ctx context.Context, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, subscribers ...*containerEventRecorder) {
+	By("wait for the subscribers to receive the events of a marker container")
+
+	var markerIDs []string
+
+	Eventually(func() bool {
+		markerID := framework.CreateDefaultContainer(ctx, rc, ic, podID, podConfig, "container-for-events-marker-")
+		removeContainer(ctx, rc, markerID)
+
+		markerIDs = append(markerIDs, markerID)
+
+		for _, events := range subscribers {
+			if !slices.ContainsFunc(markerIDs, func(id string) bool {
+				return events.find(id, runtimeapi.ContainerEventType_CONTAINER_CREATED_EVENT) != nil
+			}) {
+				return false
+			}
+		}
+
+		return true
+	}, containerEventTimeout, time.Second).Should(BeTrue(), "the subscribers should receive the events of a marker container")
+}
+
+// eventsFor returns the recorded events of the container.
+func (r *containerEventRecorder) eventsFor(containerID string) []*runtimeapi.ContainerEventResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []*runtimeapi.ContainerEventResponse
+
+	for _, event := range r.events {
+		if event.GetContainerId() == containerID {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// typesFor returns the types of the recorded events of the container.
+func (r *containerEventRecorder) typesFor(containerID string) []runtimeapi.ContainerEventType {
+	var types []runtimeapi.ContainerEventType
+
+	for _, event := range r.eventsFor(containerID) {
+		types = append(types, event.GetContainerEventType())
+	}
+
+	return types
+}
+
+// find returns the first recorded event of the type of the container, or nil.
+func (r *containerEventRecorder) find(containerID string, eventType runtimeapi.ContainerEventType) *runtimeapi.ContainerEventResponse {
+	for _, event := range r.eventsFor(containerID) {
+		if event.GetContainerEventType() == eventType {
+			return event
+		}
+	}
+
+	return nil
+}
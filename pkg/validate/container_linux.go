@@ -347,19 +347,12 @@ var _ = framework.KubeDescribe("Container Mount Readonly", func() {
 
 		BeforeEach(func(ctx SpecContext) {
 			podID, podConfig = createPrivilegedPodSandbox(ctx, rc, true)
-		})
-
-		AfterEach(func(ctx SpecContext) {
-			framework.CleanupPodSandbox(ctx, rc, podID)
+			DeferCleanup(func(ctx SpecContext) {
+				framework.CleanupPodSandbox(ctx, rc, podID)
+			})
 		})
 
 		testRRO := func(ctx context.Context, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, rro bool) {
-			if rro && !runtimeSupportsRRO(ctx, rc, framework.TestContext.RuntimeHandler) {
-				Skip("runtime does not implement recursive readonly mounts")
-
-				return
-			}
-
 			By("create host path")
 
 			hostPath, clearHostPath := createHostPathForRROMount(podID)
@@ -385,17 +378,11 @@ var _ = framework.KubeDescribe("Container Mount Readonly", func() {
 		It("should support non-recursive readonly mounts", func(ctx SpecContext) {
 			testRRO(ctx, rc, ic, false)
 		})
-		It("should support recursive readonly mounts", func(ctx SpecContext) {
+		It("should support recursive readonly mounts", framework.RequireCapabilities(framework.CapabilityRecursiveReadOnlyMounts), func(ctx SpecContext) {
 			testRRO(ctx, rc, ic, true)
 		})
 
 		testRROInvalidPropagation := func(ctx context.Context, prop runtimeapi.MountPropagation) {
-			if !runtimeSupportsRRO(ctx, rc, framework.TestContext.RuntimeHandler) {
-				Skip("runtime does not implement recursive readonly mounts")
-
-				return
-			}
-
 			hostPath, clearHostPath := createHostPathForRROMount(podID)
 			defer clearHostPath() // clean up the TempDir
 
@@ -414,19 +401,13 @@ var _ = framework.KubeDescribe("Container Mount Readonly", func() {
 			createMountContainer(ctx, rc, ic, podID, podConfig, mounts, expectErr)
 		}
 
-		It("should reject a recursive readonly mount with PROPAGATION_HOST_TO_CONTAINER", func(ctx SpecContext) {
+		It("should reject a recursive readonly mount with PROPAGATION_HOST_TO_CONTAINER", framework.RequireCapabilities(framework.CapabilityRecursiveReadOnlyMounts), func(ctx SpecContext) {
 			testRROInvalidPropagation(ctx, runtimeapi.MountPropagation_PROPAGATION_HOST_TO_CONTAINER)
 		})
-		It("should reject a recursive readonly mount with PROPAGATION_BIDIRECTIONAL", func(ctx SpecContext) {
+		It("should reject a recursive readonly mount with PROPAGATION_BIDIRECTIONAL", framework.RequireCapabilities(framework.CapabilityRecursiveReadOnlyMounts), func(ctx SpecContext) {
 			testRROInvalidPropagation(ctx, runtimeapi.MountPropagation_PROPAGATION_BIDIRECTIONAL)
 		})
-		It("should reject a recursive readonly mount with ReadOnly: false", func(ctx SpecContext) {
-			if !runtimeSupportsRRO(ctx, rc, framework.TestContext.RuntimeHandler) {
-				Skip("runtime does not implement recursive readonly mounts")
-
-				return
-			}
-
+		It("should reject a recursive readonly mount with ReadOnly: false", framework.RequireCapabilities(framework.CapabilityRecursiveReadOnlyMounts), func(ctx SpecContext) {
 			hostPath, clearHostPath := createHostPathForRROMount(podID)
 			defer clearHostPath() // clean up the TempDir
 
@@ -446,21 +427,6 @@ var _ = framework.KubeDescribe("Container Mount Readonly", func() {
 	})
 })
 
-func runtimeSupportsRRO(ctx context.Context, rc internalapi.RuntimeService, runtimeHandlerName string) bool {
-	status, err := rc.Status(ctx, false)
-	framework.ExpectNoError(err, "failed to check runtime status")
-
-	for _, h := range status.GetRuntimeHandlers() {
-		if h.GetName() == runtimeHandlerName {
-			if f := h.GetFeatures(); f != nil {
-				return f.GetRecursiveReadOnlyMounts()
-			}
-		}
-	}
-
-	return false
-}
-
 // createHostPathForRROMount creates the hostPath for RRO mount test.
 //
 // hostPath contains a "tmpfs" directory with tmpfs mounted on it.
@@ -0,0 +1,291 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/framework"
+)
+
+const (
+	cgroupV2ControllersFile = "/sys/fs/cgroup/cgroup.controllers"
+
+	updateCPUPeriod = 100000
+	updateMemory    = 100 * 1024 * 1024
+)
+
+// cgroupFiles are the paths of the cgroup files of a container, as seen from
+// inside the container.
+type cgroupFiles struct {
+	cpuShares string
+	cpuQuota  string
+	memory    string
+	cpuset    string
+}
+
+var (
+	cgroupV1Files = cgroupFiles{
+		cpuShares: "/sys/fs/cgroup/cpu/cpu.shares",
+		cpuQuota:  "/sys/fs/cgroup/cpu/cpu.cfs_quota_us",
+		memory:    "/sys/fs/cgroup/memory/memory.limit_in_bytes",
+		cpuset:    "/sys/fs/cgroup/cpuset/cpuset.cpus",
+	}
+	cgroupV2Files = cgroupFiles{
+		cpuShares: "/sys/fs/cgroup/cpu.weight",
+		cpuQuota:  "/sys/fs/cgroup/cpu.max",
+		memory:    "/sys/fs/cgroup/memory.max",
+		cpuset:    "/sys/fs/cgroup/cpuset.cpus",
+	}
+)
+
+var _ = framework.KubeDescribe("Container Update Resources", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var (
+		rc internalapi.RuntimeService
+		ic internalapi.ImageManagerService
+	)
+
+	BeforeEach(func() {
+		rc = f.CRIClient.CRIRuntimeClient
+		ic = f.CRIClient.CRIImageClient
+	})
+
+	Context("runtime should support UpdateContainerResources", func() {
+		var (
+			podID       string
+			podConfig   *runtimeapi.PodSandboxConfig
+			containerID string
+			resources   *runtimeapi.LinuxContainerResources
+			cgroupV2    bool
+			files       cgroupFiles
+			driver      runtimeapi.CgroupDriver
+		)
+
+		BeforeEach(func(ctx SpecContext) {
+			By("check the cgroup driver of the runtime")
+
+			runtimeConfig, err := rc.RuntimeConfig(ctx)
+			framework.ExpectNoError(err, "failed to get runtime config")
+
+			driver = runtimeConfig.GetLinux().GetCgroupDriver()
+			Expect(driver).To(BeElementOf(runtimeapi.CgroupDriver_SYSTEMD, runtimeapi.CgroupDriver_CGROUPFS))
+			framework.Logf("The runtime uses the %s cgroup driver", driver)
+
+			podID, podConfig = framework.CreatePodSandboxForContainer(ctx, rc)
+
+			resources = &runtimeapi.LinuxContainerResources{
+				CpuShares:              512,
+				CpuPeriod:              updateCPUPeriod,
+				CpuQuota:               20000,
+				MemoryLimitInBytes:     updateMemory,
+				MemorySwapLimitInBytes: updateMemory,
+			}
+			containerID = createResourcesContainer(ctx, rc, ic, podID, podConfig, resources)
+			startContainer(ctx, rc, containerID)
+
+			cgroupV2 = isCgroupV2Container(ctx, rc, containerID)
+			files = cgroupV1Files
+
+			if cgroupV2 {
+				files = cgroupV2Files
+			}
+		})
+
+		AfterEach(func(ctx SpecContext) {
+			framework.CleanupPodSandbox(ctx, rc, podID)
+		})
+
+		It("runtime should create the container cgroup with its cgroup driver", func(ctx SpecContext) {
+			By("check the cgroup path of the container")
+
+			cgroupPath := containerCgroupPath(ctx, rc, containerID)
+			if cgroupPath == "/" {
+				Skip("The container has a private cgroup namespace, which hides its cgroup path")
+			}
+
+			framework.Logf("The container cgroup path is %s", cgroupPath)
+			Expect(cgroupPath).To(ContainSubstring(containerID), "the cgroup of the container should be named after its ID")
+
+			// Systemd creates a transient scope unit for every container,
+			// while cgroupfs uses plain directories.
+			if driver == runtimeapi.CgroupDriver_SYSTEMD {
+				Expect(path.Base(cgroupPath)).To(HaveSuffix(".scope"), "the container cgroup should be a systemd scope")
+			} else {
+				Expect(path.Base(cgroupPath)).NotTo(HaveSuffix(".scope"), "the container cgroup should not be a systemd scope")
+			}
+		})
+
+		It("runtime should update the CPU shares and quota of a running container", func(ctx SpecContext) {
+			resources.CpuShares = 1024
+			resources.CpuQuota = 50000
+			updateContainerResources(ctx, rc, containerID, resources)
+
+			By("check the CPU shares and quota in the container status")
+
+			status := getContainerResources(ctx, rc, containerID)
+			Expect(status.GetCpuShares()).To(Equal(resources.GetCpuShares()))
+			Expect(status.GetCpuQuota()).To(Equal(resources.GetCpuQuota()))
+			Expect(status.GetCpuPeriod()).To(Equal(resources.GetCpuPeriod()))
+
+			By("check the CPU shares and quota in the cgroup of the container")
+
+			if cgroupV2 {
+				Expect(readCgroupFile(ctx, rc, containerID, files.cpuShares)).To(BeElementOf(cpuSharesToWeights(resources.GetCpuShares())))
+				Expect(readCgroupFile(ctx, rc, containerID, files.cpuQuota)).To(Equal("50000 100000"))
+			} else {
+				Expect(readCgroupFile(ctx, rc, containerID, files.cpuShares)).To(Equal("1024"))
+				Expect(readCgroupFile(ctx, rc, containerID, files.cpuQuota)).To(Equal("50000"))
+			}
+		})
+
+		It("runtime should update the memory limit of a running container", func(ctx SpecContext) {
+			resources.MemoryLimitInBytes = 2 * updateMemory
+			resources.MemorySwapLimitInBytes = 2 * updateMemory
+			updateContainerResources(ctx, rc, containerID, resources)
+
+			By("check the memory limit in the container status")
+			Expect(getContainerResources(ctx, rc, containerID).GetMemoryLimitInBytes()).To(Equal(resources.GetMemoryLimitInBytes()))
+
+			By("check the memory limit in the cgroup of the container")
+			Expect(readCgroupFile(ctx, rc, containerID, files.memory)).To(Equal(strconv.FormatInt(resources.GetMemoryLimitInBytes(), 10)))
+		})
+
+		It("runtime should update the cpuset of a running container", func(ctx SpecContext) {
+			resources.CpusetCpus = "0"
+			updateContainerResources(ctx, rc, containerID, resources)
+
+			By("check the cpuset in the container status")
+			Expect(getContainerResources(ctx, rc, containerID).GetCpusetCpus()).To(Equal("0"))
+
+			By("check the cpuset in the cgroup of the container")
+			Expect(readCgroupFile(ctx, rc, containerID, files.cpuset)).To(Equal("0"))
+		})
+
+		It("runtime should update the unified cgroup v2 keys of a running container", func(ctx SpecContext) {
+			if !cgroupV2 {
+				Skip("The container does not use cgroup v2")
+			}
+
+			memoryHigh := strconv.Itoa(updateMemory / 2)
+			resources.Unified = map[string]string{"memory.high": memoryHigh}
+			updateContainerResources(ctx, rc, containerID, resources)
+
+			By("check the unified keys in the container status")
+			framework.ExpectSubset(getContainerResources(ctx, rc, containerID).GetUnified(), resources.GetUnified(), "unified")
+
+			By("check the unified keys in the cgroup of the container")
+			Expect(readCgroupFile(ctx, rc, containerID, "/sys/fs/cgroup/memory.high")).To(Equal(memoryHigh))
+		})
+	})
+})
+
+// createResourcesContainer creates a long running container with resources.
+func createResourcesContainer(ctx context.Context, rc internalapi.RuntimeService, ic internalapi.ImageManagerService, podID string, podConfig *runtimeapi.PodSandboxConfig, resources *runtimeapi.LinuxContainerResources) string {
+	By("create a container with resources")
+
+	containerName := "container-for-update-test-" + framework.NewUUID()
+	containerConfig := &runtimeapi.ContainerConfig{
+		Metadata: framework.BuildContainerMetadata(containerName, framework.DefaultAttempt),
+		Image:    &runtimeapi.ImageSpec{Image: framework.TestContext.TestImageList.DefaultTestContainerImage},
+		Command:  pauseCmd,
+		Linux: &runtimeapi.LinuxContainerConfig{
+			Resources: proto.CloneOf(resources),
+		},
+	}
+
+	return framework.CreateContainer(ctx, rc, ic, containerConfig, podID, podConfig)
+}
+
+// updateContainerResources updates the resources of the container. The
+// resources replace the current ones, so they have to be complete.
+func updateContainerResources(ctx context.Context, rc internalapi.RuntimeService, containerID string, resources *runtimeapi.LinuxContainerResources) {
+	By("update the resources of container " + containerID)
+
+	err := rc.UpdateContainerResources(ctx, containerID, &runtimeapi.ContainerResources{Linux: resources})
+	framework.ExpectNoError(err, "failed to update the resources of container %q", containerID)
+}
+
+// getContainerResources returns the Linux resources of the container status.
+func getContainerResources(ctx context.Context, rc internalapi.RuntimeService, containerID string) *runtimeapi.LinuxContainerResources {
+	resources := getContainerStatus(ctx, rc, containerID).GetResources().GetLinux()
+	Expect(resources).NotTo(BeNil(), "the status of container %q should contain its resources", containerID)
+
+	return resources
+}
+
+// isCgroupV2Container returns true if the container uses cgroup v2.
+func isCgroupV2Container(ctx context.Context, rc internalapi.RuntimeService, containerID string) bool {
+	output := execSyncContainer(ctx, rc, containerID, []string{
+		"sh", "-c", "if [ -f " + cgroupV2ControllersFile + " ]; then echo v2; else echo v1; fi",
+	})
+
+	return strings.TrimSpace(output) == "v2"
+}
+
+// containerCgroupPath returns the cgroup path of the container from
+// /proc/self/cgroup, which is the unified hierarchy on cgroup v2 and the
+// memory controller on cgroup v1.
+func containerCgroupPath(ctx context.Context, rc internalapi.RuntimeService, containerID string) string {
+	content := readCgroupFile(ctx, rc, containerID, "/proc/self/cgroup")
+
+	for line := range strings.Lines(content) {
+		// Every line is hierarchy-ID:controller-list:cgroup-path.
+		fields := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		if (fields[0] == "0" && fields[1] == "") || slices.Contains(strings.Split(fields[1], ","), "memory") {
+			return fields[2]
+		}
+	}
+
+	framework.Failf("No cgroup path of container %s in /proc/self/cgroup:\n%s", containerID, content)
+
+	return ""
+}
+
+// readCgroupFile returns the trimmed content of a cgroup file of the container.
+func readCgroupFile(ctx context.Context, rc internalapi.RuntimeService, containerID, path string) string {
+	return strings.TrimSpace(execSyncContainer(ctx, rc, containerID, []string{"cat", path}))
+}
+
+// cpuSharesToWeights returns the cgroup v2 CPU weights of the CPU shares.
+// Runtimes either use the linear conversion of the OCI runtime spec, or the
+// quadratic conversion of newer OCI runtimes, which maps the default shares
+// to the default weight.
+func cpuSharesToWeights(shares int64) []string {
+	linear := 1 + ((shares-2)*9999)/262142
+
+	l := math.Log2(float64(shares))
+	quadratic := int64(math.Ceil(math.Pow(10, (l*l+125*l)/612.0-7.0/34.0)))
+
+	return []string{strconv.FormatInt(linear, 10), strconv.FormatInt(quadratic, 10)}
+}
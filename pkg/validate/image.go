@@ -136,10 +136,11 @@ var _ = framework.KubeDescribe("Image Manager", func() {
 					username:    imageUserUsernameGroup,
 				},
 			} {
-				framework.PullPublicImage(ctx, c, item.image, testImagePodSandbox)
-				defer removeImage(ctx, c, item.image)
+				image := framework.WithRegistryPrefix(item.image)
+				framework.PullPublicImage(ctx, c, image, testImagePodSandbox)
+				defer removeImage(ctx, c, image)
 
-				status := framework.ImageStatus(ctx, c, item.image)
+				status := framework.ImageStatus(ctx, c, image)
 				Expect(status.GetUid().GetValue()).To(Equal(item.uid), fmt.Sprintf("%s, Image Uid should be %d", item.description, item.uid))
 				Expect(status.GetUsername()).To(Equal(item.username), fmt.Sprintf("%s, Image Username should be %s", item.description, item.username))
 			}
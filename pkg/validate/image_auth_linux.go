@@ -0,0 +1,231 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"sigs.k8s.io/cri-tools/pkg/common"
+	"sigs.k8s.io/cri-tools/pkg/framework"
+	imageregistry "sigs.k8s.io/cri-tools/pkg/registry"
+)
+
+const (
+	authTestImage         = "cri-tools/auth-test:latest"
+	authTestUsername      = "cri-tools"
+	authTestPassword      = "cri-tools-password"
+	authTestIdentityToken = "cri-tools-identity-token"
+	authTestRegistryToken = "cri-tools-registry-token"
+)
+
+// plainHTTPPullErrors are the lowercase errors of runtimes pulling only over
+// HTTPS from registries which are not configured as insecure, like CRI-O
+// without a registries.conf entry for 127.0.0.1.
+var plainHTTPPullErrors = []string{
+	"server gave http response to https client",
+	"first record does not look like a tls handshake",
+	"insecure",
+}
+
+var _ = framework.KubeDescribe("Image Pull Authentication [Feature:LocalRegistry]", func() {
+	f := framework.NewDefaultCRIFramework()
+
+	var c internalapi.ImageManagerService
+
+	BeforeEach(func() {
+		c = f.CRIClient.CRIImageClient
+	})
+
+	Context("with basic authentication", func() {
+		var imageName string
+
+		BeforeEach(func(ctx SpecContext) {
+			imageName = startAuthRegistry(ctx, c, &imageregistry.Auth{Username: authTestUsername, Password: authTestPassword})
+		})
+
+		It("pulling an image without credentials should fail", func(ctx SpecContext) {
+			expectUnauthorizedPull(ctx, c, imageName, nil)
+		})
+
+		It("should pull an image with username and password", func(ctx SpecContext) {
+			pullImageWithAuth(ctx, c, imageName, &runtimeapi.AuthConfig{
+				Username: authTestUsername,
+				Password: authTestPassword,
+			})
+		})
+
+		It("should pull an image with auth", func(ctx SpecContext) {
+			pullImageWithAuth(ctx, c, imageName, &runtimeapi.AuthConfig{
+				Auth: base64.StdEncoding.EncodeToString([]byte(authTestUsername + ":" + authTestPassword)),
+			})
+		})
+
+		It("pulling an image with a wrong password should fail", func(ctx SpecContext) {
+			expectUnauthorizedPull(ctx, c, imageName, &runtimeapi.AuthConfig{
+				Username: authTestUsername,
+				Password: "wrong-password",
+			})
+		})
+	})
+
+	Context("with bearer token authentication", func() {
+		var imageName string
+
+		BeforeEach(func(ctx SpecContext) {
+			imageName = startAuthRegistry(ctx, c, &imageregistry.Auth{
+				Username:      authTestUsername,
+				Password:      authTestPassword,
+				Bearer:        true,
+				IdentityToken: authTestIdentityToken,
+				RegistryToken: authTestRegistryToken,
+			})
+		})
+
+		It("pulling an image without credentials should fail", func(ctx SpecContext) {
+			expectUnauthorizedPull(ctx, c, imageName, nil)
+		})
+
+		It("should pull an image with username and password", func(ctx SpecContext) {
+			pullImageWithAuth(ctx, c, imageName, &runtimeapi.AuthConfig{
+				Username: authTestUsername,
+				Password: authTestPassword,
+			})
+		})
+
+		It("should pull an image with an identity token", func(ctx SpecContext) {
+			pullImageWithAuth(ctx, c, imageName, &runtimeapi.AuthConfig{
+				IdentityToken: authTestIdentityToken,
+			})
+		})
+
+		It("should pull an image with a registry token", func(ctx SpecContext) {
+			pullImageWithAuth(ctx, c, imageName, &runtimeapi.AuthConfig{
+				RegistryToken: authTestRegistryToken,
+			})
+		})
+
+		It("pulling an image with a wrong password should fail", func(ctx SpecContext) {
+			expectUnauthorizedPull(ctx, c, imageName, &runtimeapi.AuthConfig{
+				Username: authTestUsername,
+				Password: "wrong-password",
+			})
+		})
+
+		It("pulling an image with a wrong identity token should fail", func(ctx SpecContext) {
+			expectUnauthorizedPull(ctx, c, imageName, &runtimeapi.AuthConfig{
+				IdentityToken: "wrong-identity-token",
+			})
+		})
+	})
+})
+
+// startAuthRegistry starts a registry on 127.0.0.1 requiring the
+// authentication until the end of the spec, and returns the name of its
+// image. The image has a unique layer, so that it is always pulled. The spec
+// is skipped if the runtime does not pull from the registry over plain HTTP.
+func startAuthRegistry(ctx context.Context, c internalapi.ImageManagerService, auth *imageregistry.Auth) string {
+	imageEndpoint := framework.TestContext.ImageServiceAddr
+	if imageEndpoint == "" {
+		imageEndpoint = framework.TestContext.RuntimeServiceAddr
+	}
+
+	if common.IsRemoteEndpoint(imageEndpoint) {
+		Skip("The registry on 127.0.0.1 is not reachable by a remote runtime")
+	}
+
+	By("start a registry requiring authentication")
+
+	r := imageregistry.New()
+
+	_, err := r.AddImage(authTestImage, map[string][]byte{"uuid": []byte(framework.NewUUID())})
+	framework.ExpectNoError(err, "failed to add the test image")
+
+	err = r.SetAuth(auth)
+	framework.ExpectNoError(err, "failed to set the registry authentication")
+
+	err = r.Start("127.0.0.1:0")
+	framework.ExpectNoError(err, "failed to start the registry")
+	DeferCleanup(r.Close)
+
+	imageName := r.Address() + "/" + authTestImage
+	skipIfPlainHTTPUnsupported(ctx, c, imageName)
+
+	return imageName
+}
+
+// skipIfPlainHTTPUnsupported skips the spec if pulling the image without
+// credentials fails because the runtime requires HTTPS, instead of because of
+// the authentication.
+func skipIfPlainHTTPUnsupported(ctx context.Context, c internalapi.ImageManagerService, imageName string) {
+	By("probe pulling image " + imageName + " over plain HTTP")
+
+	_, err := c.PullImage(ctx, &runtimeapi.ImageSpec{Image: imageName}, nil, testImagePodSandbox)
+	if err == nil {
+		DeferCleanup(func(ctx SpecContext) {
+			removeImage(ctx, c, imageName)
+		})
+
+		return
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, plainHTTPError := range plainHTTPPullErrors {
+		if strings.Contains(message, plainHTTPError) {
+			Skip("The runtime does not pull from the plain HTTP registry on 127.0.0.1: " + err.Error())
+		}
+	}
+}
+
+// pullImageWithAuth pulls the image with the credentials and removes it at
+// the end of the spec.
+func pullImageWithAuth(ctx context.Context, c internalapi.ImageManagerService, imageName string, auth *runtimeapi.AuthConfig) {
+	By("pull image " + imageName + " with credentials")
+
+	imageRef, err := c.PullImage(ctx, &runtimeapi.ImageSpec{Image: imageName}, auth, testImagePodSandbox)
+	framework.ExpectNoError(err, "failed to pull image %q with credentials", imageName)
+	Expect(imageRef).NotTo(BeEmpty(), "PullImageResponse.image_ref should not be empty")
+
+	DeferCleanup(func(ctx SpecContext) {
+		removeImage(ctx, c, imageName)
+	})
+
+	Expect(framework.ImageStatus(ctx, c, imageName)).NotTo(BeNil(), "the image should be pulled")
+}
+
+// expectUnauthorizedPull verifies that pulling the image with the credentials
+// fails because of the authentication.
+func expectUnauthorizedPull(ctx context.Context, c internalapi.ImageManagerService, imageName string, auth *runtimeapi.AuthConfig) {
+	By("pull image " + imageName + " without valid credentials")
+
+	_, err := c.PullImage(ctx, &runtimeapi.ImageSpec{Image: imageName}, auth, testImagePodSandbox)
+	Expect(err).To(HaveOccurred(), "pulling without valid credentials should fail")
+	Expect(strings.ToLower(err.Error())).To(Or(
+		ContainSubstring("401"),
+		ContainSubstring("unauthorized"),
+		ContainSubstring("authorization"),
+		ContainSubstring("authentication required"),
+	), "pulling should fail because of the authentication: %v", err)
+
+	Expect(framework.ImageStatus(ctx, c, imageName)).To(BeNil(), "the image should not be pulled")
+}
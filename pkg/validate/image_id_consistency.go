@@ -89,6 +89,7 @@ var _ = framework.KubeDescribe("Image Identifier Consistency", func() {
 			Command: nil,
 		}
 		containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+		framework.AddSpecContainer(containerID)
 		framework.ExpectNoError(err, "failed to create container")
 
 		defer func() {
@@ -193,6 +194,7 @@ var _ = framework.KubeDescribe("Image Identifier Consistency", func() {
 			Command: nil,
 		}
 		containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+		framework.AddSpecContainer(containerID)
 		framework.ExpectNoError(err, "failed to create container")
 
 		defer func() {
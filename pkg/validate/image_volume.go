@@ -136,6 +136,7 @@ var _ = framework.KubeDescribe("Image Volume [Feature:ImageVolume]", func() {
 		}
 
 		containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+		framework.AddSpecContainer(containerID)
 		skipIfImageVolumeUnsupported(err, false)
 
 		defer func() {
@@ -215,6 +216,7 @@ var _ = framework.KubeDescribe("Image Volume [Feature:ImageVolume]", func() {
 		}
 
 		containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+		framework.AddSpecContainer(containerID)
 		skipIfImageVolumeUnsupported(err, false)
 
 		defer func() {
@@ -308,6 +310,7 @@ var _ = framework.KubeDescribe("Image Volume [Feature:ImageVolume]", func() {
 		}
 
 		containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+		framework.AddSpecContainer(containerID)
 		skipIfImageVolumeUnsupported(err, true)
 
 		// Based on the spec, runtime SHOULD reject Image Volume with Readonly=false.
@@ -368,6 +371,7 @@ var _ = framework.KubeDescribe("Image Volume [Feature:ImageVolume]", func() {
 			}
 
 			containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+			framework.AddSpecContainer(containerID)
 			skipIfImageVolumeUnsupported(err, false)
 
 			defer func() {
@@ -452,6 +456,7 @@ var _ = framework.KubeDescribe("Image Volume [Feature:ImageVolume]", func() {
 			}
 
 			containerID, err := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+			framework.AddSpecContainer(containerID)
 			skipIfImageVolumeUnsupported(err, true)
 
 			if err == nil {
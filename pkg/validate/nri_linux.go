@@ -612,6 +612,7 @@ var _ = framework.KubeDescribe("NRI", func() {
 
 			runWg.Go(func() {
 				runPodID, runErr = rc.RunPodSandbox(ctx, podConfig, framework.TestContext.RuntimeHandler)
+				framework.AddSpecPodSandbox(runPodID)
 			})
 
 			By("waiting for RunPodSandbox hook to be reached")
@@ -722,6 +723,7 @@ var _ = framework.KubeDescribe("NRI", func() {
 
 			runWg.Go(func() {
 				runPodID, runErr = rc.RunPodSandbox(ctx, podConfig, framework.TestContext.RuntimeHandler)
+				framework.AddSpecPodSandbox(runPodID)
 			})
 
 			By("waiting for RunPodSandbox hook to be reached")
@@ -1109,6 +1111,7 @@ var _ = framework.KubeDescribe("NRI", func() {
 
 			// CreateContainer on a stopped sandbox MUST fail per spec.
 			ctrID, createErr := rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+			framework.AddSpecContainer(ctrID)
 			if createErr == nil {
 				// SPEC_DISCREPANCY: containerd allows CreateContainer on a stopped
 				// sandbox instead of rejecting it. Hand the unexpectedly created
@@ -1245,6 +1248,7 @@ var _ = framework.KubeDescribe("NRI", func() {
 
 			createWg.Go(func() {
 				createdID, createErr = rc.CreateContainer(ctx, podID, containerConfig, podConfig)
+				framework.AddSpecContainer(createdID)
 			})
 
 			By("waiting for CreateContainer hook to be reached")
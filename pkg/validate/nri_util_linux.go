@@ -447,6 +447,12 @@ func StartNRITestStub(pluginName, pluginIdx string, configure ...func(*NRITestPl
 		}
 	}
 
+	// Write the events of the plugin to the diagnostics of the spec, if it
+	// fails.
+	framework.AddSpecDiagnostics("nri-events-"+pluginName, func() any {
+		return plugin.Events()
+	})
+
 	return &NRITestStub{
 		Plugin: plugin,
 		Stub:   s,
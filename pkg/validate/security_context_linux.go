@@ -26,7 +26,6 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -654,18 +653,7 @@ var _ = framework.KubeDescribe("Security Context", func() {
 		})
 	})
 
-	Context("SupplementalGroupsPolicy", func() {
-		BeforeEach(func(ctx SpecContext) {
-			By("skip if the runtime does not support SupplementalGroupsPolicy")
-
-			statusResponse, err := rc.Status(ctx, false)
-			Expect(err).NotTo(HaveOccurred())
-
-			if statusResponse.GetFeatures() == nil || !statusResponse.GetFeatures().GetSupplementalGroupsPolicy() {
-				Skip("The runtime does not support SupplementalGroupsPolicy feature")
-			}
-		})
-
+	Context("SupplementalGroupsPolicy", framework.RequireCapabilities(framework.CapabilitySupplementalGroupsPolicy), func() {
 		When("SupplementalGroupsPolicy=Merge (Default)", func() {
 			It("if the container's primary UID belongs to some groups in the image, runtime should add SupplementalGroups to them", func(ctx SpecContext) {
 				By("create pod")
@@ -1046,14 +1034,10 @@ var _ = framework.KubeDescribe("Security Context", func() {
 		})
 	})
 
-	Context("UserNamespaces", func() {
+	Context("UserNamespaces", framework.RequireCapabilities(framework.CapabilityUserNamespaces), func() {
 		var (
 			podName string
 
-			// We call rc.Status() once and save the result in statusResp.
-			statusOnce sync.Once
-			statusResp *runtimeapi.StatusResponse
-
 			defaultMapping = []*runtimeapi.IDMapping{{
 				ContainerId: 0,
 				HostId:      1000,
@@ -1061,46 +1045,13 @@ var _ = framework.KubeDescribe("Security Context", func() {
 			}}
 		)
 
-		BeforeEach(func(ctx SpecContext) {
+		BeforeEach(func() {
 			podName = "user-namespaces-pod-" + framework.NewUUID()
-
-			// Find a working runtime handler if none provided
-			By("searching for runtime handler which supports user namespaces")
-			statusOnce.Do(func() {
-				tctx, cancel := context.WithTimeout(ctx, time.Minute)
-				defer cancel()
-				// Set verbose to true, other BeforeEach calls need the info field
-				// populated.
-				// XXX: Do NOT use ":=" here, it breaks the closure reference to
-				// statusResp.
-				var err error
-
-				statusResp, err = rc.Status(tctx, true)
-				framework.ExpectNoError(err, "failed to get runtime config")
-
-				_ = statusResp // Avoid unused variable error
-			})
-
-			var supportsUserNamespaces bool
-
-			for _, rh := range statusResp.GetRuntimeHandlers() {
-				if rh.GetName() == framework.TestContext.RuntimeHandler {
-					if rh.GetFeatures().GetUserNamespaces() {
-						supportsUserNamespaces = true
-
-						break
-					}
-				}
-			}
-
-			if !supportsUserNamespaces {
-				Skip("no runtime handler found which supports user namespaces")
-			}
 		})
 
 		When("Host idmap mount support is needed", func() {
 			BeforeEach(func(ctx SpecContext) {
-				pathIDMap := rootfsPath(statusResp.GetInfo())
+				pathIDMap := rootfsPath(framework.RuntimeStatus().GetInfo())
 				if err := supportsIDMap(ctx, pathIDMap); err != nil {
 					Skip("ID mapping is not supported" + " with path: " + pathIDMap + ": " + err.Error())
 				}
@@ -1672,6 +1623,7 @@ func createContainerWithExpectation(ctx context.Context, rc internalapi.RuntimeS
 	By("Create container.")
 
 	containerID, err := rc.CreateContainer(ctx, podID, config, podConfig)
+	framework.AddSpecContainer(containerID)
 
 	if !expectContainerCreateToPass {
 		msg := fmt.Sprintf("create should fail with err %v", err)
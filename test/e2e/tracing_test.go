@@ -18,6 +18,9 @@ package e2e
 
 import (
 	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
 	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -49,6 +52,12 @@ func runTracedCommand(command string, requireSuccess bool) (spans []*v1.Span, ro
 			"but got non-zero — skipping child span assertions")
 	}
 
+	return collectedSpans(command)
+}
+
+// collectedSpans waits for spans to arrive at the collector and returns them
+// along with the root span of the command.
+func collectedSpans(command string) (spans []*v1.Span, root *v1.Span) {
 	Eventually(func() int {
 		return len(t.OtelCollector.GetSpans())
 	}, "5s", "100ms").Should(BeNumerically(">", 0))
@@ -91,4 +100,49 @@ var _ = t.Describe("tracing", func() {
 		Expect(bytes.Equal(versionChild.GetTraceId(), rootSpan.GetTraceId())).To(BeTrue(),
 			"Version child span should share the same trace ID as root span")
 	})
+
+	It("should export spans over OTLP HTTP with headers", func() {
+		t.StartOtelCollector()
+		t.Crictl("--enable-tracing --tracing-protocol=http/protobuf --tracing-endpoint=" +
+			t.OtelCollector.GetHTTPEndpoint() + " --tracing-header=x-tenant=crictl version")
+
+		collectedSpans("version")
+		Expect(t.OtelCollector.GetHeaders().Get("X-Tenant")).To(Equal("crictl"))
+	})
+
+	It("should honor the OTEL_EXPORTER_OTLP environment variables", func() {
+		t.StartOtelCollector()
+		GinkgoT().Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+		GinkgoT().Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+t.OtelCollector.GetHTTPEndpoint())
+		GinkgoT().Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-tenant=env")
+
+		t.Crictl("--enable-tracing version")
+
+		collectedSpans("version")
+		Expect(t.OtelCollector.GetHeaders().Get("X-Tenant")).To(Equal("env"))
+	})
+
+	It("should nest the spans under the TRACEPARENT of the environment", func() {
+		const (
+			traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+			parentSpanID = "00f067aa0ba902b7"
+		)
+
+		GinkgoT().Setenv("TRACEPARENT", "00-"+traceID+"-"+parentSpanID+"-01")
+
+		_, root := runTracedCommand("version", false)
+		Expect(hex.EncodeToString(root.GetTraceId())).To(Equal(traceID))
+		Expect(hex.EncodeToString(root.GetParentSpanId())).To(Equal(parentSpanID))
+	})
+
+	It("should write the spans to a file as OTLP JSON", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "spans.json")
+		t.Crictl("--enable-tracing --tracing-file=" + file + " version")
+
+		data, err := os.ReadFile(file)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(ContainSubstring(`"name":"version"`))
+		Expect(string(data)).To(MatchRegexp(`"traceId":"[0-9a-f]{32}"`))
+		Expect(string(data)).To(MatchRegexp(`"spanId":"[0-9a-f]{16}"`))
+	})
 })
@@ -124,16 +124,23 @@ func (t *TestFramework) CrictlNoWait(args string) *Session {
 // CrictlWithTracing runs crictl with tracing enabled and returns the resulting session.
 // The OtelCollector is started lazily on the first call.
 func (t *TestFramework) CrictlWithTracing(args string) *Session {
+	t.StartOtelCollector()
+
+	tracingArgs := fmt.Sprintf("--enable-tracing --tracing-endpoint=%s %s",
+		t.OtelCollector.GetEndpoint(), args)
+
+	return t.Crictl(tracingArgs)
+}
+
+// StartOtelCollector starts the OtelCollector if not running yet and clears
+// its collected spans.
+func (t *TestFramework) StartOtelCollector() {
 	if t.OtelCollector == nil {
 		t.OtelCollector = NewOtelCollector()
 		Expect(t.OtelCollector.Start()).To(Succeed())
 	}
 
 	t.OtelCollector.ClearSpans()
-	tracingArgs := fmt.Sprintf("--enable-tracing --tracing-endpoint=%s %s",
-		t.OtelCollector.GetEndpoint(), args)
-
-	return t.Crictl(tracingArgs)
 }
 
 // CrictlExpect runs crictl and expects exit, expectedOut, expectedErr.
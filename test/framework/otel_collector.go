@@ -20,22 +20,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"sync"
+	"time"
 
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	v1 "go.opentelemetry.io/proto/otlp/trace/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
 )
 
-// OtelCollector is a simple OTLP gRPC collector for testing.
+// OtelCollector is a simple OTLP gRPC and HTTP collector for testing.
 type OtelCollector struct {
 	coltracepb.UnimplementedTraceServiceServer
 
-	mu    sync.Mutex
-	spans []*v1.Span
-	port  int
-	srv   *grpc.Server
+	mu       sync.Mutex
+	spans    []*v1.Span
+	headers  http.Header
+	port     int
+	httpPort int
+	srv      *grpc.Server
+	httpSrv  *http.Server
 }
 
 // NewOtelCollector creates a new OtelCollector.
@@ -45,21 +52,31 @@ func NewOtelCollector() *OtelCollector {
 	}
 }
 
-// Start starts the collector on a random port.
-func (c *OtelCollector) Start() error {
+// listen listens on a random local port.
+func listen() (net.Listener, int, error) {
 	lc := net.ListenConfig{}
 
 	lis, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
 	if err != nil {
-		return fmt.Errorf("failed to listen: %w", err)
+		return nil, 0, fmt.Errorf("failed to listen: %w", err)
 	}
 
 	addr, ok := lis.Addr().(*net.TCPAddr)
 	if !ok {
-		return errors.New("failed to get TCP address")
+		return nil, 0, errors.New("failed to get TCP address")
 	}
 
-	c.port = addr.Port
+	return lis, addr.Port, nil
+}
+
+// Start starts the gRPC and the HTTP collector on random ports.
+func (c *OtelCollector) Start() error {
+	lis, port, err := listen()
+	if err != nil {
+		return err
+	}
+
+	c.port = port
 
 	c.srv = grpc.NewServer()
 	coltracepb.RegisterTraceServiceServer(c.srv, c)
@@ -70,14 +87,74 @@ func (c *OtelCollector) Start() error {
 		}
 	}()
 
+	httpLis, httpPort, err := listen()
+	if err != nil {
+		return err
+	}
+
+	c.httpPort = httpPort
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/traces", c.exportHTTP)
+	c.httpSrv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := c.httpSrv.Serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("HTTP collector stopped: %v\n", err)
+		}
+	}()
+
 	return nil
 }
 
+// exportHTTP implements the OTLP trace service over HTTP with protobuf
+// payloads.
+func (c *OtelCollector) exportHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	req := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	c.mu.Lock()
+	c.headers = r.Header.Clone()
+	c.mu.Unlock()
+
+	resp, err := c.Export(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(data)
+}
+
 // Stop stops the collector.
 func (c *OtelCollector) Stop() {
 	if c.srv != nil {
 		c.srv.Stop()
 	}
+
+	if c.httpSrv != nil {
+		_ = c.httpSrv.Close()
+	}
 }
 
 // Export implements the OTLP trace service.
@@ -111,9 +188,23 @@ func (c *OtelCollector) ClearSpans() {
 	defer c.mu.Unlock()
 
 	c.spans = []*v1.Span{}
+	c.headers = nil
+}
+
+// GetHeaders returns the headers of the last HTTP export request.
+func (c *OtelCollector) GetHeaders() http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.headers
 }
 
 // GetEndpoint returns the collector endpoint.
 func (c *OtelCollector) GetEndpoint() string {
 	return fmt.Sprintf("127.0.0.1:%d", c.port)
 }
+
+// GetHTTPEndpoint returns the endpoint of the HTTP collector.
+func (c *OtelCollector) GetHTTPEndpoint() string {
+	return fmt.Sprintf("127.0.0.1:%d", c.httpPort)
+}